@@ -0,0 +1,382 @@
+// Package config gathers every environment knob the bot reads into one typed
+// struct. LoadConfig is called once at startup; everything else reads the
+// result through Get.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type Config struct {
+	// Server
+	Port                string
+	APISecret           string
+	BodyLimitBytes      int64
+	ImageBodyLimitBytes int64
+
+	// Storage
+	MemoryFile         string
+	MemorySaveInterval time.Duration
+	StatsFile          string
+	AlertsFile         string
+	SchedulesFile      string
+	FeedbackFile       string
+	SubscriptionsFile  string
+	OfflineQueueFile   string
+	ArchiveDir         string
+	ArchiveMaxBytes    int64
+	ArchiveMaxAge      time.Duration
+	ContentPoolFile    string
+	PromptsDir         string
+	AuditLogFile       string
+	AuditLogMaxBytes   int64
+	AuditLogBackups    int
+
+	// Targets and access
+	NotificationTargets []string
+	RepoTargets         map[string][]string
+	NoResponseGroups    []string
+	OwnerJIDs           []string
+	AdminJIDs           []string
+	FeedbackForwardJID  string
+	AIDenylist          []string
+	AIDenylistFile      string
+
+	// Gemini
+	GeminiAPIKey       string
+	GeminiModel        string
+	GeminiImageModel   string
+	GeminiTimeout      time.Duration
+	MemoryMaxPerChat   int
+	MemoryContextTurns int
+
+	// WhatsApp sending
+	SendTimeout      time.Duration
+	SendRetries      int
+	DeliveryRetries  int
+	ImageRetries     int
+	BulkWorkers      int
+	BulkSendInterval time.Duration
+	BulkSendJitter   time.Duration
+	BulkQueueSize    int
+	BulkJobHistory   int
+	OfflineQueueSize int
+
+	// Caches
+	DedupSize            int
+	DedupTTL             time.Duration
+	IdempotencyTTL       time.Duration
+	IdempotencyCacheSize int
+	WebhookLogSize       int
+	GitHubDeliveryTTL    time.Duration
+	GitHubDeliverySize   int
+
+	// Feature flags
+	QueueOnDisconnect bool
+	ArchiveMedia      bool
+	MentionReply      bool
+	MarkRead          bool
+	AutoLanguage      bool
+	ReloginOnLogout   bool
+	SharedMemory      bool
+	ShowThinking      bool
+
+	// Commands
+	CommandCooldowns   map[string]time.Duration
+	PriceAlertInterval time.Duration
+	VoiceMaxChars      int
+	ImageMaxDimension  int
+	ImageJPEGQuality   int
+
+	// Broadcasts
+	IDXBroadcastTargets []string
+	IDXBroadcastTime    string
+
+	// Integrations
+	ViseronBaseURL       string
+	ViseronDefaultCamera string
+	ViseronTargets       []string
+	ViseronCooldown      time.Duration
+	TTSServiceURL        string
+	TTSLang              string
+	TTSMaxChars          int
+	WikiLang             string
+	PrayerAPIURL         string
+	PrayerDefaultCity    string
+	ShortenerURL         string
+	ShortenerAPIKey      string
+	FXAPIURL             string
+	NewsURL              string
+	NewsSelector         string
+	MediaDownloaderURL   string
+	MediaMaxBytes        int
+	MediaMaxDuration     int
+	MediaAllowedHosts    []string
+}
+
+var current atomic.Pointer[Config]
+
+// Get returns the active configuration. If LoadConfig has not been installed
+// with Set yet, the environment is read once with defaults for invalid values.
+func Get() *Config {
+	if cfg := current.Load(); cfg != nil {
+		return cfg
+	}
+	cfg, _ := LoadConfig()
+	current.CompareAndSwap(nil, cfg)
+	return current.Load()
+}
+
+// Set installs cfg as the active configuration.
+func Set(cfg *Config) {
+	current.Store(cfg)
+}
+
+// LoadConfig reads the configuration from the environment. Unset values get
+// their defaults. Malformed or out-of-range values are reported in the
+// returned error; the returned Config is still usable, with defaults in their
+// place. None of the settings are required, so callers should log the error
+// rather than refuse to start.
+func LoadConfig() (*Config, error) {
+	e := &envReader{}
+
+	cfg := &Config{
+		Port:                e.str("PORT", "3000"),
+		APISecret:           e.str("API_SECRET", "default-secret"),
+		BodyLimitBytes:      int64(e.int("BODY_LIMIT_MB", 5, 1, 1024)) << 20,
+		ImageBodyLimitBytes: int64(e.int("IMAGE_BODY_LIMIT_MB", 20, 1, 1024)) << 20,
+
+		MemoryFile:         e.str("MEMORY_FILE", "memory.json"),
+		MemorySaveInterval: e.seconds("MEMORY_SAVE_INTERVAL_SECONDS", 5*time.Second, 1),
+		StatsFile:          e.str("STATS_FILE", "stats.json"),
+		AlertsFile:         e.str("ALERTS_FILE", "alerts.json"),
+		SchedulesFile:      e.str("SCHEDULES_FILE", "schedules.json"),
+		FeedbackFile:       e.str("FEEDBACK_FILE", "feedback.jsonl"),
+		SubscriptionsFile:  e.str("SUBSCRIPTIONS_FILE", "subscriptions.json"),
+		OfflineQueueFile:   e.str("OFFLINE_QUEUE_FILE", "offline_queue.json"),
+		ArchiveDir:         e.str("ARCHIVE_DIR", "media_archive"),
+		ArchiveMaxBytes:    int64(e.int("ARCHIVE_MAX_MB", 1024, 0, 0)) << 20,
+		ArchiveMaxAge:      time.Duration(e.int("ARCHIVE_MAX_AGE_DAYS", 30, 0, 0)) * 24 * time.Hour,
+		ContentPoolFile:    e.str("CONTENT_POOL_FILE", ""),
+		PromptsDir:         e.str("PROMPTS_DIR", "prompts"),
+		AuditLogFile:       e.str("AUDIT_LOG", ""),
+		AuditLogMaxBytes:   int64(e.int("AUDIT_LOG_MAX_MB", 10, 1, 0)) << 20,
+		AuditLogBackups:    e.int("AUDIT_LOG_BACKUPS", 5, 0, 100),
+
+		NotificationTargets: e.list("NOTIFICATION_TARGETS", ","),
+		RepoTargets:         ParseRepoTargets(os.Getenv("REPO_TARGETS")),
+		NoResponseGroups:    e.list("NO_RESPONSE", ";"),
+		OwnerJIDs:           e.list("OWNER_JID", ","),
+		AdminJIDs:           e.list("ADMIN_JIDS", ","),
+		FeedbackForwardJID:  e.str("FEEDBACK_FORWARD_JID", ""),
+		AIDenylist:          e.list("AI_DENYLIST", ","),
+		AIDenylistFile:      e.str("AI_DENYLIST_FILE", ""),
+
+		GeminiAPIKey:       e.str("API_KEY_GEMINI", ""),
+		GeminiModel:        e.str("GEMINI_MODEL", "gemini-2.5-flash"),
+		GeminiImageModel:   e.str("GEMINI_IMAGE_MODEL", "gemini-2.5-flash-preview-image-generation"),
+		GeminiTimeout:      e.seconds("GEMINI_TIMEOUT_SECONDS", 60*time.Second, 1),
+		MemoryMaxPerChat:   e.int("MEMORY_MAX_PER_CHAT", 50, 1, 0),
+		MemoryContextTurns: e.int("MEMORY_CONTEXT_TURNS", 6, 1, 0),
+
+		SendTimeout:      e.seconds("WHATSAPP_SEND_TIMEOUT_SECONDS", 30*time.Second, 1),
+		SendRetries:      e.int("SEND_RETRIES", 2, 1, 10),
+		DeliveryRetries:  e.int("DELIVERY_RETRIES", 3, 1, 10),
+		ImageRetries:     e.int("IMAGE_RETRIES", 3, 1, 10),
+		BulkWorkers:      e.int("BULK_WORKERS", 3, 1, 0),
+		BulkSendInterval: time.Duration(e.int("BULK_SEND_INTERVAL_MS", 800, 1, 0)) * time.Millisecond,
+		BulkSendJitter:   time.Duration(e.int("BULK_SEND_JITTER_MS", 700, 1, 0)) * time.Millisecond,
+		BulkQueueSize:    e.int("BULK_QUEUE_SIZE", 10000, 1, 0),
+		BulkJobHistory:   e.int("BULK_JOB_HISTORY", 100, 1, 0),
+		OfflineQueueSize: e.int("OFFLINE_QUEUE_SIZE", 500, 1, 0),
+
+		DedupSize:            e.int("MESSAGE_DEDUP_SIZE", 1000, 1, 0),
+		DedupTTL:             e.seconds("MESSAGE_DEDUP_TTL_SECONDS", 10*time.Minute, 1),
+		IdempotencyTTL:       e.seconds("IDEMPOTENCY_TTL_SECONDS", 10*time.Minute, 1),
+		IdempotencyCacheSize: e.int("IDEMPOTENCY_CACHE_SIZE", 1000, 1, 0),
+		WebhookLogSize:       e.int("GITHUB_WEBHOOK_LOG_SIZE", 50, 1, 0),
+		GitHubDeliveryTTL:    e.seconds("GITHUB_DELIVERY_DEDUP_TTL_SECONDS", time.Hour, 1),
+		GitHubDeliverySize:   e.int("GITHUB_DELIVERY_DEDUP_SIZE", 1000, 1, 0),
+
+		QueueOnDisconnect: e.bool("QUEUE_ON_DISCONNECT", false),
+		ArchiveMedia:      e.bool("ARCHIVE_MEDIA", false),
+		MentionReply:      e.bool("MENTION_REPLY", false),
+		MarkRead:          e.bool("MARK_READ", false),
+		AutoLanguage:      e.bool("AI_AUTO_LANGUAGE", false),
+		ReloginOnLogout:   e.bool("RELOGIN_ON_LOGOUT", false),
+		SharedMemory:      e.bool("MEMORY_SHARED", false),
+		ShowThinking:      e.bool("SHOW_THINKING", true),
+
+		CommandCooldowns:   e.cooldowns("COOLDOWN_"),
+		PriceAlertInterval: time.Duration(e.int("PRICE_ALERT_INTERVAL_MINUTES", 5, 1, 0)) * time.Minute,
+		VoiceMaxChars:      e.int("VOICE_MAX_CHARS", 600, 1, 0),
+		ImageMaxDimension:  e.int("IMG_MAX_DIMENSION", 1280, 64, 8192),
+		ImageJPEGQuality:   e.int("IMG_JPEG_QUALITY", 85, 1, 100),
+
+		IDXBroadcastTargets: e.list("IDX_BROADCAST_TARGETS", ","),
+		IDXBroadcastTime:    e.str("IDX_BROADCAST_TIME", "16:30"),
+
+		ViseronBaseURL:       e.str("VISERON_BASE_URL", ""),
+		ViseronDefaultCamera: e.str("VISERON_DEFAULT_CAMERA", ""),
+		ViseronTargets:       e.list("VISERON_TARGET", ","),
+		ViseronCooldown:      e.seconds("VISERON_COOLDOWN_SECONDS", 60*time.Second, 0),
+		TTSServiceURL:        e.str("TTS_SERVICE_URL", ""),
+		TTSLang:              e.str("TTS_LANG", "id"),
+		TTSMaxChars:          e.int("TTS_MAX_CHARS", 500, 1, 0),
+		WikiLang:             strings.ToLower(e.str("WIKI_LANG", "id")),
+		PrayerAPIURL:         e.str("PRAYER_API_URL", "https://api.aladhan.com/v1/timingsByCity"),
+		PrayerDefaultCity:    e.str("PRAYER_DEFAULT_CITY", "Jakarta"),
+		ShortenerURL:         e.str("SHORTENER_URL", "https://is.gd/create.php?format=simple&url={url}"),
+		ShortenerAPIKey:      e.str("SHORTENER_API_KEY", ""),
+		FXAPIURL:             e.str("FX_API_URL", "https://open.er-api.com/v6/latest/{base}"),
+		NewsURL:              e.str("NEWS_URL", "https://www.cnbcindonesia.com/market/indeks/5"),
+		NewsSelector:         e.str("NEWS_SELECTOR", ""),
+		MediaDownloaderURL:   e.str("MEDIA_DOWNLOADER_URL", ""),
+		MediaMaxBytes:        e.int("MEDIA_MAX_MB", 16, 1, 0) * 1024 * 1024,
+		MediaMaxDuration:     e.int("MEDIA_MAX_DURATION_SECONDS", 180, 1, 0),
+		MediaAllowedHosts:    e.list("MEDIA_ALLOWED_HOSTS", ","),
+	}
+
+	if len(cfg.MediaAllowedHosts) == 0 {
+		cfg.MediaAllowedHosts = []string{"youtube.com", "youtu.be", "tiktok.com", "instagram.com"}
+	}
+	for i, h := range cfg.MediaAllowedHosts {
+		cfg.MediaAllowedHosts[i] = strings.ToLower(h)
+	}
+
+	if port, err := strconv.Atoi(cfg.Port); err != nil || port < 1 || port > 65535 {
+		e.fail("PORT", cfg.Port, "must be a port number between 1 and 65535, using 3000")
+		cfg.Port = "3000"
+	}
+	if _, err := time.Parse("15:04", cfg.IDXBroadcastTime); err != nil {
+		e.fail("IDX_BROADCAST_TIME", cfg.IDXBroadcastTime, "must be HH:MM, using 16:30")
+		cfg.IDXBroadcastTime = "16:30"
+	}
+	if cfg.MemoryContextTurns > cfg.MemoryMaxPerChat {
+		log.Printf("[config] MEMORY_CONTEXT_TURNS (%d) exceeds MEMORY_MAX_PER_CHAT (%d), clamping", cfg.MemoryContextTurns, cfg.MemoryMaxPerChat)
+		cfg.MemoryContextTurns = cfg.MemoryMaxPerChat
+	}
+	if cfg.APISecret == "default-secret" {
+		log.Println("[config] Warning: API_SECRET not set, using the insecure default")
+	}
+	if cfg.GeminiAPIKey == "" {
+		log.Println("[config] Warning: API_KEY_GEMINI environment variable not set")
+	}
+
+	return cfg, errors.Join(e.errs...)
+}
+
+// ParseRepoTargets parses "owner/repo1:jid1,jid2;owner/repo2:jid3" into a map keyed by
+// lower-cased repository full name. Malformed entries are skipped.
+func ParseRepoTargets(raw string) map[string][]string {
+	result := make(map[string][]string)
+
+	for _, entry := range strings.Split(raw, ";") {
+		repo, targetList, found := strings.Cut(strings.TrimSpace(entry), ":")
+		repo = strings.ToLower(strings.TrimSpace(repo))
+		if !found || repo == "" {
+			continue
+		}
+
+		for _, target := range strings.Split(targetList, ",") {
+			if target = strings.TrimSpace(target); target != "" {
+				result[repo] = append(result[repo], target)
+			}
+		}
+	}
+	return result
+}
+
+// envReader reads typed values and collects every validation error so a bad
+// .env is reported in one go.
+type envReader struct {
+	errs []error
+}
+
+func (e *envReader) fail(name, val, reason string) {
+	e.errs = append(e.errs, fmt.Errorf("%s=%q: %s", name, val, reason))
+}
+
+func (e *envReader) str(name, def string) string {
+	if val := strings.TrimSpace(os.Getenv(name)); val != "" {
+		return val
+	}
+	return def
+}
+
+// int reads an integer in [min, max]; max 0 means unbounded.
+func (e *envReader) int(name string, def, min, max int) int {
+	val := strings.TrimSpace(os.Getenv(name))
+	if val == "" {
+		return def
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		e.fail(name, val, fmt.Sprintf("not an integer, using %d", def))
+		return def
+	}
+	if n < min || (max > 0 && n > max) {
+		if max > 0 {
+			e.fail(name, val, fmt.Sprintf("must be between %d and %d, using %d", min, max, def))
+		} else {
+			e.fail(name, val, fmt.Sprintf("must be at least %d, using %d", min, def))
+		}
+		return def
+	}
+	return n
+}
+
+func (e *envReader) seconds(name string, def time.Duration, min int) time.Duration {
+	return time.Duration(e.int(name, int(def/time.Second), min, 0)) * time.Second
+}
+
+func (e *envReader) bool(name string, def bool) bool {
+	val := strings.TrimSpace(os.Getenv(name))
+	if val == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		e.fail(name, val, fmt.Sprintf("must be true or false, using %t", def))
+		return def
+	}
+	return b
+}
+
+func (e *envReader) list(name, sep string) []string {
+	var result []string
+	for _, part := range strings.Split(os.Getenv(name), sep) {
+		if part = strings.TrimSpace(part); part != "" {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+// cooldowns collects COOLDOWN_<CMD>=seconds overrides keyed by lower-cased
+// command name. 0 disables the cooldown for that command. Other variables that
+// happen to share the prefix are reported and ignored.
+func (e *envReader) cooldowns(prefix string) map[string]time.Duration {
+	result := make(map[string]time.Duration)
+	for _, kv := range os.Environ() {
+		name, val, _ := strings.Cut(kv, "=")
+		if !strings.HasPrefix(name, prefix) || len(name) == len(prefix) {
+			continue
+		}
+		val = strings.TrimSpace(val)
+		secs, err := strconv.Atoi(val)
+		if err != nil || secs < 0 {
+			e.fail(name, val, "not a cooldown in seconds, ignored")
+			continue
+		}
+		result[strings.ToLower(strings.TrimPrefix(name, prefix))] = time.Duration(secs) * time.Second
+	}
+	return result
+}
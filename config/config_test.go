@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadConfigFallsBackOnInvalidValues(t *testing.T) {
+	t.Setenv("GEMINI_TIMEOUT_SECONDS", "soon")
+	t.Setenv("SEND_RETRIES", "99")
+	t.Setenv("MARK_READ", "maybe")
+	t.Setenv("PORT", "http")
+	t.Setenv("COOLDOWN_IMG", "45")
+	t.Setenv("COOLDOWN_MODE", "strict")
+
+	cfg, err := LoadConfig()
+	if err == nil {
+		t.Error("expected the invalid values to be reported")
+	}
+	if cfg == nil {
+		t.Fatal("LoadConfig returned a nil Config")
+	}
+
+	if cfg.GeminiTimeout != 60*time.Second {
+		t.Errorf("GeminiTimeout = %v, want 60s", cfg.GeminiTimeout)
+	}
+	if cfg.SendRetries != 2 {
+		t.Errorf("SendRetries = %d, want 2", cfg.SendRetries)
+	}
+	if cfg.MarkRead {
+		t.Error("MarkRead = true, want the default false")
+	}
+	if cfg.Port != "3000" {
+		t.Errorf("Port = %q, want 3000", cfg.Port)
+	}
+	if got := cfg.CommandCooldowns["img"]; got != 45*time.Second {
+		t.Errorf("CommandCooldowns[img] = %v, want 45s", got)
+	}
+	if _, ok := cfg.CommandCooldowns["mode"]; ok {
+		t.Error("unrelated COOLDOWN_MODE should be ignored")
+	}
+}
+
+func TestParseRepoTargets(t *testing.T) {
+	got := ParseRepoTargets(" Owner/Repo1:jid1, jid2 ; owner/repo2:jid3;broken;:jid4;owner/repo3: ")
+
+	want := map[string][]string{
+		"owner/repo1": {"jid1", "jid2"},
+		"owner/repo2": {"jid3"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("ParseRepoTargets() = %v, want %v", got, want)
+	}
+	for repo, targets := range want {
+		if len(got[repo]) != len(targets) {
+			t.Errorf("%s: got %v, want %v", repo, got[repo], targets)
+			continue
+		}
+		for i := range targets {
+			if got[repo][i] != targets[i] {
+				t.Errorf("%s[%d] = %q, want %q", repo, i, got[repo][i], targets[i])
+			}
+		}
+	}
+}
@@ -5,15 +5,48 @@ import (
 )
 
 type SendRequest struct {
-	Secret  string `json:"secret"`
-	Target  string `json:"target"`
-	Message string `json:"message"`
+	Secret         string `json:"secret"`
+	Target         string `json:"target"`
+	Message        string `json:"message"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+type SendImageRequest struct {
+	Secret         string `json:"secret"`
+	Target         string `json:"target"`
+	Image          string `json:"image"`
+	Caption        string `json:"caption"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+}
+
+type SendLocationRequest struct {
+	Secret         string   `json:"secret"`
+	Target         string   `json:"target"`
+	Latitude       *float64 `json:"lat"`
+	Longitude      *float64 `json:"lng"`
+	Name           string   `json:"name"`
+	IdempotencyKey string   `json:"idempotency_key,omitempty"`
+}
+
+type ButtonOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type SendButtonsRequest struct {
+	Secret         string         `json:"secret"`
+	Target         string         `json:"target"`
+	Text           string         `json:"text"`
+	Footer         string         `json:"footer"`
+	Buttons        []ButtonOption `json:"buttons"`
+	IdempotencyKey string         `json:"idempotency_key,omitempty"`
 }
 
 type BulkMessageRequest struct {
-	Secret  string   `json:"secret"`
-	Targets []string `json:"targets"`
-	Message string   `json:"message"`
+	Secret         string   `json:"secret"`
+	Targets        []string `json:"targets"`
+	Message        string   `json:"message"`
+	IdempotencyKey string   `json:"idempotency_key,omitempty"`
 }
 
 type BulkDifferentMessageRequest struct {
@@ -22,6 +55,7 @@ type BulkDifferentMessageRequest struct {
 		Targets string `json:"targets"`
 		Message string `json:"message"`
 	} `json:"messages"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 type GitHubWebhookPayload struct {
@@ -84,6 +118,53 @@ type PullRequest struct {
 	Merged  bool   `json:"merged"`
 }
 
+type GitLabWebhookPayload struct {
+	ObjectKind        string                 `json:"object_kind"`
+	Ref               string                 `json:"ref,omitempty"`
+	UserName          string                 `json:"user_name,omitempty"`
+	UserUsername      string                 `json:"user_username,omitempty"`
+	User              *GitLabUser            `json:"user,omitempty"`
+	Project           GitLabProject          `json:"project"`
+	Commits           []GitLabCommit         `json:"commits,omitempty"`
+	TotalCommitsCount int                    `json:"total_commits_count,omitempty"`
+	ObjectAttributes  *GitLabMergeAttributes `json:"object_attributes,omitempty"`
+}
+
+type GitLabUser struct {
+	Name     string `json:"name"`
+	Username string `json:"username"`
+}
+
+type GitLabProject struct {
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type GitLabCommit struct {
+	ID       string   `json:"id"`
+	Message  string   `json:"message"`
+	Title    string   `json:"title"`
+	URL      string   `json:"url"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+	Author   struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"author"`
+}
+
+type GitLabMergeAttributes struct {
+	IID          int    `json:"iid"`
+	Title        string `json:"title"`
+	URL          string `json:"url"`
+	State        string `json:"state"`
+	Action       string `json:"action"`
+	SourceBranch string `json:"source_branch"`
+	TargetBranch string `json:"target_branch"`
+}
+
 type MessageEvent = events.Message
 
 type ViseronPayload struct {
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"whatsmeow-api/config"
+	"whatsmeow-api/utils"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// adminCommands lists the commands that are restricted to ADMIN_JIDS.
+// broadcast and reminder are reserved so they are gated as soon as they exist.
+var adminCommands = map[string]bool{
+	"groups":    true,
+	"broadcast": true,
+	"reminder":  true,
+	"schedule":  true,
+	"setname":   true,
+	"remindall": true,
+}
+
+// getAdminJIDs returns the configured admin identifiers. OWNER_JID entries
+// are treated as admins as well.
+func getAdminJIDs() []string {
+	cfg := config.Get()
+	admins := make([]string, 0, len(cfg.AdminJIDs)+len(cfg.OwnerJIDs))
+	admins = append(admins, cfg.AdminJIDs...)
+	return append(admins, cfg.OwnerJIDs...)
+}
+
+// isAdmin reports whether sender matches one of the configured admins. Entries
+// may be phone numbers, full JIDs or LIDs.
+func isAdmin(sender types.JID) bool {
+	base := sender.ToNonAD()
+	for _, candidate := range getAdminJIDs() {
+		candidateJID := utils.CreateTargetJID(candidate)
+		if base.User == candidateJID.User ||
+			base.String() == candidateJID.String() ||
+			base.String() == candidate {
+			return true
+		}
+	}
+	return false
+}
+
+// requireAdmin replies with a refusal and returns false when the sender of v
+// is not an admin.
+func requireAdmin(v *events.Message, command string) bool {
+	if isAdmin(v.Info.Sender) {
+		return true
+	}
+	log.Printf("[Admin] Rejected !%s from non-admin %s", command, v.Info.Sender.String())
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Maaf, perintah ini hanya untuk admin.", utils.SendRetries())
+	return false
+}
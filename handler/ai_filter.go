@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"bufio"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+
+	"whatsmeow-api/config"
+)
+
+const aiBlockedReply = "[Info] Maaf, topik tersebut tidak dapat dibahas oleh asisten di sini."
+
+var (
+	aiDenylistOnce sync.Once
+	aiDenylist     []*regexp.Regexp
+)
+
+// loadAIDenylist compiles the case-insensitive patterns from AI_DENYLIST
+// (comma-separated) and AI_DENYLIST_FILE (one per line, # for comments).
+// Invalid patterns are logged and skipped.
+func loadAIDenylist() []*regexp.Regexp {
+	aiDenylistOnce.Do(func() {
+		patterns := append([]string(nil), config.Get().AIDenylist...)
+
+		if path := config.Get().AIDenylistFile; path != "" {
+			f, err := os.Open(path)
+			if err != nil {
+				log.Printf("[ai-filter] Failed to open %s: %v", path, err)
+			} else {
+				scanner := bufio.NewScanner(f)
+				for scanner.Scan() {
+					patterns = append(patterns, scanner.Text())
+				}
+				f.Close()
+			}
+		}
+
+		for _, p := range patterns {
+			p = strings.TrimSpace(p)
+			if p == "" || strings.HasPrefix(p, "#") {
+				continue
+			}
+			re, err := regexp.Compile("(?i)" + p)
+			if err != nil {
+				log.Printf("[ai-filter] Skipping invalid pattern %q: %v", p, err)
+				continue
+			}
+			aiDenylist = append(aiDenylist, re)
+		}
+
+		if len(aiDenylist) > 0 {
+			log.Printf("[ai-filter] Loaded %d denylist pattern(s)", len(aiDenylist))
+		}
+	})
+	return aiDenylist
+}
+
+// matchesAIDenylist reports whether text matches any denylisted pattern.
+func matchesAIDenylist(text string) bool {
+	for _, re := range loadAIDenylist() {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
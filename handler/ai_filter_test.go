@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"whatsmeow-api/config"
+)
+
+func TestMatchesAIDenylist(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "denylist.txt")
+	content := "# comments and blank lines are ignored\n\njudi\\s+online\n[unclosed\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatalf("write denylist: %v", err)
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.AIDenylist = []string{"password", " "}
+	cfg.AIDenylistFile = file
+	config.Set(cfg)
+
+	resetDenylist := func() {
+		aiDenylistOnce = sync.Once{}
+		aiDenylist = nil
+	}
+	resetDenylist()
+	t.Cleanup(resetDenylist)
+
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"berapa PASSWORD wifi kantor?", true},
+		{"situs Judi   Online terbaik", true},
+		{"judionline", false},
+		{"harga saham BBCA hari ini", false},
+		{"[unclosed", false},
+	}
+	for _, tt := range tests {
+		if got := matchesAIDenylist(tt.text); got != tt.want {
+			t.Errorf("matchesAIDenylist(%q) = %t, want %t", tt.text, got, tt.want)
+		}
+	}
+	if n := len(loadAIDenylist()); n != 2 {
+		t.Errorf("loaded %d patterns, want 2 (the invalid one is skipped)", n)
+	}
+}
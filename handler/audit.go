@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/services/audit"
+	"whatsmeow-api/utils"
+)
+
+// Audit statuses. A command is "error" when its handler reported a failure
+// through replyError or markCommandFailed, "ok" otherwise.
+const (
+	auditOK       = "ok"
+	auditError    = "error"
+	auditUnknown  = "unknown"
+	auditDenied   = "denied"
+	auditCooldown = "cooldown"
+	auditPanic    = "panic"
+)
+
+// failedCommands holds the IDs of messages whose command has failed; EventHandler
+// takes the mark after dispatch to pick the audit status.
+var failedCommands sync.Map
+
+// markCommandFailed records that the command in v failed.
+func markCommandFailed(v *events.Message) {
+	failedCommands.Store(v.Info.ID, struct{}{})
+}
+
+// takeCommandFailed reports whether the command in v was marked as failed and
+// clears the mark.
+func takeCommandFailed(v *events.Message) bool {
+	_, failed := failedCommands.LoadAndDelete(v.Info.ID)
+	return failed
+}
+
+// replyError sends an error reply to the command's chat and marks the command
+// as failed.
+func replyError(v *events.Message, message string) {
+	markCommandFailed(v)
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, utils.SendRetries())
+}
+
+// auditCommand records an executed command when AUDIT_LOG is set. Plain
+// messages (command == "") are not recorded.
+func auditCommand(v *events.Message, command, status string, start time.Time) {
+	if audit.Log == nil || command == "" {
+		return
+	}
+	err := audit.Log.Append(audit.Entry{
+		Sender:     v.Info.Sender.ToNonAD().String(),
+		Chat:       v.Info.Chat.String(),
+		Command:    command,
+		Status:     status,
+		DurationMs: time.Since(start).Milliseconds(),
+	})
+	if err != nil {
+		log.Printf("[audit] Failed to record !%s: %v", command, err)
+	}
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/services/audit"
+)
+
+func TestEventHandlerAuditsCommandOutcome(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.CommandCooldowns = map[string]time.Duration{}
+	config.Set(cfg)
+
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := audit.InitAudit(path, 1<<20, 1); err != nil {
+		t.Fatalf("InitAudit: %v", err)
+	}
+	t.Cleanup(func() { audit.Log = nil })
+
+	orig := commandHandlers["ping"]
+	commandHandlers["ping"] = func(v *events.Message, message string) {
+		if message == "!ping fail" {
+			replyError(v, "[Error] gagal")
+		}
+	}
+	t.Cleanup(func() { commandHandlers["ping"] = orig })
+
+	EventHandler(testMessage("628400000001", "!ping"))
+	EventHandler(testMessage("628400000001", "!ping fail"))
+	EventHandler(testMessage("628400000001", "!pingx"))
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer f.Close()
+
+	var got []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var e audit.Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			t.Fatalf("decode %q: %v", sc.Text(), err)
+		}
+		got = append(got, e.Command+":"+e.Status)
+	}
+
+	want := []string{"ping:ok", "ping:error", "pingx:unknown"}
+	if len(got) != len(want) {
+		t.Fatalf("audit entries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entry %d = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
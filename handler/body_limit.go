@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"whatsmeow-api/config"
+)
+
+// imageBodyPaths accept base64 media in the body and get the larger limit.
+var imageBodyPaths = map[string]bool{
+	"/send-image": true,
+}
+
+func bodyLimitFor(path string) int64 {
+	if imageBodyPaths[path] {
+		return config.Get().ImageBodyLimitBytes
+	}
+	return config.Get().BodyLimitBytes
+}
+
+// bodyLimitMiddleware caps request bodies at BODY_LIMIT_MB (IMAGE_BODY_LIMIT_MB for
+// image endpoints). Requests that declare a larger Content-Length are rejected up
+// front; chunked bodies fail with *http.MaxBytesError once they cross the limit.
+func bodyLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		limit := bodyLimitFor(r.URL.Path)
+		if r.ContentLength > limit {
+			log.Printf("[http %s] Rejected %s %s: body of %d bytes exceeds %d", requestIDFrom(r.Context()), r.Method, r.URL.Path, r.ContentLength, limit)
+			writePayloadTooLarge(w, limit)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.As(err, &maxErr)
+}
+
+func writePayloadTooLarge(w http.ResponseWriter, limit int64) {
+	writeErrorWithFields(w, http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Request body too large", map[string]interface{}{
+		"limit_bytes": limit,
+	})
+}
+
+// writeBodyError reports a failure to read or decode the request body: 413 when
+// the body hit the size limit, 400 otherwise.
+func writeBodyError(w http.ResponseWriter, r *http.Request, err error) {
+	if isBodyTooLarge(err) {
+		writePayloadTooLarge(w, bodyLimitFor(r.URL.Path))
+		return
+	}
+	writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+type contentPool struct {
+	Jokes  []string `json:"jokes"`
+	Quotes []string `json:"quotes"`
+}
+
+var defaultContentPool = contentPool{
+	Jokes: []string{
+		"Kenapa programmer suka gelap? Karena light mode bikin bug kelihatan semua.",
+		"Ikan apa yang paling sibuk? Ikan kerja bakti.",
+		"Sayur apa yang paling dingin? Kembang kol... kembang kolkas.",
+		"Kenapa matahari tidak pernah sekolah? Karena sudah terang.",
+		"Hewan apa yang paling kuat? Kuda-kudaan, karena bisa dinaiki anak kecil seharian tanpa capek.",
+		"Apa bedanya kamu dengan kalender? Kalender punya tanggal, kamu belum punya tanggal jadian.",
+		"Kenapa nyamuk suka gigit manusia? Karena kalau gigit batu, giginya copot.",
+		"Buah apa yang paling pintar menyanyi? Buah anggur... eh, anggur-anggur merdu.",
+		"Kenapa Wi-Fi di rumah nenek lemot? Karena nenek pakai password 'sabar'.",
+		"Tau nggak kenapa air laut asin? Karena ikannya lupa bawa gula.",
+	},
+	Quotes: []string{
+		"\"Kesuksesan adalah hasil dari persiapan, kerja keras, dan belajar dari kegagalan.\" - Colin Powell",
+		"\"Jangan menunggu kesempatan, ciptakanlah.\" - George Bernard Shaw",
+		"\"Pendidikan adalah senjata paling ampuh untuk mengubah dunia.\" - Nelson Mandela",
+		"\"Bermimpilah setinggi langit, jika engkau jatuh, engkau akan jatuh di antara bintang-bintang.\" - Soekarno",
+		"\"Hidup itu seperti bersepeda. Untuk menjaga keseimbangan, kamu harus terus bergerak.\" - Albert Einstein",
+		"\"Orang boleh pandai setinggi langit, tapi selama ia tidak menulis, ia akan hilang di dalam masyarakat dan dari sejarah.\" - Pramoedya Ananta Toer",
+		"\"Satu-satunya cara untuk melakukan pekerjaan hebat adalah mencintai apa yang kamu lakukan.\" - Steve Jobs",
+		"\"Habis gelap terbitlah terang.\" - R.A. Kartini",
+		"\"Ing ngarso sung tulodo, ing madyo mangun karso, tut wuri handayani.\" - Ki Hajar Dewantara",
+		"\"Lebih baik terlambat daripada tidak sama sekali, tetapi lebih baik tidak terlambat.\"",
+	},
+}
+
+var (
+	contentPoolOnce   sync.Once
+	loadedContentPool contentPool
+
+	contentRandMu sync.Mutex
+	contentRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// getContentPool returns the joke/quote pool, loading CONTENT_POOL_FILE once if configured.
+// Categories missing from the file fall back to the built-in content.
+func getContentPool() contentPool {
+	contentPoolOnce.Do(func() {
+		loadedContentPool = defaultContentPool
+
+		path := config.Get().ContentPoolFile
+		if path == "" {
+			return
+		}
+
+		b, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[content] Failed to read %s: %v (using built-in pool)", path, err)
+			return
+		}
+
+		var custom contentPool
+		if err := json.Unmarshal(b, &custom); err != nil {
+			log.Printf("[content] Failed to parse %s: %v (using built-in pool)", path, err)
+			return
+		}
+
+		if len(custom.Jokes) > 0 {
+			loadedContentPool.Jokes = custom.Jokes
+		}
+		if len(custom.Quotes) > 0 {
+			loadedContentPool.Quotes = custom.Quotes
+		}
+		log.Printf("[content] Loaded %d jokes and %d quotes from %s", len(custom.Jokes), len(custom.Quotes), path)
+	})
+	return loadedContentPool
+}
+
+func pickRandom(items []string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	contentRandMu.Lock()
+	defer contentRandMu.Unlock()
+	return items[contentRand.Intn(len(items))]
+}
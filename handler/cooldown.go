@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/utils"
+)
+
+// defaultCooldowns applies to the expensive commands when no COOLDOWN_<COMMAND>
+// env var is set. Any other command can be given a cooldown through env alone.
+var defaultCooldowns = map[string]time.Duration{
+	"idx": 60 * time.Second,
+	"img": 30 * time.Second,
+}
+
+const cooldownPruneThreshold = 1000
+
+var (
+	cooldownMu    sync.Mutex
+	cooldownUntil = make(map[string]time.Time)
+)
+
+// commandCooldown returns the cooldown for command, overridden by COOLDOWN_<COMMAND>
+// in seconds. "0" disables the cooldown.
+func commandCooldown(command string) time.Duration {
+	if cooldown, ok := config.Get().CommandCooldowns[command]; ok {
+		return cooldown
+	}
+	return defaultCooldowns[command]
+}
+
+// takeCooldown reserves command in chat until now+cooldown. It returns false and
+// the remaining wait when the command is still cooling down.
+func takeCooldown(chat, command string, cooldown time.Duration, now time.Time) (bool, time.Duration) {
+	key := chat + "|" + command
+
+	cooldownMu.Lock()
+	defer cooldownMu.Unlock()
+
+	if until, ok := cooldownUntil[key]; ok && now.Before(until) {
+		return false, until.Sub(now)
+	}
+
+	if len(cooldownUntil) >= cooldownPruneThreshold {
+		for k, until := range cooldownUntil {
+			if !now.Before(until) {
+				delete(cooldownUntil, k)
+			}
+		}
+	}
+	cooldownUntil[key] = now.Add(cooldown)
+	return true, 0
+}
+
+// enforceCommandCooldown replies with the remaining wait and returns false when command
+// is on cooldown in the chat of v.
+func enforceCommandCooldown(v *events.Message, command string) bool {
+	cooldown := commandCooldown(command)
+	if command == "" || cooldown <= 0 {
+		return true
+	}
+
+	ok, remaining := takeCooldown(v.Info.Chat.String(), command, cooldown, time.Now())
+	if ok {
+		return true
+	}
+
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	log.Printf("[Cooldown] !%s in %s blocked for another %ds", command, v.Info.Chat.String(), seconds)
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Info] Perintah !%s sedang cooldown. Coba lagi dalam %d detik.", command, seconds), utils.SendRetries())
+	return false
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+)
+
+func TestTakeCooldown(t *testing.T) {
+	now := time.Now()
+	chat := "cooldown-test@s.whatsapp.net"
+
+	if ok, _ := takeCooldown(chat, "idx", time.Minute, now); !ok {
+		t.Fatal("first call should not be on cooldown")
+	}
+
+	ok, remaining := takeCooldown(chat, "idx", time.Minute, now.Add(20*time.Second))
+	if ok {
+		t.Fatal("second call inside the window should be on cooldown")
+	}
+	if remaining != 40*time.Second {
+		t.Errorf("remaining = %v, want 40s", remaining)
+	}
+
+	if ok, _ := takeCooldown(chat, "img", time.Minute, now.Add(20*time.Second)); !ok {
+		t.Error("a different command in the same chat should not share the cooldown")
+	}
+	if ok, _ := takeCooldown("other@s.whatsapp.net", "idx", time.Minute, now.Add(20*time.Second)); !ok {
+		t.Error("the same command in another chat should not share the cooldown")
+	}
+
+	if ok, _ := takeCooldown(chat, "idx", time.Minute, now.Add(time.Minute)); !ok {
+		t.Error("call after the window should be allowed again")
+	}
+}
+
+func TestEventHandlerCooldownKeysOnDispatchedCommand(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.CommandCooldowns = map[string]time.Duration{"img": time.Hour, "roll": time.Hour}
+	config.Set(cfg)
+
+	calls := map[string]int{}
+	for _, command := range []string{"img", "roll"} {
+		command := command
+		orig := commandHandlers[command]
+		commandHandlers[command] = func(v *events.Message, message string) { calls[command]++ }
+		t.Cleanup(func() { commandHandlers[command] = orig })
+	}
+
+	tests := []struct {
+		name    string
+		sender  string
+		texts   []string
+		command string
+		want    int
+	}{
+		{"second call inside the window is blocked", "628300000001", []string{"!img kucing", "!img anjing"}, "img", 1},
+		{"suffix is not dispatched and takes no cooldown", "628300000002", []string{"!imgx kucing", "!imgx", "!img kucing"}, "img", 1},
+		{"alias shares the cooldown of its command", "628300000003", []string{"!roll 2d6", "!dice 2d6"}, "roll", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = map[string]int{}
+			for _, text := range tt.texts {
+				EventHandler(testMessage(tt.sender, text))
+			}
+			if calls[tt.command] != tt.want {
+				t.Errorf("!%s handler ran %d times, want %d", tt.command, calls[tt.command], tt.want)
+			}
+		})
+	}
+
+	cooldownMu.Lock()
+	defer cooldownMu.Unlock()
+	for key := range cooldownUntil {
+		if key == "628300000002@s.whatsapp.net|imgx" {
+			t.Errorf("unknown command reserved a cooldown: %s", key)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+type seenMessage struct {
+	id     string
+	seenAt time.Time
+}
+
+// seenSet is a bounded set of recently seen IDs. Entries expire after a TTL and
+// the oldest are evicted first once the set is full.
+type seenSet struct {
+	mu    sync.Mutex
+	ids   map[string]time.Time
+	order []seenMessage
+}
+
+func newSeenSet() *seenSet {
+	return &seenSet{ids: make(map[string]time.Time)}
+}
+
+// mark records id and reports whether it was already seen within ttl.
+func (s *seenSet) mark(id string, ttl time.Duration, size int) bool {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// order is sorted by seenAt, so expired entries are always at the front
+	for len(s.order) > 0 && (now.Sub(s.order[0].seenAt) > ttl || len(s.order) >= size) {
+		oldest := s.order[0]
+		if seenAt, ok := s.ids[oldest.id]; ok && seenAt.Equal(oldest.seenAt) {
+			delete(s.ids, oldest.id)
+		}
+		s.order = s.order[1:]
+	}
+
+	if seenAt, ok := s.ids[id]; ok && now.Sub(seenAt) <= ttl {
+		return true
+	}
+
+	s.ids[id] = now
+	s.order = append(s.order, seenMessage{id: id, seenAt: now})
+	return false
+}
+
+// forget removes id so a later retry is processed again. Its entry in order is
+// skipped on eviction because the timestamps no longer match.
+func (s *seenSet) forget(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.ids, id)
+}
+
+var processedMessages = newSeenSet()
+
+func getDedupSize() int {
+	return config.Get().DedupSize
+}
+
+func getDedupTTL() time.Duration {
+	return config.Get().DedupTTL
+}
+
+// markMessageProcessed records a message ID and reports whether it was already seen
+// within the TTL. The set is bounded; the oldest entries are evicted first.
+func markMessageProcessed(id string) bool {
+	if id == "" {
+		return false
+	}
+	return processedMessages.mark(id, getDedupTTL(), getDedupSize())
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Stable machine-readable error codes returned in {"error": {"code", "message"}}.
+// Clients should branch on the code; the message is for humans and may change.
+const (
+	ErrCodeUnauthorized       = "UNAUTHORIZED"
+	ErrCodeInvalidRequest     = "INVALID_REQUEST"
+	ErrCodeInvalidTarget      = "INVALID_TARGET"
+	ErrCodeInvalidImage       = "INVALID_IMAGE"
+	ErrCodeInvalidCoordinates = "INVALID_COORDINATES"
+	ErrCodeInvalidButtons     = "INVALID_BUTTONS"
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	ErrCodeNotConnected       = "NOT_CONNECTED"
+	ErrCodeQueueFailed        = "QUEUE_FAILED"
+	ErrCodeSendFailed         = "SEND_FAILED"
+	ErrCodeNotFound           = "NOT_FOUND"
+	ErrCodeInProgress         = "IN_PROGRESS"
+	ErrCodeQRUnavailable      = "QR_UNAVAILABLE"
+	ErrCodeReconnectFailed    = "RECONNECT_FAILED"
+	ErrCodeUpstreamFailed     = "UPSTREAM_FAILED"
+	ErrCodeInternal           = "INTERNAL_ERROR"
+)
+
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// writeError writes a {"error": {"code": ..., "message": ...}} body with status,
+// plus the request_id set by requestIDMiddleware.
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeErrorWithFields(w, status, code, message, nil)
+}
+
+// writeErrorWithFields is writeError with extra top-level fields, for responses
+// that also echo back context such as the target.
+func writeErrorWithFields(w http.ResponseWriter, status int, code, message string, fields map[string]interface{}) {
+	body := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		body[k] = v
+	}
+	body["error"] = apiError{Code: code, Message: message}
+	writeJSON(w, status, body)
+}
+
+// writeJSON writes body as a JSON response with status. Every JSON response,
+// success or error, carries the request_id set by requestIDMiddleware unless
+// body already has one.
+func writeJSON(w http.ResponseWriter, status int, body map[string]interface{}) {
+	if _, exists := body["request_id"]; !exists {
+		if id := w.Header().Get("X-Request-ID"); id != "" {
+			body["request_id"] = id
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
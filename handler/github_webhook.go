@@ -8,13 +8,63 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"whatsmeow-api/config"
 	"whatsmeow-api/domain"
 	"whatsmeow-api/utils"
 	"whatsmeow-api/whatsapp"
 )
 
+type githubDelivery struct {
+	DeliveryID   string    `json:"delivery_id,omitempty"`
+	Event        string    `json:"event"`
+	Repository   string    `json:"repository"`
+	ReceivedAt   time.Time `json:"received_at"`
+	Status       string    `json:"status"`
+	TargetSource string    `json:"target_source,omitempty"`
+	Targets      []string  `json:"targets"`
+	SuccessCount int       `json:"success_count"`
+}
+
+var (
+	githubDeliveryMu  sync.Mutex
+	githubDeliveryLog []githubDelivery
+)
+
+// handledGitHubDeliveries holds recent X-GitHub-Delivery IDs so a delivery
+// GitHub retries after a timeout doesn't notify twice.
+var handledGitHubDeliveries = newSeenSet()
+
+func getWebhookLogSize() int {
+	return config.Get().WebhookLogSize
+}
+
+func recordGitHubDelivery(entry githubDelivery) {
+	limit := getWebhookLogSize()
+
+	githubDeliveryMu.Lock()
+	defer githubDeliveryMu.Unlock()
+
+	githubDeliveryLog = append(githubDeliveryLog, entry)
+	if over := len(githubDeliveryLog) - limit; over > 0 {
+		githubDeliveryLog = append([]githubDelivery(nil), githubDeliveryLog[over:]...)
+	}
+}
+
+func getGitHubDeliveries() []githubDelivery {
+	githubDeliveryMu.Lock()
+	defer githubDeliveryMu.Unlock()
+
+	// Newest first
+	out := make([]githubDelivery, 0, len(githubDeliveryLog))
+	for i := len(githubDeliveryLog) - 1; i >= 0; i-- {
+		out = append(out, githubDeliveryLog[i])
+	}
+	return out
+}
+
 func formatGitHubMessage(eventType string, payload *domain.GitHubWebhookPayload) string {
 	repo := payload.Repository.FullName
 
@@ -38,14 +88,11 @@ func formatGitHubMessage(eventType string, payload *domain.GitHubWebhookPayload)
 				message += fmt.Sprintf("_... and %d more commits_\n", commitCount-3)
 				break
 			}
-			shortID := commit.ID[:7]
+			shortID := utils.ShortCommitID(commit.ID)
 
 			fileChanges := utils.GetFileChangesSummary(commit)
 
-			commitMsg := commit.Message
-			if len(commitMsg) > 80 {
-				commitMsg = commitMsg[:77] + "..."
-			}
+			commitMsg := utils.TruncateRunes(commit.Message, 80)
 
 			message += fmt.Sprintf("- `%s` %s%s\n", shortID, commitMsg, fileChanges)
 		}
@@ -113,8 +160,11 @@ func handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
 		log.Printf("[github] Failed to read request body: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to read request body"})
+		if isBodyTooLarge(err) {
+			writePayloadTooLarge(w, bodyLimitFor(r.URL.Path))
+			return
+		}
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Failed to read request body")
 		return
 	}
 
@@ -125,8 +175,7 @@ func handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 	eventType := r.Header.Get("X-GitHub-Event")
 	if eventType == "" {
 		log.Printf("[github] Missing X-GitHub-Event header")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Missing X-GitHub-Event header"})
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Missing X-GitHub-Event header")
 		return
 	}
 
@@ -135,42 +184,104 @@ func handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 	var payload domain.GitHubWebhookPayload
 	if err := json.Unmarshal(body, &payload); err != nil {
 		log.Printf("[github] Failed to parse JSON payload: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to parse JSON payload"})
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Failed to parse JSON payload")
 		return
 	}
 
 	log.Printf("[github] Repository: %s", payload.Repository.FullName)
 
-	if !whatsapp.Client.IsConnected() {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp client not connected"})
+	deliveryID := strings.TrimSpace(r.Header.Get("X-GitHub-Delivery"))
+
+	delivery := &githubDelivery{
+		DeliveryID: deliveryID,
+		Event:      eventType,
+		Repository: payload.Repository.FullName,
+		ReceivedAt: time.Now(),
+		Targets:    []string{},
+	}
+	defer func() { recordGitHubDelivery(*delivery) }()
+
+	// Marked before sending so a retry arriving while this one is still in
+	// flight is caught too; failures below forget the ID so GitHub can retry.
+	if deliveryID != "" && handledGitHubDeliveries.mark(deliveryID, config.Get().GitHubDeliveryTTL, config.Get().GitHubDeliverySize) {
+		log.Printf("[github] Skipping duplicate delivery %s", deliveryID)
+		delivery.Status = "duplicate"
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":      "duplicate",
+			"event":       eventType,
+			"delivery_id": deliveryID,
+		})
 		return
 	}
 
-	var targets []string
-
-	customJID := r.URL.Query().Get("jid")
-	if customJID != "" {
-
-		targets = []string{customJID}
-		log.Printf("[github] Using custom JID from query parameter: %s", customJID)
-	} else {
-
-		targets = utils.GetNotificationTargets()
-		if len(targets) == 0 {
-			w.WriteHeader(http.StatusOK)
-			json.NewEncoder(w).Encode(map[string]string{
-				"status": "Webhook received but no notification targets configured",
-				"event":  eventType,
-			})
-			return
+	if !whatsapp.GetClient().IsConnected() {
+		if deliveryID != "" {
+			handledGitHubDeliveries.forget(deliveryID)
 		}
-		log.Printf("[github] Using default targets from environment: %d targets", len(targets))
+		delivery.Status = "whatsapp_not_connected"
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
+		return
 	}
 
+	targets, targetSource := resolveWebhookTargets(r, "github", payload.Repository.FullName)
+	if len(targets) == 0 {
+		delivery.Status = "no_targets"
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status": "Webhook received but no notification targets configured",
+			"event":  eventType,
+		})
+		return
+	}
+	customJID := r.URL.Query().Get("jid")
+
 	message := formatGitHubMessage(eventType, &payload)
 
+	results, successCount := sendWebhookNotification("GitHub", eventType, targets, message)
+	if successCount == 0 && deliveryID != "" {
+		handledGitHubDeliveries.forget(deliveryID)
+	}
+
+	delivery.Status = "processed"
+	delivery.Targets = targets
+	delivery.SuccessCount = successCount
+	delivery.TargetSource = targetSource
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "Webhook processed",
+		"event":         eventType,
+		"repository":    payload.Repository.FullName,
+		"targets_sent":  successCount,
+		"total_targets": len(targets),
+		"custom_jid":    customJID != "",
+		"target_source": targetSource,
+		"results":       results,
+	})
+}
+
+// resolveWebhookTargets picks the notification targets for a repository webhook:
+// the ?jid= query parameter wins, then REPO_TARGETS for the repository, then
+// NOTIFICATION_TARGETS. It returns no targets when none are configured.
+func resolveWebhookTargets(r *http.Request, source, repoFullName string) ([]string, string) {
+	if customJID := r.URL.Query().Get("jid"); customJID != "" {
+		log.Printf("[%s] Using custom JID from query parameter: %s", source, customJID)
+		return []string{customJID}, "query_parameter"
+	}
+
+	if repoTargets := utils.GetRepoTargets(repoFullName); len(repoTargets) > 0 {
+		log.Printf("[%s] Using repository-specific targets for %s: %d targets", source, repoFullName, len(repoTargets))
+		return repoTargets, "repository"
+	}
+
+	targets := utils.GetNotificationTargets()
+	if len(targets) > 0 {
+		log.Printf("[%s] Using default targets from environment: %d targets", source, len(targets))
+	}
+	return targets, "environment"
+}
+
+// sendWebhookNotification sends message to every target, pausing briefly between
+// sends, and returns the per-target results and the number of successful sends.
+func sendWebhookNotification(source, eventType string, targets []string, message string) ([]map[string]interface{}, int) {
 	results := make([]map[string]interface{}, len(targets))
 	successCount := 0
 
@@ -187,17 +298,11 @@ func handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 			continue
 		}
 
-		targetType := "individual"
-		displayTarget := target
-		if utils.IsGroupJID(target) {
-			targetType = "group"
-		} else {
-			displayTarget = utils.NormalizePhoneNumber(strings.TrimSpace(target))
-		}
+		targetType, displayTarget := utils.DescribeTarget(target)
 
-		log.Printf("Sending GitHub notification (%s) to %s: %s", eventType, targetType, displayTarget)
+		log.Printf("Sending %s notification (%s) to %s: %s", source, eventType, targetType, displayTarget)
 
-		err := utils.SendMessageWithRetry(context.Background(), targetJID, message, 2)
+		err := utils.SendMessageWithRetry(context.Background(), targetJID, message, utils.SendRetries())
 
 		results[i] = map[string]interface{}{
 			"target":      displayTarget,
@@ -207,7 +312,7 @@ func handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			results[i]["error"] = err.Error()
-			log.Printf("Failed to send GitHub notification to %s %s: %v", targetType, displayTarget, err)
+			log.Printf("Failed to send %s notification to %s %s: %v", source, targetType, displayTarget, err)
 		} else {
 			successCount++
 		}
@@ -217,20 +322,19 @@ func handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":        "Webhook processed",
-		"event":         eventType,
-		"repository":    payload.Repository.FullName,
-		"targets_sent":  successCount,
-		"total_targets": len(targets),
-		"custom_jid":    customJID != "",
-		"target_source": func() string {
-			if customJID != "" {
-				return "query_parameter"
-			}
-			return "environment"
-		}(),
-		"results": results,
+	return results, successCount
+}
+
+func handleWebhookLog(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	deliveries := getGitHubDeliveries()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":     "Success",
+		"total":      len(deliveries),
+		"capacity":   getWebhookLogSize(),
+		"deliveries": deliveries,
+		"timestamp":  time.Now().Format(time.RFC3339),
 	})
 }
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsmeow-api/domain"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+func gitLabUser(payload *domain.GitLabWebhookPayload) string {
+	if payload.User != nil {
+		if payload.User.Name != "" {
+			return payload.User.Name
+		}
+		if payload.User.Username != "" {
+			return payload.User.Username
+		}
+	}
+	if payload.UserName != "" {
+		return payload.UserName
+	}
+	if payload.UserUsername != "" {
+		return payload.UserUsername
+	}
+	return "Unknown"
+}
+
+func formatGitLabMessage(eventType string, payload *domain.GitLabWebhookPayload) string {
+	repo := payload.Project.PathWithNamespace
+
+	switch eventType {
+	case "Push Hook":
+		branch := strings.TrimPrefix(payload.Ref, "refs/heads/")
+		commitCount := payload.TotalCommitsCount
+		if commitCount == 0 {
+			commitCount = len(payload.Commits)
+		}
+
+		if commitCount == 0 {
+			return fmt.Sprintf("[GitLab Push]\nRepository: %s\nPusher: %s\nBranch: %s\n\n_No commits in this push_",
+				repo, gitLabUser(payload), branch)
+		}
+
+		message := fmt.Sprintf("[GitLab Push]\nRepository: %s\nPusher: %s\nBranch: %s\nCommits: %d\n\n",
+			repo, gitLabUser(payload), branch, commitCount)
+
+		for i, commit := range payload.Commits {
+			if i >= 3 {
+				message += fmt.Sprintf("_... and %d more commits_\n", commitCount-3)
+				break
+			}
+			shortID := utils.ShortCommitID(commit.ID)
+
+			fileChanges := utils.GetFileChangesSummary(domain.Commit{
+				Added:    commit.Added,
+				Modified: commit.Modified,
+				Removed:  commit.Removed,
+			})
+
+			commitMsg := commit.Title
+			if commitMsg == "" {
+				commitMsg, _, _ = strings.Cut(strings.TrimSpace(commit.Message), "\n")
+			}
+			commitMsg = utils.TruncateRunes(commitMsg, 80)
+
+			message += fmt.Sprintf("- `%s` %s%s\n", shortID, commitMsg, fileChanges)
+		}
+
+		message += fmt.Sprintf("\nView Repository: %s", payload.Project.WebURL)
+
+		return message
+
+	case "Merge Request Hook":
+		mr := payload.ObjectAttributes
+		if mr == nil {
+			mr = &domain.GitLabMergeAttributes{}
+		}
+		actionPrefix := "[Merge Request]"
+		switch mr.Action {
+		case "open":
+			actionPrefix = "[New MR]"
+		case "merge":
+			actionPrefix = "[Merged MR]"
+		case "close":
+			actionPrefix = "[Closed MR]"
+		case "reopen":
+			actionPrefix = "[Reopened MR]"
+		case "approved":
+			actionPrefix = "[Approved MR]"
+		case "update":
+			actionPrefix = "[Updated MR]"
+		}
+
+		return fmt.Sprintf("%s\nRepository: %s\nUser: %s\nMR !%d: %s\nBranch: %s -> %s\nLink: %s",
+			actionPrefix, repo, gitLabUser(payload), mr.IID, mr.Title, mr.SourceBranch, mr.TargetBranch, mr.URL)
+
+	default:
+		return fmt.Sprintf("[GitLab Event: %s]\nRepository: %s\nUser: %s\nLink: %s",
+			eventType, repo, gitLabUser(payload), payload.Project.WebURL)
+	}
+}
+
+func handleGitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	log.Printf("[gitlab] webhook received: %s %s", r.Method, r.URL.Path)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Printf("[gitlab] Failed to read request body: %v", err)
+		if isBodyTooLarge(err) {
+			writePayloadTooLarge(w, bodyLimitFor(r.URL.Path))
+			return
+		}
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Failed to read request body")
+		return
+	}
+
+	eventType := r.Header.Get("X-Gitlab-Event")
+	if eventType == "" {
+		log.Printf("[gitlab] Missing X-Gitlab-Event header")
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Missing X-Gitlab-Event header")
+		return
+	}
+
+	log.Printf("[gitlab] event type: %s", eventType)
+
+	var payload domain.GitLabWebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		log.Printf("[gitlab] Failed to parse JSON payload: %v", err)
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Failed to parse JSON payload")
+		return
+	}
+
+	repo := payload.Project.PathWithNamespace
+	log.Printf("[gitlab] Repository: %s", repo)
+
+	delivery := &githubDelivery{
+		Event:      "gitlab:" + eventType,
+		Repository: repo,
+		ReceivedAt: time.Now(),
+		Targets:    []string{},
+	}
+	defer func() { recordGitHubDelivery(*delivery) }()
+
+	if !whatsapp.GetClient().IsConnected() {
+		delivery.Status = "whatsapp_not_connected"
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
+		return
+	}
+
+	targets, targetSource := resolveWebhookTargets(r, "gitlab", repo)
+	if len(targets) == 0 {
+		delivery.Status = "no_targets"
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status": "Webhook received but no notification targets configured",
+			"event":  eventType,
+		})
+		return
+	}
+
+	message := formatGitLabMessage(eventType, &payload)
+
+	results, successCount := sendWebhookNotification("GitLab", eventType, targets, message)
+
+	delivery.Status = "processed"
+	delivery.Targets = targets
+	delivery.SuccessCount = successCount
+	delivery.TargetSource = targetSource
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "Webhook processed",
+		"event":         eventType,
+		"repository":    repo,
+		"targets_sent":  successCount,
+		"total_targets": len(targets),
+		"custom_jid":    r.URL.Query().Get("jid") != "",
+		"target_source": targetSource,
+		"results":       results,
+	})
+}
@@ -0,0 +1,369 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+)
+
+const helpMessage = `[WhatsApp Bot] Bantuan Penggunaan
+
+[Daftar Perintah]
+
+*!help* atau */help*
+Menampilkan bantuan dan cara penggunaan bot
+
+*!menu* atau */menu*
+Menampilkan menu interaktif untuk memilih perintah
+
+*!hallo* atau */hallo*
+Menyapa bot dengan ramah
+
+*!fiq [pertanyaan]* atau */fiq [pertanyaan]*
+Tanya apa saja ke asisten AI pribadi Fiq
+
+*!persona [nama]* atau */persona [nama]*
+Mengganti kepribadian asisten !fiq di chat ini (!persona reset untuk kembali ke Fiq)
+
+*!groups* atau */groups*
+Menampilkan daftar grup yang diikuti bot (khusus admin)
+
+*!groups [nama grup]* atau */groups [nama grup]*
+Mencari grup berdasarkan nama dan menampilkan ID-nya
+Contoh: *!groups Braincore Community*
+
+*!groups page [nomor]* atau */groups page [nomor]*
+Menampilkan halaman berikutnya dari daftar grup (20 grup per halaman)
+Contoh: *!groups page 2*
+
+*!ping* atau */ping*
+Cek apakah bot sedang aktif
+
+*!status* atau */status*
+Menampilkan status koneksi bot
+
+*!info* atau */info*
+Menampilkan informasi tentang bot
+
+*!schedule* atau */schedule*
+Mengatur pesan berulang harian/mingguan di chat ini (khusus admin)
+
+*!test* atau */test*
+Test apakah bot berfungsi dengan baik
+
+*!echo [teks]* atau */echo [teks]*
+Mengulang pesan yang dikirim, atau kirim ulang gambar/stiker jika membalas (reply) media
+
+*!idx* atau */idx*
+Menampilkan data pasar saham IDX hari ini
+
+*!gainers* atau */gainers*
+Menampilkan 10 saham IDX dengan kenaikan terbesar hari ini
+
+*!losers* atau */losers*
+Menampilkan 10 saham IDX dengan penurunan terbesar hari ini
+
+*!top* atau */top*
+Menampilkan saham IDX dengan kenaikan dan penurunan terbesar hari ini
+
+*!news* atau */news*
+Menampilkan 5 berita pasar saham terbaru beserta link-nya
+
+*!alert [kode] [>|<] [harga]* atau */alert [kode] [>|<] [harga]*
+Notifikasi lewat chat pribadi saat harga saham melewati target
+Contoh: *!alert BBCA > 10000*, *!alert list*, *!alert remove 1*
+
+*!voice [pertanyaan]* atau */voice [pertanyaan]*
+Bertanya kepada Fiq dan menerima jawaban dalam bentuk pesan suara
+
+*!img [jumlah] [deskripsi]* atau */img [jumlah] [deskripsi]*
+Membuat gambar AI berdasarkan deskripsi yang diberikan (jumlah opsional, maks 4)
+
+*!describe [pertanyaan]* atau */describe [pertanyaan]*
+Kirim gambar dengan caption ini untuk mendapatkan penjelasan isi gambar dari AI
+
+*!summarize* atau */summarize*
+Balas (reply) sebuah pesan panjang dengan perintah ini untuk mendapatkan ringkasannya
+
+*!sentiment* atau */sentiment*
+Balas (reply) sebuah pesan dengan perintah ini untuk mengetahui sentimennya (positif/netral/negatif)
+
+*!define [kata]* atau */define [kata]*
+Menampilkan definisi singkat dan contoh kalimat dari sebuah kata atau istilah
+
+*!wiki [topik]* atau */wiki [topik]*
+Menampilkan ringkasan singkat artikel Wikipedia
+
+*!ytdl [url]* atau */ytdl [url]*
+Mengunduh video pendek dari link (YouTube, TikTok, Instagram) dan mengirimkannya
+
+*!calc [ekspresi]* atau */calc [ekspresi]*
+Menghitung ekspresi matematika sederhana, contoh: !calc 2*(3+4)/5
+
+*!roll [NdM|N]* atau */roll [NdM|N]*
+Melempar dadu, contoh: !roll 2d6 atau !roll 100
+
+*!countdown [tanggal] [jam] [acara]* atau */countdown ...*
+Menghitung mundur menuju suatu tanggal, contoh: !countdown 2025-12-31 Tahun Baru
+
+*!jadwal [kota]* atau */jadwal [kota]*
+Menampilkan jadwal sholat hari ini untuk kota tertentu
+
+*!sticker* atau */sticker*
+Kirim gambar dengan caption ini untuk mengubahnya menjadi stiker
+
+*!whois* atau */whois*
+Menampilkan JID, nama, dan status admin Anda di chat ini
+
+*!location [lat] [lng] [nama]* atau */location [lat] [lng] [nama]*
+Mengirim lokasi berdasarkan koordinat
+Contoh: *!location -6.1754 106.8272 Monas*
+
+*!feedback [pesan]* atau */feedback [pesan]*
+Mengirim saran atau masukan untuk pengembang bot
+
+*!convert [jumlah] [dari] [ke]* atau */convert [jumlah] [dari] [ke]*
+Konversi mata uang, contoh: !convert 100 USD IDR
+
+*!subscribe idx* atau */subscribe idx*
+Berlangganan ringkasan IDX harian untuk chat ini
+
+*!unsubscribe idx* atau */unsubscribe idx*
+Berhenti berlangganan ringkasan IDX harian
+
+*!edit [instruksi]* atau */edit [instruksi]*
+Edit gambar dengan AI (balas gambar atau kirim gambar dengan caption)
+
+*!tts [bahasa] [teks]* atau */tts [bahasa] [teks]*
+Bacakan teks sebagai pesan suara, contoh: !tts en hello world
+
+*!shorten [url]* atau */shorten [url]*
+Memperpendek URL panjang
+
+*!remindall [waktu] [pesan]* atau */remindall [waktu] [pesan]*
+Mengirim pengumuman sekali ke semua chat pelanggan IDX setelah waktu tertentu (khusus admin)
+Contoh: *!remindall 2h Rapat dimulai*
+
+*!setname [nama]* atau */setname [nama]*
+Mengganti nama tampilan (push name) bot (khusus admin)
+
+*!groupinfo* atau */groupinfo*
+Menampilkan nama, JID, pemilik, jumlah anggota, dan tanggal dibuat grup ini
+
+*!joke* atau */joke*
+Menampilkan lelucon acak
+
+*!quote* atau */quote*
+Menampilkan kutipan motivasi acak
+
+[Tips]
+- Semua perintah bisa menggunakan ! atau /
+- Ketik !help [perintah] untuk bantuan detail, contoh: !help img
+- Bot akan merespons secara otomatis
+- Gunakan perintah di chat pribadi atau grup
+
+[Fiq - Asisten AI]
+Fiq adalah asisten pribadi berbasis Google Gemini yang siap membantu Anda dengan berbagai pertanyaan dan tugas sehari-hari.
+
+[Dukungan]
+Jika ada pertanyaan, silakan hubungi administrator bot.`
+
+// commandHelp holds the detailed usage shown by !help [perintah]. Commands
+// without an entry fall back to their lines from helpMessage.
+var commandHelp = map[string]string{
+	"fiq": `[Bantuan] !fiq
+
+Tanya apa saja ke asisten AI pribadi Fiq. Fiq mengingat percakapan sebelumnya di chat ini.
+
+Cara menggunakan: !fiq [pertanyaan]
+
+Contoh:
+- !fiq apa itu inflasi?
+- !fiq buatkan puisi pendek tentang hujan`,
+
+	"persona": `[Bantuan] !persona
+
+Mengganti kepribadian asisten !fiq di chat ini.
+
+Cara menggunakan:
+- !persona (lihat persona aktif dan daftar persona)
+- !persona [nama] (ganti persona)
+- !persona reset (kembali ke Fiq)
+
+Contoh: !persona guru`,
+
+	"img": `[Bantuan] !img
+
+Membuat gambar AI berdasarkan deskripsi yang diberikan. Setiap gambar membutuhkan waktu 30-60 detik.
+
+Cara menggunakan:
+- !img [deskripsi gambar]
+- !img [jumlah 1-4] [deskripsi gambar]
+
+Contoh:
+- !img kucing lucu bermain di taman
+- !img 3 robot futuristik di kota masa depan`,
+
+	"edit": `[Bantuan] !edit
+
+Mengedit gambar dengan AI sesuai instruksi.
+
+Cara menggunakan:
+- Kirim gambar dengan caption !edit [instruksi]
+- Balas (reply) gambar dengan !edit [instruksi]
+
+Contoh: !edit ubah latar belakang menjadi pantai`,
+
+	"describe": `[Bantuan] !describe
+
+Menjelaskan isi gambar dengan AI.
+
+Cara menggunakan:
+- Kirim gambar dengan caption !describe
+- Kirim gambar dengan caption !describe [pertanyaan]
+
+Contoh: !describe ada berapa orang di foto ini?`,
+
+	"voice": `[Bantuan] !voice
+
+Bertanya kepada Fiq dan menerima jawaban dalam bentuk pesan suara.
+
+Cara menggunakan: !voice [pertanyaan]
+
+Contoh: !voice ceritakan fakta menarik tentang bulan`,
+
+	"tts": `[Bantuan] !tts
+
+Membacakan teks sebagai pesan suara.
+
+Cara menggunakan:
+- !tts [teks] (bahasa Indonesia)
+- !tts [kode bahasa] [teks]
+
+Kode bahasa: id, en, ms, jv, su, ar, ja, ko, zh, fr, de, es, it, nl, pt, ru, th, vi, hi, tr
+
+Contoh: !tts en hello world`,
+
+	"idx": `[Bantuan] !idx
+
+Menampilkan ringkasan pasar saham IDX.
+
+Cara menggunakan:
+- !idx (data hari ini)
+- !idx [tanggal] (data tanggal tertentu)
+
+Contoh:
+- !idx 2025-01-15
+- !idx 15 januari 2025
+
+Lihat juga: !gainers, !losers, !top, !alert`,
+
+	"alert":    alertUsage,
+	"schedule": scheduleUsage,
+
+	"groups": `[Bantuan] !groups
+
+Menampilkan daftar grup yang diikuti bot (khusus admin).
+
+Cara menggunakan:
+- !groups (halaman pertama, 20 grup per halaman)
+- !groups page [nomor]
+- !groups [nama grup] (cari grup dan tampilkan ID-nya)
+
+Contoh:
+- !groups page 2
+- !groups Braincore Community`,
+
+	"subscribe": `[Bantuan] !subscribe
+
+Berlangganan ringkasan IDX yang dikirim otomatis setiap hari bursa.
+
+Cara menggunakan:
+- !subscribe idx
+- !unsubscribe idx (berhenti berlangganan)`,
+
+	"convert": `[Bantuan] !convert
+
+Konversi mata uang dengan kurs terbaru.
+
+Cara menggunakan: !convert [jumlah] [dari] [ke]
+
+Contoh:
+- !convert 100 USD IDR
+- !convert 1.500.000 IDR SGD`,
+
+	"countdown": `[Bantuan] !countdown
+
+Menghitung mundur menuju suatu tanggal.
+
+Cara menggunakan: !countdown [tanggal] [jam opsional] [nama acara]
+
+Contoh:
+- !countdown 2025-12-31 Tahun Baru
+- !countdown 17/08/2025 08:00 Upacara
+- !countdown 1 Januari 2026`,
+
+	"calc": `[Bantuan] !calc
+
+Menghitung ekspresi matematika sederhana. Mendukung + - * / % dan tanda kurung.
+
+Cara menggunakan: !calc [ekspresi]
+
+Contoh:
+- !calc 2*(3+4)/5
+- !calc 17 % 5`,
+
+	"roll": `[Bantuan] !roll
+
+Melempar dadu.
+
+Cara menggunakan:
+- !roll (satu dadu 6 sisi)
+- !roll [N] (angka acak 1 sampai N)
+- !roll [jumlah]d[sisi]
+
+Contoh:
+- !roll 2d6
+- !roll 100`,
+
+	"location": `[Bantuan] !location
+
+Mengirim lokasi berdasarkan koordinat.
+
+Cara menggunakan: !location [lat] [lng] [nama opsional]
+
+Contoh: !location -6.1754 106.8272 Monas`,
+}
+
+// commandHelpAliases maps commands that share a help entry, on top of the
+// dispatch aliases in commandAliases.
+var commandHelpAliases = map[string]string{
+	"unsubscribe": "subscribe",
+}
+
+// commandHelpText returns the detailed help for a single command. The
+// command may be given with or without its ! or / prefix.
+func commandHelpText(command string) string {
+	name := strings.ToLower(strings.TrimLeft(command, "!/"))
+	if alias, ok := commandAliases[name]; ok {
+		name = alias
+	}
+	if alias, ok := commandHelpAliases[name]; ok {
+		name = alias
+	}
+
+	if text, ok := commandHelp[name]; ok {
+		return text
+	}
+
+	var entries []string
+	for _, entry := range strings.Split(helpMessage, "\n\n") {
+		if strings.HasPrefix(entry, "*!"+name+"*") || strings.HasPrefix(entry, "*!"+name+" ") {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) > 0 {
+		return fmt.Sprintf("[Bantuan] !%s\n\n%s", name, strings.Join(entries, "\n\n"))
+	}
+
+	return fmt.Sprintf("[Bantuan]\n\nPerintah *%s* tidak ditemukan.\n\nKetik !help untuk melihat daftar perintah.", name)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+type idempotentResponse struct {
+	pending   bool
+	status    int
+	body      []byte
+	expiresAt time.Time
+}
+
+var (
+	idempotencyMu    sync.Mutex
+	idempotencyKeys  = make(map[string]*idempotentResponse)
+	idempotencyOrder []string
+)
+
+func getIdempotencyTTL() time.Duration {
+	return config.Get().IdempotencyTTL
+}
+
+// idempotencyKey returns the key from the JSON body, falling back to the
+// Idempotency-Key header.
+func idempotencyKey(r *http.Request, bodyKey string) string {
+	if bodyKey != "" {
+		return bodyKey
+	}
+	return r.Header.Get("Idempotency-Key")
+}
+
+// idempotencyRecorder buffers a response so it can be replayed for retries
+// that carry the same key.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	key    string
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// commit stores successful responses for replay. Failed requests release the
+// key so the client can retry them.
+func (rec *idempotencyRecorder) commit() {
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+
+	if _, exists := idempotencyKeys[rec.key]; !exists {
+		return
+	}
+	if rec.status < 200 || rec.status >= 300 {
+		delete(idempotencyKeys, rec.key)
+		return
+	}
+	idempotencyKeys[rec.key] = &idempotentResponse{
+		status:    rec.status,
+		body:      rec.body.Bytes(),
+		expiresAt: time.Now().Add(getIdempotencyTTL()),
+	}
+}
+
+// beginIdempotent checks key against recent requests. If it was already
+// handled, the cached response is written and ok is false. Otherwise the key
+// is reserved and a recorder is returned; the caller must defer rec.commit()
+// and write its response through rec. An empty key disables the check.
+func beginIdempotent(w http.ResponseWriter, key string) (rec *idempotencyRecorder, ok bool) {
+	if key == "" {
+		return nil, true
+	}
+
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+
+	now := time.Now()
+	if prior, exists := idempotencyKeys[key]; exists && (prior.pending || now.Before(prior.expiresAt)) {
+		if prior.pending {
+			writeErrorWithFields(w, http.StatusConflict, ErrCodeInProgress, "A request with this idempotency key is still in progress", map[string]interface{}{
+				"idempotency_key": key,
+			})
+			return nil, false
+		}
+		log.Printf("[idempotency] Replaying cached response for key %s", key)
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(prior.status)
+		w.Write(prior.body)
+		return nil, false
+	}
+
+	limit := config.Get().IdempotencyCacheSize
+	kept := idempotencyOrder[:0]
+	for _, k := range idempotencyOrder {
+		if entry, exists := idempotencyKeys[k]; exists && k != key && (entry.pending || now.Before(entry.expiresAt)) {
+			kept = append(kept, k)
+		} else if k != key {
+			delete(idempotencyKeys, k)
+		}
+	}
+	idempotencyOrder = kept
+	for len(idempotencyOrder) >= limit {
+		delete(idempotencyKeys, idempotencyOrder[0])
+		idempotencyOrder = idempotencyOrder[1:]
+	}
+
+	idempotencyKeys[key] = &idempotentResponse{pending: true}
+	idempotencyOrder = append(idempotencyOrder, key)
+
+	return &idempotencyRecorder{ResponseWriter: w, key: key}, true
+}
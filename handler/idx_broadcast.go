@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/services/idx"
+	"whatsmeow-api/services/subscription"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+// idxBroadcastRecipients returns IDX_BROADCAST_TARGETS plus subscribed chats,
+// minus the chats that unsubscribed.
+func idxBroadcastRecipients() []string {
+	var configured []string
+	for _, target := range config.Get().IDXBroadcastTargets {
+		if jid := utils.CreateTargetJID(target); !jid.IsEmpty() {
+			configured = append(configured, jid.String())
+		}
+	}
+	return subscription.Store.Recipients(subscription.TopicIDX, configured)
+}
+
+// idxBroadcastDue reports whether the daily broadcast should go out at now
+// (already in WIB). It only fires on weekdays, within 5 minutes of
+// IDX_BROADCAST_TIME so a missed tick still delivers.
+func idxBroadcastDue(now time.Time) bool {
+	if now.Weekday() == time.Saturday || now.Weekday() == time.Sunday {
+		return false
+	}
+	at, err := time.Parse("15:04", config.Get().IDXBroadcastTime)
+	if err != nil {
+		return false
+	}
+	fireAt := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	return !now.Before(fireAt) && now.Sub(fireAt) < 5*time.Minute
+}
+
+// StartIDXBroadcast sends the day's IDX market summary to every recipient at
+// IDX_BROADCAST_TIME on weekdays. It blocks, so run it in its own goroutine.
+func StartIDXBroadcast() {
+	log.Printf("[broadcast] IDX broadcast started (time: %s WIB)", config.Get().IDXBroadcastTime)
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := time.Now().In(utils.WIB())
+		if !idxBroadcastDue(now) || !whatsapp.GetClient().IsConnected() {
+			continue
+		}
+
+		recipients := idxBroadcastRecipients()
+		if len(recipients) == 0 || !subscription.Store.TakeRun(subscription.TopicIDX, now.Format("2006-01-02")) {
+			continue
+		}
+		sendIDXBroadcast(now, recipients)
+	}
+}
+
+func sendIDXBroadcast(now time.Time, recipients []string) {
+	data, err := idx.GetIDXMarketData(now)
+	if err != nil {
+		log.Printf("[broadcast] Failed to fetch IDX data: %v", err)
+		return
+	}
+	message := idx.FormatIDXResponse(data) + "\n\n[Ketik !unsubscribe idx untuk berhenti menerima pesan ini]"
+
+	sent := 0
+	for _, recipient := range recipients {
+		jid, err := types.ParseJID(recipient)
+		if err != nil {
+			log.Printf("[broadcast] Invalid recipient %s: %v", recipient, err)
+			continue
+		}
+		if err := utils.SendMessageWithRetry(context.Background(), jid, message, utils.DeliveryRetries()); err != nil {
+			log.Printf("[broadcast] Failed to send IDX broadcast to %s: %v", recipient, err)
+			continue
+		}
+		sent++
+		time.Sleep(time.Second)
+	}
+	log.Printf("[broadcast] IDX broadcast sent to %d/%d chats", sent, len(recipients))
+}
+
+// handleSubscribeCommand handles !subscribe idx and !unsubscribe idx for the
+// current chat.
+func handleSubscribeCommand(v *events.Message, originalMessage string, subscribe bool) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	command := "subscribe"
+	if !subscribe {
+		command = "unsubscribe"
+	}
+
+	var topic string
+	if fields := strings.Fields(originalMessage); len(fields) > 1 {
+		topic = strings.ToLower(fields[1])
+	}
+	if topic != subscription.TopicIDX {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Langganan]\n\nGunakan: !%s idx\n\nTopik yang tersedia:\n- idx (ringkasan pasar IDX setiap hari bursa pukul %s WIB)", command, config.Get().IDXBroadcastTime), utils.SendRetries())
+		return
+	}
+
+	chat := v.Info.Chat.ToNonAD().String()
+	var changed bool
+	var err error
+	if subscribe {
+		changed, err = subscription.Store.Subscribe(topic, chat)
+	} else {
+		changed, err = subscription.Store.Unsubscribe(topic, chat)
+	}
+	if err != nil {
+		log.Printf("Failed to %s %s from %s: %v", command, chat, topic, err)
+		replyError(v, "[Error] Gagal menyimpan langganan. Silakan coba lagi nanti.")
+		return
+	}
+
+	var msg string
+	switch {
+	case subscribe && changed:
+		msg = fmt.Sprintf("[Langganan] Chat ini sekarang berlangganan ringkasan IDX harian (hari bursa, pukul %s WIB).", config.Get().IDXBroadcastTime)
+	case subscribe:
+		msg = "[Langganan] Chat ini sudah berlangganan ringkasan IDX harian."
+	case changed:
+		msg = "[Langganan] Chat ini tidak akan menerima ringkasan IDX harian lagi."
+	default:
+		msg = "[Langganan] Chat ini sudah berhenti berlangganan ringkasan IDX harian."
+	}
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, msg, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send subscription reply: %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/utils"
+)
+
+func archiveMediaEnabled() bool {
+	return config.Get().ArchiveMedia
+}
+
+func archiveDir() string {
+	return config.Get().ArchiveDir
+}
+
+// archiveMediaFileName names an archived file by the message timestamp and media
+// kind, with the message ID appended so files from the same second don't collide.
+func archiveMediaFileName(v *events.Message, kind, mimetype string) string {
+	ts := v.Info.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	id := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, string(v.Info.ID))
+	return fmt.Sprintf("%s_%s_%s%s", ts.In(utils.WIB()).Format("20060102-150405"), kind, id, utils.MediaExtension(mimetype))
+}
+
+// archiveIncomingMedia saves the media attached to v into ARCHIVE_DIR. Messages
+// without media are ignored.
+func archiveIncomingMedia(v *events.Message) {
+	if media, _, _ := utils.GetMediaMessage(v.Message); media == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	data, mimetype, kind, err := utils.DownloadMedia(ctx, v.Message)
+	if err != nil {
+		log.Printf("[archive] Failed to download media from %s: %v", v.Info.Chat.String(), err)
+		return
+	}
+
+	dir := archiveDir()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		log.Printf("[archive] Failed to create %s: %v", dir, err)
+		return
+	}
+
+	name := archiveMediaFileName(v, kind, mimetype)
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		log.Printf("[archive] Failed to write %s: %v", name, err)
+		return
+	}
+	log.Printf("[archive] Saved %s (%d bytes) from %s", name, len(data), v.Info.Chat.String())
+
+	cfg := config.Get()
+	pruneArchive(dir, cfg.ArchiveMaxBytes, cfg.ArchiveMaxAge, time.Now())
+}
+
+// pruneArchive deletes archived files older than maxAge, then the oldest
+// remaining files until the directory is no larger than maxBytes. A zero limit
+// is not enforced.
+func pruneArchive(dir string, maxBytes int64, maxAge time.Duration, now time.Time) {
+	if maxBytes <= 0 && maxAge <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Printf("[archive] Failed to read %s for pruning: %v", dir, err)
+		return
+	}
+
+	var files []os.FileInfo
+	var total int64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+
+	removed := 0
+	for _, info := range files {
+		expired := maxAge > 0 && now.Sub(info.ModTime()) > maxAge
+		oversize := maxBytes > 0 && total > maxBytes
+		if !expired && !oversize {
+			break
+		}
+		if err := os.Remove(filepath.Join(dir, info.Name())); err != nil {
+			log.Printf("[archive] Failed to prune %s: %v", info.Name(), err)
+			continue
+		}
+		total -= info.Size()
+		removed++
+	}
+	if removed > 0 {
+		log.Printf("[archive] Pruned %d old file(s), %d bytes left", removed, total)
+	}
+}
+
+// handleListArchivedMedia lists the archived media files, newest first.
+func handleListArchivedMedia(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	entries, err := os.ReadDir(archiveDir())
+	if err != nil && !os.IsNotExist(err) {
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to read archive: "+err.Error())
+		return
+	}
+
+	files := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, map[string]interface{}{
+			"name":        entry.Name(),
+			"size":        info.Size(),
+			"modified_at": info.ModTime().Format(time.RFC3339),
+			"url":         "/download/" + entry.Name(),
+		})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i]["name"].(string) > files[j]["name"].(string)
+	})
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    "Success",
+		"enabled":   archiveMediaEnabled(),
+		"total":     len(files),
+		"files":     files,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+}
+
+// handleDownloadArchivedMedia serves a single archived file by name.
+func handleDownloadArchivedMedia(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Invalid file name")
+		return
+	}
+
+	path := filepath.Join(archiveDir(), name)
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		writeErrorWithFields(w, http.StatusNotFound, ErrCodeNotFound, "File not found", map[string]interface{}{"name": name})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	http.ServeFile(w, r, path)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPruneArchive(t *testing.T) {
+	now := time.Date(2026, time.October, 16, 12, 0, 0, 0, time.UTC)
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"a_old.jpg", 100, 40 * 24 * time.Hour},
+		{"b.jpg", 300, 10 * 24 * time.Hour},
+		{"c.jpg", 300, 5 * 24 * time.Hour},
+		{"d_new.jpg", 300, time.Hour},
+	}
+
+	tests := []struct {
+		name     string
+		maxBytes int64
+		maxAge   time.Duration
+		want     []string
+	}{
+		{"no limits", 0, 0, []string{"a_old.jpg", "b.jpg", "c.jpg", "d_new.jpg"}},
+		{"age only", 0, 30 * 24 * time.Hour, []string{"b.jpg", "c.jpg", "d_new.jpg"}},
+		{"size only", 700, 0, []string{"c.jpg", "d_new.jpg"}},
+		{"age and size", 1000, 7 * 24 * time.Hour, []string{"c.jpg", "d_new.jpg"}},
+		{"within limits", 2000, 60 * 24 * time.Hour, []string{"a_old.jpg", "b.jpg", "c.jpg", "d_new.jpg"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, f := range files {
+				path := filepath.Join(dir, f.name)
+				if err := os.WriteFile(path, make([]byte, f.size), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				mtime := now.Add(-f.age)
+				if err := os.Chtimes(path, mtime, mtime); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			pruneArchive(dir, tt.maxBytes, tt.maxAge, now)
+
+			entries, _ := os.ReadDir(dir)
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.Name())
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("remaining files = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
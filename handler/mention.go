@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"regexp"
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+var mentionTokenRe = regexp.MustCompile(`@\d+`)
+
+// mentionReplyEnabled reports whether @-mentioning the bot should be treated
+// as a !fiq question. Enabled with MENTION_REPLY=true.
+func mentionReplyEnabled() bool {
+	return config.Get().MentionReply
+}
+
+// isBotJID reports whether jid refers to the logged-in account, by phone
+// number or LID.
+func isBotJID(jid types.JID) bool {
+	store := whatsapp.GetClient().Store
+	if store.ID != nil && jid.User == store.ID.User && jid.Server == store.ID.Server {
+		return true
+	}
+	return !store.LID.IsEmpty() && jid.User == store.LID.User && jid.Server == store.LID.Server
+}
+
+// rewriteBotMention turns a message that @-mentions the bot into a !fiq
+// command carrying the rest of the text. Messages that already use a command
+// prefix are left alone.
+func rewriteBotMention(v *events.Message, message string) (string, bool) {
+	trimmed := strings.TrimSpace(message)
+	if strings.HasPrefix(trimmed, "!") || strings.HasPrefix(trimmed, "/") {
+		return message, false
+	}
+
+	mentioned := false
+	for _, raw := range utils.GetContextInfo(v.Message).GetMentionedJID() {
+		jid, err := types.ParseJID(raw)
+		if err == nil && isBotJID(jid) {
+			mentioned = true
+			break
+		}
+	}
+	if !mentioned {
+		return message, false
+	}
+
+	question := strings.Join(strings.Fields(mentionTokenRe.ReplaceAllString(trimmed, "")), " ")
+	return "!fiq " + question, true
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"log"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+type menuRow struct {
+	ID          string
+	Title       string
+	Description string
+	Command     string
+}
+
+type menuSection struct {
+	Title string
+	Rows  []menuRow
+}
+
+var menuSections = []menuSection{
+	{
+		Title: "AI",
+		Rows: []menuRow{
+			{ID: "menu_ai_fiq", Title: "!fiq", Description: "Tanya asisten AI Fiq", Command: "!fiq"},
+			{ID: "menu_ai_apik", Title: "!apik", Description: "Tanya asisten AI !apik", Command: "!apik"},
+			{ID: "menu_ai_img", Title: "!img", Description: "Buat gambar dengan AI", Command: "!img"},
+			{ID: "menu_ai_voice", Title: "!voice", Description: "Jawaban Fiq sebagai pesan suara", Command: "!voice"},
+			{ID: "menu_ai_describe", Title: "!describe", Description: "Jelaskan isi gambar", Command: "!describe"},
+			{ID: "menu_ai_edit", Title: "!edit", Description: "Edit gambar dengan AI", Command: "!edit"},
+			{ID: "menu_ai_summarize", Title: "!summarize", Description: "Ringkas pesan yang dibalas", Command: "!summarize"},
+			{ID: "menu_ai_sentiment", Title: "!sentiment", Description: "Analisis sentimen pesan yang dibalas", Command: "!sentiment"},
+			{ID: "menu_ai_define", Title: "!define", Description: "Definisi singkat sebuah istilah", Command: "!define"},
+		},
+	},
+	{
+		Title: "Market",
+		Rows: []menuRow{
+			{ID: "menu_market_idx", Title: "!idx", Description: "Data pasar IDX hari ini", Command: "!idx"},
+			{ID: "menu_market_gainers", Title: "!gainers", Description: "Saham dengan kenaikan terbesar", Command: "!gainers"},
+			{ID: "menu_market_losers", Title: "!losers", Description: "Saham dengan penurunan terbesar", Command: "!losers"},
+			{ID: "menu_market_news", Title: "!news", Description: "Berita pasar terbaru", Command: "!news"},
+			{ID: "menu_market_alert", Title: "!alert", Description: "Kelola price alert saham", Command: "!alert list"},
+		},
+	},
+	{
+		Title: "Utility",
+		Rows: []menuRow{
+			{ID: "menu_util_help", Title: "!help", Description: "Bantuan lengkap", Command: "!help"},
+			{ID: "menu_util_ping", Title: "!ping", Description: "Cek bot aktif", Command: "!ping"},
+			{ID: "menu_util_status", Title: "!status", Description: "Status koneksi bot", Command: "!status"},
+			{ID: "menu_util_jid", Title: "!jid", Description: "Lihat JID Anda dan chat ini", Command: "!jid"},
+			{ID: "menu_util_calc", Title: "!calc", Description: "Kalkulator sederhana", Command: "!calc"},
+			{ID: "menu_util_roll", Title: "!roll", Description: "Lempar dadu", Command: "!roll"},
+			{ID: "menu_util_jadwal", Title: "!jadwal", Description: "Jadwal sholat hari ini", Command: "!jadwal"},
+			{ID: "menu_util_wiki", Title: "!wiki", Description: "Ringkasan artikel Wikipedia", Command: "!wiki"},
+			{ID: "menu_util_sticker", Title: "!sticker", Description: "Ubah gambar jadi stiker", Command: "!sticker"},
+			{ID: "menu_util_joke", Title: "!joke", Description: "Lelucon acak", Command: "!joke"},
+			{ID: "menu_util_quote", Title: "!quote", Description: "Kutipan motivasi acak", Command: "!quote"},
+		},
+	},
+}
+
+// resolveMenuSelection maps a tapped list row ID back to the command text it stands for
+func resolveMenuSelection(message string) (string, bool) {
+	for _, section := range menuSections {
+		for _, row := range section.Rows {
+			if row.ID == message {
+				return row.Command, true
+			}
+		}
+	}
+	return "", false
+}
+
+func handleMenuCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	sections := make([]*waE2E.ListMessage_Section, 0, len(menuSections))
+	for _, section := range menuSections {
+		rows := make([]*waE2E.ListMessage_Row, 0, len(section.Rows))
+		for _, row := range section.Rows {
+			rows = append(rows, &waE2E.ListMessage_Row{
+				RowID:       proto.String(row.ID),
+				Title:       proto.String(row.Title),
+				Description: proto.String(row.Description),
+			})
+		}
+		sections = append(sections, &waE2E.ListMessage_Section{
+			Title: proto.String(section.Title),
+			Rows:  rows,
+		})
+	}
+
+	listMsg := &waE2E.Message{
+		ListMessage: &waE2E.ListMessage{
+			Title:       proto.String("[WhatsApp Bot] Menu"),
+			Description: proto.String("Pilih perintah yang ingin dijalankan."),
+			ButtonText:  proto.String("Lihat Menu"),
+			FooterText:  proto.String("Ketik !help untuk bantuan lengkap"),
+			ListType:    waE2E.ListMessage_SINGLE_SELECT.Enum(),
+			Sections:    sections,
+		},
+	}
+
+	if _, err := utils.SendMessageWithTimeout(context.Background(), v.Info.Chat, listMsg); err != nil {
+		log.Printf("Failed to send menu list message, falling back to text help: %v", err)
+		handleHelpCommand(v, "")
+	}
+}
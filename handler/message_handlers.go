@@ -2,11 +2,11 @@ package handler
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"log"
 	"net/http"
-	"os"
-	"time"
+	"strings"
 
 	"whatsmeow-api/domain"
 	"whatsmeow-api/utils"
@@ -18,222 +18,328 @@ func handleSendMessage(w http.ResponseWriter, r *http.Request) {
 
 	var req domain.SendRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeBodyError(w, r, err)
 		return
 	}
 
-	SECRET := os.Getenv("API_SECRET")
-	if SECRET == "" {
-		SECRET = "default-secret"
+	rec, ok := beginIdempotent(w, idempotencyKey(r, req.IdempotencyKey))
+	if !ok {
+		return
+	}
+	if rec != nil {
+		defer rec.commit()
+		w = rec
+	}
+
+	targetJID := utils.CreateTargetJID(req.Target)
+
+	if targetJID.IsEmpty() {
+		writeErrorWithFields(w, http.StatusBadRequest, ErrCodeInvalidTarget, "Invalid target format (must be phone number, group JID, newsletter JID or LID)", map[string]interface{}{
+			"target": req.Target,
+		})
+		return
+	}
+
+	if !whatsapp.GetClient().IsConnected() {
+		if !queueOnDisconnect() {
+			writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
+			return
+		}
+
+		queued, err := enqueueOfflineSend(req.Target, req.Message)
+		if err != nil {
+			log.Printf("[http %s] Failed to queue message for %s: %v", requestIDFrom(r.Context()), req.Target, err)
+			writeError(w, http.StatusServiceUnavailable, ErrCodeQueueFailed, "WhatsApp client not connected and message could not be queued: "+err.Error())
+			return
+		}
+
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{
+			"status":       "Queued",
+			"target":       req.Target,
+			"queue_length": queued,
+			"message":      "WhatsApp client not connected, message will be sent on reconnect",
+		})
+		return
+	}
+
+	targetType, displayTarget := utils.DescribeTarget(req.Target)
+
+	log.Printf("[http %s] Sending message to %s: %s (original: %s)", requestIDFrom(r.Context()), targetType, displayTarget, req.Target)
+
+	err := utils.SendMessageWithRetry(context.Background(), targetJID, req.Message, utils.DeliveryRetries())
+	if err != nil {
+		writeErrorWithFields(w, http.StatusInternalServerError, ErrCodeSendFailed, err.Error(), map[string]interface{}{
+			"original_target": req.Target,
+			"target_type":     targetType,
+		})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "Success",
+		"target":      displayTarget,
+		"target_type": targetType,
+	})
+}
+
+func handleSendImage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req domain.SendImageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, r, err)
+		return
 	}
 
-	if req.Secret != SECRET {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+	rec, ok := beginIdempotent(w, idempotencyKey(r, req.IdempotencyKey))
+	if !ok {
 		return
 	}
+	if rec != nil {
+		defer rec.commit()
+		w = rec
+	}
 
-	if !whatsapp.Client.IsConnected() {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp client not connected"})
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
 		return
 	}
 
 	targetJID := utils.CreateTargetJID(req.Target)
 
 	if targetJID.IsEmpty() {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error":  "Invalid target format (must be phone number or group JID)",
+		writeErrorWithFields(w, http.StatusBadRequest, ErrCodeInvalidTarget, "Invalid target format (must be phone number, group JID, newsletter JID or LID)", map[string]interface{}{
 			"target": req.Target,
 		})
 		return
 	}
 
-	targetType := "individual"
-	displayTarget := req.Target
-	if utils.IsGroupJID(req.Target) {
-		targetType = "group"
-	} else {
-		displayTarget = utils.NormalizePhoneNumber(req.Target)
+	// Accept both raw base64 and data URLs such as "data:image/png;base64,...".
+	imageBase64 := strings.TrimSpace(req.Image)
+	if idx := strings.Index(imageBase64, ";base64,"); strings.HasPrefix(imageBase64, "data:") && idx >= 0 {
+		imageBase64 = imageBase64[idx+len(";base64,"):]
+	}
+
+	if imageBase64 == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidImage, "Image data is required (base64)")
+		return
+	}
+
+	if _, err := base64.StdEncoding.DecodeString(imageBase64); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidImage, "Invalid base64 image data: "+err.Error())
+		return
 	}
 
-	log.Printf("Sending message to %s: %s (original: %s)", targetType, displayTarget, req.Target)
+	targetType, displayTarget := utils.DescribeTarget(req.Target)
+
+	log.Printf("[http %s] Sending image to %s: %s (original: %s)", requestIDFrom(r.Context()), targetType, displayTarget, req.Target)
 
-	err := utils.SendMessageWithRetry(context.Background(), targetJID, req.Message, 3)
+	usedFallback, err := utils.SendImageWithRetryStatus(context.Background(), targetJID, imageBase64, req.Caption, utils.ImageRetries())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"error":           err.Error(),
+		writeErrorWithFields(w, http.StatusInternalServerError, ErrCodeSendFailed, err.Error(), map[string]interface{}{
 			"original_target": req.Target,
 			"target_type":     targetType,
+			"fallback":        usedFallback,
 		})
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"status":      "Success",
 		"target":      displayTarget,
 		"target_type": targetType,
+		"uploaded":    !usedFallback,
+		"fallback":    usedFallback,
 	})
 }
 
-func handleBulkSendSameMessage(w http.ResponseWriter, r *http.Request) {
+func handleSendLocation(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var req domain.BulkMessageRequest
+	var req domain.SendLocationRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeBodyError(w, r, err)
 		return
 	}
 
-	SECRET := os.Getenv("API_SECRET")
-	if SECRET == "" {
-		SECRET = "default-secret"
+	rec, ok := beginIdempotent(w, idempotencyKey(r, req.IdempotencyKey))
+	if !ok {
+		return
+	}
+	if rec != nil {
+		defer rec.commit()
+		w = rec
 	}
 
-	if req.Secret != SECRET {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
 		return
 	}
 
-	if !whatsapp.Client.IsConnected() {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp client not connected"})
+	if req.Latitude == nil || req.Longitude == nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidCoordinates, "lat and lng are required")
+		return
+	}
+	if err := utils.ValidateCoordinates(*req.Latitude, *req.Longitude); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidCoordinates, "Invalid coordinates (lat must be -90..90, lng must be -180..180)")
 		return
 	}
 
-	results := make([]map[string]interface{}, len(req.Targets))
-
-	for i, target := range req.Targets {
-		targetJID := utils.CreateTargetJID(target)
-
-		if targetJID.IsEmpty() {
-			results[i] = map[string]interface{}{
-				"original_target": target,
-				"success":         false,
-				"error":           "Invalid JID format",
-			}
-			log.Printf("Skipping invalid bulk target: %s", target)
-			continue
-		}
+	targetJID := utils.CreateTargetJID(req.Target)
 
-		targetType := "individual"
-		displayTarget := target
-		if utils.IsGroupJID(target) {
-			targetType = "group"
-		} else {
-			displayTarget = utils.NormalizePhoneNumber(target)
-		}
+	if targetJID.IsEmpty() {
+		writeErrorWithFields(w, http.StatusBadRequest, ErrCodeInvalidTarget, "Invalid target format (must be phone number, group JID, newsletter JID or LID)", map[string]interface{}{
+			"target": req.Target,
+		})
+		return
+	}
 
-		log.Printf("Sending bulk message %d/%d to %s: %s", i+1, len(req.Targets), targetType, displayTarget)
+	targetType, displayTarget := utils.DescribeTarget(req.Target)
 
-		err := utils.SendMessageWithRetry(context.Background(), targetJID, req.Message, 2)
+	log.Printf("[http %s] Sending location %.6f,%.6f to %s: %s", requestIDFrom(r.Context()), *req.Latitude, *req.Longitude, targetType, displayTarget)
 
-		results[i] = map[string]interface{}{
-			"original_target": target,
-			"target":          displayTarget,
+	if err := utils.SendLocationWithRetry(context.Background(), targetJID, *req.Latitude, *req.Longitude, req.Name, utils.DeliveryRetries()); err != nil {
+		writeErrorWithFields(w, http.StatusInternalServerError, ErrCodeSendFailed, err.Error(), map[string]interface{}{
+			"original_target": req.Target,
 			"target_type":     targetType,
-			"success":         err == nil,
-		}
-
-		if err != nil {
-			results[i]["error"] = err.Error()
-			log.Printf("Failed to send bulk message to %s %s: %v", targetType, displayTarget, err)
-		}
-
-		if i < len(req.Targets)-1 {
-			time.Sleep(1 * time.Second)
-		}
+		})
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "Bulk same message processing completed",
-		"results": results,
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "Success",
+		"target":      displayTarget,
+		"target_type": targetType,
 	})
 }
 
-func handleBulkSendDifferentMessages(w http.ResponseWriter, r *http.Request) {
+func handleSendButtons(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	var req domain.BulkDifferentMessageRequest
+	var req domain.SendButtonsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeBodyError(w, r, err)
 		return
 	}
 
-	SECRET := os.Getenv("API_SECRET")
-	if SECRET == "" {
-		SECRET = "default-secret"
+	rec, ok := beginIdempotent(w, idempotencyKey(r, req.IdempotencyKey))
+	if !ok {
+		return
+	}
+	if rec != nil {
+		defer rec.commit()
+		w = rec
 	}
 
-	if req.Secret != SECRET {
-		w.WriteHeader(http.StatusUnauthorized)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Unauthorized"})
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
 		return
 	}
 
-	if !whatsapp.Client.IsConnected() {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp client not connected"})
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "text is required")
 		return
 	}
-
-	results := make([]map[string]interface{}, len(req.Messages))
-
-	for i, msg := range req.Messages {
-		targetJID := utils.CreateTargetJID(msg.Targets)
-
-		if targetJID.IsEmpty() {
-			results[i] = map[string]interface{}{
-				"original_target": msg.Targets,
-				"success":         false,
-				"error":           "Invalid JID format",
-				"message":         msg.Message,
-			}
-			log.Printf("Skipping invalid different message target: %s", msg.Targets)
-			continue
+	// A button without an ID replies with its label, so the label doubles as the command.
+	for i := range req.Buttons {
+		if strings.TrimSpace(req.Buttons[i].ID) == "" {
+			req.Buttons[i].ID = req.Buttons[i].Label
 		}
+	}
+	if err := utils.ValidateButtons(req.Buttons); err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidButtons, err.Error())
+		return
+	}
 
-		targetType := "individual"
-		displayTarget := msg.Targets
-		if utils.IsGroupJID(msg.Targets) {
-			targetType = "group"
-		} else {
-			displayTarget = utils.NormalizePhoneNumber(msg.Targets)
-		}
+	targetJID := utils.CreateTargetJID(req.Target)
+
+	if targetJID.IsEmpty() {
+		writeErrorWithFields(w, http.StatusBadRequest, ErrCodeInvalidTarget, "Invalid target format (must be phone number, group JID, newsletter JID or LID)", map[string]interface{}{
+			"target": req.Target,
+		})
+		return
+	}
 
-		log.Printf("Sending different message %d/%d to %s: %s", i+1, len(req.Messages), targetType, displayTarget)
+	targetType, displayTarget := utils.DescribeTarget(req.Target)
 
-		err := utils.SendMessageWithRetry(context.Background(), targetJID, msg.Message, 2)
+	log.Printf("[http %s] Sending %d-button message to %s: %s", requestIDFrom(r.Context()), len(req.Buttons), targetType, displayTarget)
 
-		results[i] = map[string]interface{}{
-			"original_target": msg.Targets,
-			"target":          displayTarget,
+	if err := utils.SendButtonsWithRetry(context.Background(), targetJID, req.Text, req.Footer, req.Buttons, utils.DeliveryRetries()); err != nil {
+		writeErrorWithFields(w, http.StatusInternalServerError, ErrCodeSendFailed, err.Error(), map[string]interface{}{
+			"original_target": req.Target,
 			"target_type":     targetType,
-			"success":         err == nil,
-			"message":         msg.Message,
-		}
+		})
+		return
+	}
 
-		if err != nil {
-			results[i]["error"] = err.Error()
-			log.Printf("Failed to send different message to %s %s: %v", targetType, displayTarget, err)
-		}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":      "Success",
+		"target":      displayTarget,
+		"target_type": targetType,
+		"buttons":     len(req.Buttons),
+	})
+}
 
-		if i < len(req.Messages)-1 {
-			time.Sleep(1 * time.Second)
-		}
+func handleBulkSendSameMessage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req domain.BulkMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, r, err)
+		return
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "Bulk different messages processing completed",
-		"results": results,
-	})
+	rec, ok := beginIdempotent(w, idempotencyKey(r, req.IdempotencyKey))
+	if !ok {
+		return
+	}
+	if rec != nil {
+		defer rec.commit()
+		w = rec
+	}
+
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
+		return
+	}
+
+	items := make([]bulkItem, len(req.Targets))
+	for i, target := range req.Targets {
+		items[i] = bulkItem{Target: target, Message: req.Message}
+	}
+
+	job := enqueueBulkJob("same_message", items)
+	writeJobAccepted(w, job, "Bulk same message queued")
+}
+
+func handleBulkSendDifferentMessages(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req domain.BulkDifferentMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBodyError(w, r, err)
+		return
+	}
+
+	rec, ok := beginIdempotent(w, idempotencyKey(r, req.IdempotencyKey))
+	if !ok {
+		return
+	}
+	if rec != nil {
+		defer rec.commit()
+		w = rec
+	}
+
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
+		return
+	}
+
+	items := make([]bulkItem, len(req.Messages))
+	for i, msg := range req.Messages {
+		items[i] = bulkItem{Target: msg.Targets, Message: msg.Message, IncludeMessage: true}
+	}
+
+	job := enqueueBulkJob("different_messages", items)
+	writeJobAccepted(w, job, "Bulk different messages queued")
 }
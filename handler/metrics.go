@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsmeow-api/services/gemini"
+	"whatsmeow-api/services/idx"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+// joinedGroupsTTL keeps Prometheus scrapes from querying WhatsApp for the
+// group list on every request.
+const joinedGroupsTTL = time.Minute
+
+var (
+	joinedGroupsMu      sync.Mutex
+	joinedGroupsCount   = -1
+	joinedGroupsFetched time.Time
+)
+
+// cachedJoinedGroups returns the number of joined groups, or -1 when unknown.
+func cachedJoinedGroups(ctx context.Context) int {
+	joinedGroupsMu.Lock()
+	defer joinedGroupsMu.Unlock()
+
+	if time.Since(joinedGroupsFetched) < joinedGroupsTTL {
+		return joinedGroupsCount
+	}
+	joinedGroupsFetched = time.Now()
+
+	client := whatsapp.GetClient()
+	if client == nil || !client.IsConnected() || !client.IsLoggedIn() {
+		joinedGroupsCount = -1
+		return joinedGroupsCount
+	}
+
+	groupsCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	groups, err := client.GetJoinedGroups(groupsCtx)
+	if err != nil {
+		log.Printf("[metrics] Failed to count joined groups: %v", err)
+		joinedGroupsCount = -1
+		return joinedGroupsCount
+	}
+	joinedGroupsCount = len(groups)
+	return joinedGroupsCount
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// metricsWriter renders metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	sb strings.Builder
+}
+
+func (m *metricsWriter) header(name, kind, help string) {
+	fmt.Fprintf(&m.sb, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func (m *metricsWriter) value(name string, v float64, labels ...string) {
+	m.sb.WriteString(name)
+	if len(labels) > 0 {
+		m.sb.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.sb.WriteByte(',')
+			}
+			fmt.Fprintf(&m.sb, `%s="%s"`, labels[i], labelEscaper.Replace(labels[i+1]))
+		}
+		m.sb.WriteByte('}')
+	}
+	fmt.Fprintf(&m.sb, " %g\n", v)
+}
+
+func (m *metricsWriter) single(name, kind, help string, v float64) {
+	m.header(name, kind, help)
+	m.value(name, v)
+}
+
+func boolGauge(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var m metricsWriter
+
+	m.single("wabot_uptime_seconds", "gauge", "Seconds since the bot started.", time.Since(statsStartedAt).Seconds())
+	m.single("wabot_messages_processed_total", "counter", "Incoming messages handled by the bot.", float64(messagesProcessed.Load()))
+	m.single("wabot_panics_recovered_total", "counter", "Panics recovered during event processing.", float64(panicsRecovered.Load()))
+
+	m.header("wabot_commands_total", "counter", "Commands handled, by command name.")
+	counts := getCommandCounts()
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.value("wabot_commands_total", float64(counts[name]), "command", name)
+	}
+
+	attempted, succeeded := utils.GetSendStats()
+	m.header("wabot_sends_total", "counter", "WhatsApp message sends, by result.")
+	m.value("wabot_sends_total", float64(succeeded), "result", "success")
+	m.value("wabot_sends_total", float64(attempted-succeeded), "result", "failure")
+
+	geminiTotal, geminiFailed := gemini.CallStats()
+	m.header("wabot_gemini_requests_total", "counter", "Gemini API calls, by result.")
+	m.value("wabot_gemini_requests_total", float64(geminiTotal-geminiFailed), "result", "success")
+	m.value("wabot_gemini_requests_total", float64(geminiFailed), "result", "failure")
+
+	scrapes, scrapeFailures, scrapeTime := idx.ScrapeStats()
+	m.header("wabot_idx_scrape_duration_seconds", "summary", "Time spent fetching IDX market data.")
+	m.value("wabot_idx_scrape_duration_seconds_sum", scrapeTime.Seconds())
+	m.value("wabot_idx_scrape_duration_seconds_count", float64(scrapes))
+	m.single("wabot_idx_scrape_failures_total", "counter", "IDX market data fetches that failed.", float64(scrapeFailures))
+
+	client := whatsapp.GetClient()
+	m.single("wabot_whatsapp_connected", "gauge", "1 if the WhatsApp client is connected.", boolGauge(client != nil && client.IsConnected()))
+	m.single("wabot_whatsapp_logged_in", "gauge", "1 if the WhatsApp client is logged in.", boolGauge(client != nil && client.IsLoggedIn()))
+	m.single("wabot_whatsapp_logged_out", "gauge", "1 if the session was logged out remotely.", boolGauge(whatsapp.GetSessionState().LoggedOut))
+
+	if groups := cachedJoinedGroups(r.Context()); groups >= 0 {
+		m.single("wabot_joined_groups", "gauge", "Number of WhatsApp groups the bot is in.", float64(groups))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(m.sb.String()))
+}
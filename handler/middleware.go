@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"whatsmeow-api/config"
+)
+
+func getAPISecret() string {
+	return config.Get().APISecret
+}
+
+// extractRequestSecret looks for the API secret in the X-API-Secret header, then the
+// legacy "secret" field of a JSON body. The body is restored so the wrapped handler
+// can still decode it. The error is only set when the
+// body could not be read, e.g. because it exceeded the size limit.
+func extractRequestSecret(r *http.Request) (string, error) {
+	if secret := r.Header.Get("X-API-Secret"); secret != "" {
+		return secret, nil
+	}
+	if r.Body == nil {
+		return "", nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	if len(body) == 0 {
+		return "", nil
+	}
+
+	var payload struct {
+		Secret string `json:"secret"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return "", nil
+	}
+	return payload.Secret, nil
+}
+
+func writeUnauthorized(w http.ResponseWriter) {
+	writeError(w, http.StatusUnauthorized, ErrCodeUnauthorized, "Unauthorized")
+}
+
+// requireAPISecret rejects requests without a valid API secret before the handler runs.
+func requireAPISecret(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret, err := extractRequestSecret(r)
+		if isBodyTooLarge(err) {
+			writePayloadTooLarge(w, bodyLimitFor(r.URL.Path))
+			return
+		}
+		if secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(getAPISecret())) != 1 {
+			log.Printf("[auth] Unauthorized request: %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+			writeUnauthorized(w)
+			return
+		}
+		next(w, r)
+	}
+}
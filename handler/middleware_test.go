@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"whatsmeow-api/config"
+)
+
+func TestRequireAPISecret(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.APISecret = "s3cret"
+	config.Set(cfg)
+
+	h := requireAPISecret(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name   string
+		req    func() *http.Request
+		status int
+	}{
+		{"header", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/stats", nil)
+			r.Header.Set("X-API-Secret", "s3cret")
+			return r
+		}, http.StatusNoContent},
+		{"json body", func() *http.Request {
+			return httptest.NewRequest(http.MethodPost, "/send-message", strings.NewReader(`{"secret":"s3cret","message":"hi"}`))
+		}, http.StatusNoContent},
+		{"wrong header", func() *http.Request {
+			r := httptest.NewRequest(http.MethodGet, "/stats", nil)
+			r.Header.Set("X-API-Secret", "s3cre")
+			return r
+		}, http.StatusUnauthorized},
+		{"query parameter is not accepted", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/stats?secret=s3cret", nil)
+		}, http.StatusUnauthorized},
+		{"missing", func() *http.Request {
+			return httptest.NewRequest(http.MethodGet, "/stats", nil)
+		}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			h(rec, tt.req())
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+var errOfflineQueueFull = errors.New("offline queue is full")
+
+// queuedSend is a message accepted while WhatsApp was disconnected
+type queuedSend struct {
+	Target   string    `json:"target"`
+	Message  string    `json:"message"`
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+var (
+	offlineQueueMu       sync.Mutex
+	offlineQueueFlushing bool
+)
+
+// queueOnDisconnect reports whether sends should be queued instead of rejected
+// while the client is offline. Enabled with QUEUE_ON_DISCONNECT=true.
+func queueOnDisconnect() bool {
+	return config.Get().QueueOnDisconnect
+}
+
+func getOfflineQueuePath() string {
+	return config.Get().OfflineQueueFile
+}
+
+// loadOfflineQueue reads the queue file. Callers must hold offlineQueueMu.
+func loadOfflineQueue() []queuedSend {
+	b, err := os.ReadFile(getOfflineQueuePath())
+	if err != nil || len(b) == 0 {
+		return nil
+	}
+	var items []queuedSend
+	if err := json.Unmarshal(b, &items); err != nil {
+		log.Printf("[queue] Failed to parse %s: %v", getOfflineQueuePath(), err)
+		return nil
+	}
+	return items
+}
+
+// saveOfflineQueue writes the queue file. Callers must hold offlineQueueMu.
+func saveOfflineQueue(items []queuedSend) error {
+	path := getOfflineQueuePath()
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+	b, err := json.MarshalIndent(items, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// enqueueOfflineSend persists a message to be sent once the client reconnects.
+// It returns the queue length after adding the message.
+func enqueueOfflineSend(target, message string) (int, error) {
+	offlineQueueMu.Lock()
+	defer offlineQueueMu.Unlock()
+
+	items := loadOfflineQueue()
+	if len(items) >= config.Get().OfflineQueueSize {
+		return len(items), errOfflineQueueFull
+	}
+
+	items = append(items, queuedSend{Target: target, Message: message, QueuedAt: time.Now()})
+	if err := saveOfflineQueue(items); err != nil {
+		return len(items) - 1, err
+	}
+	return len(items), nil
+}
+
+// flushOfflineQueue sends everything queued while disconnected. Messages that can
+// never be delivered (e.g. an unknown recipient) are dropped; on a transient
+// failure it stops and keeps the rest for the next reconnect.
+func flushOfflineQueue() {
+	offlineQueueMu.Lock()
+	if offlineQueueFlushing {
+		offlineQueueMu.Unlock()
+		return
+	}
+	offlineQueueFlushing = true
+	offlineQueueMu.Unlock()
+
+	defer func() {
+		offlineQueueMu.Lock()
+		offlineQueueFlushing = false
+		offlineQueueMu.Unlock()
+	}()
+
+	for {
+		offlineQueueMu.Lock()
+		items := loadOfflineQueue()
+		offlineQueueMu.Unlock()
+		if len(items) == 0 {
+			return
+		}
+		if !whatsapp.GetClient().IsConnected() {
+			log.Printf("[queue] Client disconnected again, %d message(s) still queued", len(items))
+			return
+		}
+
+		item := items[0]
+		targetJID := utils.CreateTargetJID(item.Target)
+		if targetJID.IsEmpty() {
+			log.Printf("[queue] Dropping queued message with invalid target %q", item.Target)
+		} else if err := utils.SendMessageWithRetry(context.Background(), targetJID, item.Message, utils.DeliveryRetries()); err != nil {
+			// A logged-out or dropped client fails every send, so it says nothing
+			// about this message.
+			client := whatsapp.GetClient()
+			if utils.IsRetryableSendError(err) || !client.IsConnected() || !client.IsLoggedIn() {
+				log.Printf("[queue] Failed to flush message to %s, will retry on next reconnect: %v", item.Target, err)
+				return
+			}
+			log.Printf("[queue] Dropping message queued at %s for %s, it cannot be delivered: %v", item.QueuedAt.Format(time.RFC3339), item.Target, err)
+		} else {
+			log.Printf("[queue] Delivered message queued at %s to %s", item.QueuedAt.Format(time.RFC3339), item.Target)
+		}
+
+		// Remove the head under the lock; new messages may have been appended meanwhile
+		offlineQueueMu.Lock()
+		current := loadOfflineQueue()
+		if len(current) > 0 {
+			current = current[1:]
+		}
+		if err := saveOfflineQueue(current); err != nil {
+			log.Printf("[queue] Failed to update queue file: %v", err)
+			offlineQueueMu.Unlock()
+			return
+		}
+		offlineQueueMu.Unlock()
+	}
+}
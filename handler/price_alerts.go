@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/services/alerts"
+	"whatsmeow-api/services/idx"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+var alertAddRe = regexp.MustCompile(`^([A-Za-z]{2,6})\s*([<>])\s*([\d.,]+)$`)
+
+const alertUsage = `[Price Alert]
+
+Cara menggunakan:
+- !alert BBCA > 10000 (notifikasi saat harga di atas 10000)
+- !alert TLKM < 3000 (notifikasi saat harga di bawah 3000)
+- !alert list (lihat alert aktif Anda)
+- !alert remove [id] (hapus alert)
+
+Notifikasi dikirim sekali lewat chat pribadi, lalu alert otomatis dihapus.`
+
+func getAlertCheckInterval() time.Duration {
+	return config.Get().PriceAlertInterval
+}
+
+func handleAlertCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var args string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!alert ") || strings.HasPrefix(lower, "/alert ") {
+		args = strings.TrimSpace(originalMessage[7:])
+	}
+
+	owner := v.Info.Sender.ToNonAD().String()
+	argsLower := strings.ToLower(args)
+
+	var response string
+	switch {
+	case args == "":
+		response = alertUsage
+
+	case argsLower == "list":
+		list := alerts.Store.List(owner)
+		if len(list) == 0 {
+			response = "[Price Alert]\n\nAnda belum memiliki alert aktif."
+			break
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("[Price Alert] %d alert aktif\n\n", len(list)))
+		for _, a := range list {
+			sb.WriteString(fmt.Sprintf("#%d %s %s %s\n", a.ID, a.Code, a.Operator, idx.FormatRupiahAmount(a.Threshold)))
+		}
+		sb.WriteString("\nGunakan !alert remove [id] untuk menghapus alert")
+		response = sb.String()
+
+	case strings.HasPrefix(argsLower, "remove"):
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(args[len("remove"):]), "#"))
+		if err != nil {
+			markCommandFailed(v)
+			response = "[Error] ID alert tidak valid. Contoh: !alert remove 3"
+			break
+		}
+		if alerts.Store.Remove(owner, id) {
+			response = fmt.Sprintf("[Price Alert]\n\nAlert #%d berhasil dihapus.", id)
+		} else {
+			markCommandFailed(v)
+			response = fmt.Sprintf("[Error] Alert #%d tidak ditemukan.", id)
+		}
+
+	default:
+		m := alertAddRe.FindStringSubmatch(args)
+		if m == nil {
+			markCommandFailed(v)
+			response = "[Error] Format alert tidak dikenali.\n\n" + alertUsage
+			break
+		}
+		threshold, ok := idx.ParseRupiah(m[3])
+		if !ok || threshold <= 0 {
+			markCommandFailed(v)
+			response = "[Error] Harga target tidak valid. Contoh: !alert BBCA > 10000"
+			break
+		}
+		alert, err := alerts.Store.Add(owner, m[1], m[2], threshold)
+		if err != nil {
+			log.Printf("[alert] Failed to save alert: %v", err)
+			markCommandFailed(v)
+			response = "[Error] Gagal menyimpan alert. Silakan coba lagi nanti."
+			break
+		}
+		response = fmt.Sprintf("[Price Alert]\n\nAlert #%d dibuat: %s %s %s\nHarga dicek setiap %v.",
+			alert.ID, alert.Code, alert.Operator, idx.FormatRupiahAmount(alert.Threshold), getAlertCheckInterval())
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send alert response: %v", err)
+	}
+}
+
+// StartPriceAlertWatcher polls prices for every ticker with an active alert and DMs the
+// owner once the threshold is crossed. It blocks, so run it in its own goroutine.
+func StartPriceAlertWatcher() {
+	interval := getAlertCheckInterval()
+	log.Printf("[alert] Price alert watcher started (interval: %v)", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		checkPriceAlerts()
+	}
+}
+
+func checkPriceAlerts() {
+	codes := alerts.Store.Codes()
+	if len(codes) == 0 || !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	for _, code := range codes {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		price, err := idx.GetStockPrice(ctx, code)
+		cancel()
+		if err != nil {
+			log.Printf("[alert] Failed to fetch price for %s: %v", code, err)
+			continue
+		}
+
+		for _, a := range alerts.Store.TakeTriggered(code, price) {
+			ownerJID, err := types.ParseJID(a.Owner)
+			if err != nil {
+				log.Printf("[alert] Invalid owner JID %s: %v", a.Owner, err)
+				continue
+			}
+
+			direction := "di atas"
+			if a.Operator == "<" {
+				direction = "di bawah"
+			}
+			message := fmt.Sprintf("[Price Alert] %s\n\nHarga %s sekarang %s, sudah %s target %s.\n\nAlert #%d telah dihapus.",
+				a.Code, a.Code, idx.FormatRupiahAmount(price), direction, idx.FormatRupiahAmount(a.Threshold), a.ID)
+
+			if err := utils.SendMessageWithRetry(context.Background(), ownerJID, message, utils.DeliveryRetries()); err != nil {
+				log.Printf("[alert] Failed to notify %s for alert #%d: %v", a.Owner, a.ID, err)
+				// Keep the alert for the next poll unless the owner can never be reached
+				if utils.IsRetryableSendError(err) {
+					if err := alerts.Store.Restore(a); err != nil {
+						log.Printf("[alert] Failed to restore alert #%d: %v", a.ID, err)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/skip2/go-qrcode"
+
+	"whatsmeow-api/whatsapp"
+)
+
+// handleQRCode renders the pending login QR code as a PNG so it can be
+// scanned from a browser. Returns 204 when the device is already logged in.
+func handleQRCode(w http.ResponseWriter, r *http.Request) {
+	if client := whatsapp.GetClient(); client != nil && client.Store.ID != nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	code := whatsapp.GetQRCode()
+	if code == "" {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeQRUnavailable, "QR code not available yet, try again shortly")
+		return
+	}
+
+	png, err := qrcode.Encode(code, qrcode.Medium, 256)
+	if err != nil {
+		log.Printf("[qr] Failed to render QR code: %v", err)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, "Failed to render QR code")
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(png)
+}
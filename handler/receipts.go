@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/whatsapp"
+)
+
+// markReadEnabled reports whether command messages should get a read receipt.
+// Enabled with MARK_READ=true.
+func markReadEnabled() bool {
+	return config.Get().MarkRead
+}
+
+// markCommandRead sends a read receipt for v. whatsmeow expects the sender only
+// for group chats; in DMs the chat already identifies the user.
+func markCommandRead(v *events.Message) {
+	var sender types.JID
+	if v.Info.IsGroup {
+		sender = v.Info.Sender
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if err := whatsapp.GetClient().MarkRead(ctx, []types.MessageID{v.Info.ID}, time.Now(), v.Info.Chat, sender); err != nil {
+		log.Printf("[Warning] Failed to mark message %s as read: %v", v.Info.ID, err)
+	}
+}
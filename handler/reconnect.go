@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"whatsmeow-api/whatsapp"
+)
+
+var reconnectMu sync.Mutex
+
+// handleReconnect drops and re-opens the WhatsApp websocket. Only one reconnect
+// runs at a time; concurrent calls get 409.
+func handleReconnect(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !reconnectMu.TryLock() {
+		writeError(w, http.StatusConflict, ErrCodeInProgress, "Reconnect already in progress")
+		return
+	}
+	defer reconnectMu.Unlock()
+
+	log.Printf("[reconnect] Manual reconnect requested from %s", r.RemoteAddr)
+
+	wasConnected := whatsapp.GetClient().IsConnected()
+	whatsapp.GetClient().Disconnect()
+
+	if err := whatsapp.GetClient().Connect(); err != nil {
+		log.Printf("[reconnect] Connect failed: %v", err)
+		writeErrorWithFields(w, http.StatusInternalServerError, ErrCodeReconnectFailed, err.Error(), map[string]interface{}{
+			"was_connected": wasConnected,
+			"connected":     whatsapp.GetClient().IsConnected(),
+		})
+		return
+	}
+
+	// Connect returns once the socket is open; give the login handshake a moment
+	deadline := time.Now().Add(10 * time.Second)
+	for !whatsapp.GetClient().IsLoggedIn() && time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	connected := whatsapp.GetClient().IsConnected()
+	loggedIn := whatsapp.GetClient().IsLoggedIn()
+	log.Printf("[reconnect] Done (connected=%t, logged_in=%t)", connected, loggedIn)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":        "Success",
+		"was_connected": wasConnected,
+		"connected":     connected,
+		"logged_in":     loggedIn,
+		"timestamp":     time.Now().Format(time.RFC3339),
+	})
+}
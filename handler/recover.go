@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync/atomic"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/utils"
+)
+
+var panicsRecovered atomic.Int64
+
+// recoverEventPanic stops a panic in event processing from taking down the
+// bot. It logs the panic, the stack and the message that caused it. Use it
+// directly with defer.
+func recoverEventPanic(evt interface{}) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	panicsRecovered.Add(1)
+	log.Printf("[panic] Recovered while handling %s: %v\n%s", describeEvent(evt), r, debug.Stack())
+
+	if v, ok := evt.(*events.Message); ok {
+		takeCommandFailed(v)
+		auditCommand(v, commandName(utils.GetMessageText(v.Message)), auditPanic, v.Info.Timestamp)
+	}
+}
+
+// goSafe runs fn in a new goroutine, recovering and logging any panic.
+func goSafe(name string, fn func()) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				panicsRecovered.Add(1)
+				log.Printf("[panic] Recovered in %s: %v\n%s", name, r, debug.Stack())
+			}
+		}()
+		fn()
+	}()
+}
+
+func describeEvent(evt interface{}) string {
+	v, ok := evt.(*events.Message)
+	if !ok {
+		return fmt.Sprintf("event %T", evt)
+	}
+	text := utils.GetMessageText(v.Message)
+	if runes := []rune(text); len(runes) > 200 {
+		text = string(runes[:200]) + "..."
+	}
+	return fmt.Sprintf("message %s in %s from %s (%q)", v.Info.ID, v.Info.Chat, v.Info.Sender, text)
+}
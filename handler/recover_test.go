@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRecoverEventPanic(t *testing.T) {
+	before := panicsRecovered.Load()
+	func() {
+		defer recoverEventPanic(&events.Connected{})
+		panic("boom")
+	}()
+	if got := panicsRecovered.Load() - before; got != 1 {
+		t.Errorf("panicsRecovered grew by %d, want 1", got)
+	}
+
+	func() {
+		defer recoverEventPanic(&events.Connected{})
+	}()
+	if got := panicsRecovered.Load() - before; got != 1 {
+		t.Errorf("a handler that returns normally must not count as a panic (grew by %d)", got)
+	}
+}
+
+func TestGoSafeRecoversPanics(t *testing.T) {
+	before := panicsRecovered.Load()
+	goSafe("test", func() { panic("boom") })
+
+	deadline := time.Now().Add(time.Second)
+	for panicsRecovered.Load() == before {
+		if time.Now().After(deadline) {
+			t.Fatal("goSafe did not recover the panic")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDescribeEvent(t *testing.T) {
+	if got := describeEvent(&events.Connected{}); got != "event *events.Connected" {
+		t.Errorf("describeEvent(Connected) = %q", got)
+	}
+
+	jid := types.NewJID("628500000002", types.DefaultUserServer)
+	msg := &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{Chat: jid, Sender: jid},
+			ID:            "RECOVER1",
+		},
+		Message: &waE2E.Message{Conversation: proto.String(strings.Repeat("é", 300))},
+	}
+	got := describeEvent(msg)
+	if !strings.Contains(got, strings.Repeat("é", 200)+"...") || strings.Contains(got, strings.Repeat("é", 201)) {
+		t.Errorf("message text should be cut to 200 runes, got %q", got)
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/services/schedule"
+	"whatsmeow-api/services/subscription"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+const (
+	minReminderDelay = time.Minute
+	maxReminderDelay = 7 * 24 * time.Hour
+)
+
+const remindAllUsage = `[Pengumuman]
+
+Cara menggunakan (khusus admin):
+- !remindall 2h Rapat dimulai
+- !remindall 30m Server maintenance sebentar lagi
+- !remindall 1d Jangan lupa laporan mingguan
+
+Waktu: menit (m), jam (h) atau hari (d), maksimal 7 hari.
+Pesan dikirim sekali ke semua chat yang berlangganan !subscribe idx.
+Gunakan !schedule list dan !schedule cancel [id] di chat ini untuk melihat atau membatalkan.`
+
+// parseReminderDelay parses Go durations ("2h", "1h30m") plus whole days ("3d")
+// and enforces minReminderDelay..maxReminderDelay.
+func parseReminderDelay(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(s); err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+	}
+
+	if d < minReminderDelay || d > maxReminderDelay {
+		return 0, fmt.Errorf("duration %s outside %s..%s", d, minReminderDelay, maxReminderDelay)
+	}
+	return d, nil
+}
+
+// handleRemindAllCommand schedules a one-time announcement to every chat
+// subscribed to the IDX broadcast: !remindall 2h Meeting starts
+func handleRemindAllCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	fields := strings.Fields(originalMessage)
+	if len(fields) < 3 {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, remindAllUsage, utils.SendRetries())
+		return
+	}
+
+	delay, err := parseReminderDelay(fields[1])
+	if err != nil {
+		replyError(v, "[Error] Waktu tidak valid. Gunakan contoh 30m, 2h atau 1d (minimal 1 menit, maksimal 7 hari).")
+		return
+	}
+
+	// Keep the message's original spacing and line breaks
+	rest := originalMessage[strings.Index(originalMessage, fields[0])+len(fields[0]):]
+	message := strings.TrimSpace(rest[strings.Index(rest, fields[1])+len(fields[1]):])
+
+	recipients := idxBroadcastRecipients()
+	if len(recipients) == 0 {
+		replyError(v, "[Error] Belum ada chat yang berlangganan. Gunakan !subscribe idx di chat tujuan terlebih dahulu.")
+		return
+	}
+
+	saved, err := schedule.Store.Add(schedule.Schedule{
+		Chat:      v.Info.Chat.String(),
+		Kind:      schedule.KindOnce,
+		RunAt:     time.Now().Add(delay).Unix(),
+		Topic:     subscription.TopicIDX,
+		Message:   message,
+		CreatedBy: v.Info.Sender.ToNonAD().String(),
+	})
+	if err != nil {
+		log.Printf("[schedule] Failed to save announcement: %v", err)
+		replyError(v, "[Error] Gagal menyimpan pengumuman. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Pengumuman]\n\nPengumuman #%d dijadwalkan %s.\nTarget saat ini: %d chat (daftar pelanggan diperiksa ulang saat pengiriman).", saved.ID, describeSchedule(saved), len(recipients))
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send remindall response: %v", err)
+	}
+}
+
+// sendTopicReminder delivers a topic schedule to the topic's current subscribers.
+// It is not restored on failure: retrying would resend to the chats that already
+// got it, so a failed recipient is only logged.
+func sendTopicReminder(sc schedule.Schedule) {
+	if sc.Topic != subscription.TopicIDX {
+		log.Printf("[schedule] Unknown topic %q for schedule #%d", sc.Topic, sc.ID)
+		return
+	}
+
+	recipients := idxBroadcastRecipients()
+	message := "[Pengumuman]\n\n" + sc.Message + "\n\n[Ketik !unsubscribe idx untuk berhenti menerima pesan ini]"
+
+	sent := 0
+	for _, recipient := range recipients {
+		jid, err := types.ParseJID(recipient)
+		if err != nil {
+			log.Printf("[schedule] Invalid recipient %s: %v", recipient, err)
+			continue
+		}
+		if err := utils.SendMessageWithRetry(context.Background(), jid, message, utils.DeliveryRetries()); err != nil {
+			log.Printf("[schedule] Failed to send schedule #%d to %s: %v", sc.ID, recipient, err)
+			continue
+		}
+		sent++
+		time.Sleep(time.Second)
+	}
+	log.Printf("[schedule] Sent schedule #%d to %d/%d subscribers", sc.ID, sent, len(recipients))
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type requestIDKey struct{}
+
+// newRequestID returns a short random ID used to correlate log lines.
+func newRequestID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano()%0xffffffff, 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// requestIDFrom returns the request ID stored by requestIDMiddleware, or "-".
+func requestIDFrom(ctx context.Context) string {
+	if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+		return id
+	}
+	return "-"
+}
+
+// requestIDWriter records the response status for the request log. Writes go
+// straight through, so file downloads, images and streamed responses are not
+// held in memory.
+type requestIDWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rw *requestIDWriter) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *requestIDWriter) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	return rw.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *requestIDWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Flush forwards to the underlying writer when it supports streaming.
+func (rw *requestIDWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// requestIDMiddleware tags every HTTP request with an ID: it is stored in the
+// request context, echoed in the X-Request-ID header and added to JSON
+// bodies by writeJSON.
+// An incoming X-Request-ID header is reused so callers can correlate their own logs.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+
+		w.Header().Set("X-Request-ID", id)
+		rw := &requestIDWriter{ResponseWriter: w}
+		start := time.Now()
+
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+		log.Printf("[http %s] %s %s -> %d (%v)", id, r.Method, r.URL.Path, rw.status, time.Since(start).Round(time.Millisecond))
+	})
+}
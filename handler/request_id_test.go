@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDMiddlewareAddsIDToErrors(t *testing.T) {
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "bad")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Request-ID", "abc123")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", rec.Code)
+	}
+	if got := rec.Header().Get("X-Request-ID"); got != "abc123" {
+		t.Errorf("X-Request-ID = %q, want abc123", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["request_id"] != "abc123" {
+		t.Errorf("request_id = %v, want abc123", body["request_id"])
+	}
+}
+
+func TestRequestIDMiddlewarePassesBodiesThrough(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\nnot really a png")
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", "24")
+		w.Write(png[:12])
+		w.(http.Flusher).Flush()
+		w.Write(png[12:])
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/qr", nil))
+
+	if !rec.Flushed {
+		t.Error("Flush was not forwarded to the underlying writer")
+	}
+	if got := rec.Header().Get("Content-Length"); got != "24" {
+		t.Errorf("Content-Length = %q, want 24", got)
+	}
+	if rec.Body.String() != string(png) {
+		t.Errorf("body = %q, want %q", rec.Body.String(), png)
+	}
+	if rec.Header().Get("X-Request-ID") == "" {
+		t.Error("X-Request-ID header not set")
+	}
+}
+
+func TestRequestIDMiddlewareAddsIDToSuccess(t *testing.T) {
+	h := requestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusAccepted, map[string]interface{}{"status": "queued"})
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/send", nil)
+	req.Header.Set("X-Request-ID", "ok789")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want 202", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode body: %v", err)
+	}
+	if body["request_id"] != "ok789" || body["status"] != "queued" {
+		t.Errorf("body = %v, want status queued and request_id ok789", body)
+	}
+}
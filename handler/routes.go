@@ -2,16 +2,20 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 
+	"whatsmeow-api/services/gemini"
 	"whatsmeow-api/services/idx"
 	"whatsmeow-api/utils"
 	"whatsmeow-api/whatsapp"
@@ -19,16 +23,36 @@ import (
 
 func SetupRoutes() *mux.Router {
 	r := mux.NewRouter()
+	r.Use(requestIDMiddleware)
+	r.Use(bodyLimitMiddleware)
 
 	r.HandleFunc("/health", handleHealthCheck).Methods("GET")
 
 	r.HandleFunc("/", handleMainStatus).Methods("GET")
 
-	r.HandleFunc("/send-message", handleSendMessage).Methods("POST")
-	r.HandleFunc("/send-bulk-same-message", handleBulkSendSameMessage).Methods("POST")
-	r.HandleFunc("/send-bulk-different-messages", handleBulkSendDifferentMessages).Methods("POST")
+	// Send endpoints require the API secret (X-API-Secret header or "secret" JSON field).
+	// Webhooks stay open because GitHub/GitLab/Viseron cannot attach the header.
+	r.HandleFunc("/send-message", requireAPISecret(handleSendMessage)).Methods("POST")
+	r.HandleFunc("/send-image", requireAPISecret(handleSendImage)).Methods("POST")
+	r.HandleFunc("/send-location", requireAPISecret(handleSendLocation)).Methods("POST")
+	r.HandleFunc("/send-message-with-buttons", requireAPISecret(handleSendButtons)).Methods("POST")
+	r.HandleFunc("/send-bulk-same-message", requireAPISecret(handleBulkSendSameMessage)).Methods("POST")
+	r.HandleFunc("/send-bulk-different-messages", requireAPISecret(handleBulkSendDifferentMessages)).Methods("POST")
+	r.HandleFunc("/job/{id}", requireAPISecret(handleGetJob)).Methods("GET")
 
 	r.HandleFunc("/github-webhook", handleGitHubWebhook).Methods("POST")
+	r.HandleFunc("/gitlab-webhook", handleGitLabWebhook).Methods("POST")
+	r.HandleFunc("/webhook-log", requireAPISecret(handleWebhookLog)).Methods("GET")
+
+	r.HandleFunc("/stats", requireAPISecret(handleStats)).Methods("GET")
+	r.HandleFunc("/stats/reset", requireAPISecret(handleResetStats)).Methods("POST")
+	r.HandleFunc("/metrics", requireAPISecret(handleMetrics)).Methods("GET")
+
+	r.HandleFunc("/qr", requireAPISecret(handleQRCode)).Methods("GET")
+	r.HandleFunc("/reconnect", requireAPISecret(handleReconnect)).Methods("POST")
+
+	r.HandleFunc("/download", requireAPISecret(handleListArchivedMedia)).Methods("GET")
+	r.HandleFunc("/download/{name}", requireAPISecret(handleDownloadArchivedMedia)).Methods("GET")
 
 	r.HandleFunc("/viseron-webhook", handleViseronWebhook).Methods("POST")
 
@@ -43,66 +67,199 @@ func SetupRoutes() *mux.Router {
 
 func handleHealthCheck(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+
+	resp := map[string]interface{}{
 		"status":    "healthy",
 		"timestamp": time.Now().Format(time.RFC3339),
-		"whatsapp":  whatsapp.Client.IsConnected(),
+		"whatsapp":  whatsapp.GetClient().IsConnected(),
 		"version":   "2.0.0",
-	})
+	}
+
+	// A logged-out session never recovers on its own, so report it as
+	// unhealthy for monitoring to alert on.
+	status := http.StatusOK
+	if session := whatsapp.GetSessionState(); session.LoggedOut {
+		status = http.StatusServiceUnavailable
+		resp["status"] = "logged_out"
+		resp["session"] = map[string]interface{}{
+			"logged_out": true,
+			"reason":     session.Reason,
+			"since":      session.Since.Format(time.RFC3339),
+		}
+	}
+
+	if r.URL.Query().Get("detail") == "true" {
+		resp["detail"] = healthDetail(r.Context())
+	}
+
+	writeJSON(w, status, resp)
+}
+
+// healthDetail collects extra monitoring fields for /health?detail=true. Fields that
+// need a live connection are left null when the client is offline.
+func healthDetail(ctx context.Context) map[string]interface{} {
+	detail := map[string]interface{}{
+		"device_jid":        nil,
+		"logged_in":         whatsapp.GetClient().IsLoggedIn(),
+		"joined_groups":     nil,
+		"memory_keys":       gemini.MemStore.KeyCount(),
+		"last_connected_at": nil,
+	}
+
+	if id := whatsapp.GetClient().Store.ID; id != nil {
+		detail["device_jid"] = id.String()
+	}
+	if ts := lastConnectedAt.Load(); ts > 0 {
+		detail["last_connected_at"] = time.Unix(ts, 0).Format(time.RFC3339)
+	}
+
+	if whatsapp.GetClient().IsConnected() && whatsapp.GetClient().IsLoggedIn() {
+		groupsCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if groups, err := whatsapp.GetClient().GetJoinedGroups(groupsCtx); err == nil {
+			detail["joined_groups"] = len(groups)
+		} else {
+			log.Printf("[health] Failed to count joined groups: %v", err)
+		}
+	}
+
+	return detail
 }
 
 func handleMainStatus(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"status":    "WhatsApp Bot API is running",
-		"connected": whatsapp.Client.IsConnected(),
+		"connected": whatsapp.GetClient().IsConnected(),
 		"timestamp": time.Now().Format(time.RFC3339),
 		"endpoints": []string{
-			"/health",
+			"/health (?detail=true for device, groups and memory info)",
 			"/send-message",
+			"/send-image",
+			"/send-location",
+			"/send-message-with-buttons",
 			"/send-bulk-same-message",
 			"/send-bulk-different-messages",
+			"/job/{id} (bulk send progress)",
 			"/github-webhook (supports ?jid=<target_jid> parameter)",
+			"/gitlab-webhook (push and merge request events, supports ?jid=<target_jid> parameter)",
+			"/webhook-log (requires X-API-Secret header)",
 			"/viseron-webhook",
 			"/groups",
+			"/stats (requires X-API-Secret header)",
+			"/stats/reset (POST, requires X-API-Secret header)",
+			"/metrics (Prometheus text format, requires X-API-Secret header)",
+			"/qr (login QR code as PNG, requires X-API-Secret header)",
+			"/reconnect (POST, requires X-API-Secret header)",
+			"/download (archived media list, requires X-API-Secret header)",
+			"/download/{name} (archived media file, requires X-API-Secret header)",
 		},
 	})
 }
 
+// parseGroupsPaging reads the optional limit/offset query parameters. A zero
+// limit means "no limit" so plain GET /groups keeps returning every group.
+func parseGroupsPaging(r *http.Request) (limit, offset int, err error) {
+	q := r.URL.Query()
+	if raw := q.Get("limit"); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 {
+			return 0, 0, fmt.Errorf("limit must be a positive integer")
+		}
+	}
+	if raw := q.Get("offset"); raw != "" {
+		offset, err = strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return 0, 0, fmt.Errorf("offset must be a non-negative integer")
+		}
+	}
+	return limit, offset, nil
+}
+
 func handleGetGroups(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if !whatsapp.Client.IsConnected() {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp client not connected"})
+	limit, offset, err := parseGroupsPaging(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, err.Error())
 		return
 	}
+	name := strings.TrimSpace(r.URL.Query().Get("name"))
 
-	groups, err := whatsapp.Client.GetJoinedGroups(context.Background())
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp client not connected")
+		return
+	}
+
+	groups, err := whatsapp.GetClient().GetJoinedGroups(context.Background())
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
 		return
 	}
 
-	groupList := make([]map[string]interface{}, len(groups))
-	for i, group := range groups {
+	type groupMatch struct {
+		group *types.GroupInfo
+		score float64
+	}
+	matches := make([]groupMatch, 0, len(groups))
+	for _, group := range groups {
+		if name == "" {
+			matches = append(matches, groupMatch{group: group})
+			continue
+		}
+		// Same fuzzy matching and threshold as the !groups search
+		if score := utils.FuzzyScore(name, group.Name); score >= groupsMinMatchScore {
+			matches = append(matches, groupMatch{group: group, score: score})
+		}
+	}
+
+	// Sort so pages are stable between requests: best match first when
+	// filtering, alphabetical otherwise.
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return strings.ToLower(matches[i].group.Name) < strings.ToLower(matches[j].group.Name)
+	})
+
+	total := len(matches)
+	if offset > total {
+		offset = total
+	}
+	matches = matches[offset:]
+	if limit > 0 && limit < len(matches) {
+		matches = matches[:limit]
+	}
+
+	groupList := make([]map[string]interface{}, len(matches))
+	for i, match := range matches {
+		group := match.group
 		groupList[i] = map[string]interface{}{
 			"jid":        group.JID.String(),
 			"name":       group.Name,
 			"owner":      group.OwnerJID.String(),
 			"created_at": group.GroupCreated.Unix(),
 		}
+		if name != "" {
+			groupList[i]["score"] = match.score
+		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	resp := map[string]interface{}{
 		"status":    "Success",
-		"total":     len(groups),
+		"total":     total,
+		"count":     len(groupList),
+		"offset":    offset,
 		"groups":    groupList,
 		"timestamp": time.Now().Format(time.RFC3339),
-	})
+	}
+	if limit > 0 {
+		resp["limit"] = limit
+	}
+	if name != "" {
+		resp["name"] = name
+	}
+
+	writeJSON(w, http.StatusOK, resp)
 }
 
 func handleIDXData(w http.ResponseWriter, r *http.Request) {
@@ -113,17 +270,13 @@ func handleIDXData(w http.ResponseWriter, r *http.Request) {
 	data, err := idx.GetIDXMarketData(time.Time{})
 	if err != nil {
 		log.Printf("[Error] Error fetching IDX data: %v", err)
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Failed to fetch IDX data: " + err.Error(),
-		})
+		writeError(w, http.StatusInternalServerError, ErrCodeUpstreamFailed, "Failed to fetch IDX data: "+err.Error())
 		return
 	}
 
 	response := idx.FormatIDXResponse(data)
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"status":    "success",
 		"timestamp": time.Now().Format(time.RFC3339),
 		"data":      data,
@@ -131,10 +284,82 @@ func handleIDXData(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// commandAliases maps alternative command names to the command they run, so an
+// alias shares its command's admin gate, cooldown and stats.
+var commandAliases = map[string]string{
+	"repeat": "schedule",
+	"dice":   "roll",
+	"time":   "jadwal",
+}
+
+// commandHandler runs one command. message is the full command text, trimmed.
+type commandHandler func(v *events.Message, message string)
+
+// commandHandlers maps the exact command name, as returned by commandName, to
+// its handler. The admin gate and cooldowns use the same name, so "!groupsx"
+// is an unknown command rather than !groups.
+var commandHandlers = map[string]commandHandler{
+	"help":        handleHelpCommand,
+	"menu":        func(v *events.Message, message string) { handleMenuCommand(v) },
+	"hallo":       func(v *events.Message, message string) { handleHalloCommand(v) },
+	"ping":        func(v *events.Message, message string) { handlePingCommand(v) },
+	"status":      func(v *events.Message, message string) { handleStatusCommand(v) },
+	"info":        func(v *events.Message, message string) { handleInfoCommand(v) },
+	"groups":      handleGroupsCommand,
+	"schedule":    handleScheduleCommand,
+	"test":        func(v *events.Message, message string) { handleTestCommand(v) },
+	"echo":        handleEchoCommand,
+	"persona":     handlePersonaCommand,
+	"fiq":         handleFiqCommand,
+	"apik":        handleApikCommand,
+	"idx":         handleIDXCommand,
+	"gainers":     func(v *events.Message, message string) { handleMoversCommand(v, true, false) },
+	"losers":      func(v *events.Message, message string) { handleMoversCommand(v, false, true) },
+	"top":         func(v *events.Message, message string) { handleMoversCommand(v, true, true) },
+	"news":        func(v *events.Message, message string) { handleNewsCommand(v) },
+	"alert":       handleAlertCommand,
+	"voice":       handleVoiceCommand,
+	"img":         handleImgCommand,
+	"cctv":        handleCCTVCommand,
+	"jid":         handleJIDCommand,
+	"location":    handleLocationCommand,
+	"edit":        handleEditCommand,
+	"unsubscribe": func(v *events.Message, message string) { handleSubscribeCommand(v, message, false) },
+	"subscribe":   func(v *events.Message, message string) { handleSubscribeCommand(v, message, true) },
+	"tts":         handleTTSCommand,
+	"convert":     handleConvertCommand,
+	"shorten":     handleShortenCommand,
+	"feedback":    handleFeedbackCommand,
+	"remindall":   handleRemindAllCommand,
+	"setname":     handleSetNameCommand,
+	"groupinfo":   func(v *events.Message, message string) { handleGroupInfoCommand(v) },
+	"whois":       func(v *events.Message, message string) { handleWhoisCommand(v) },
+	"describe":    handleDescribeCommand,
+	"summarize":   func(v *events.Message, message string) { handleSummarizeCommand(v) },
+	"sentiment":   func(v *events.Message, message string) { handleSentimentCommand(v) },
+	"define":      handleDefineCommand,
+	"wiki":        handleWikiCommand,
+	"ytdl":        handleYtdlCommand,
+	"calc":        handleCalcCommand,
+	"roll":        handleRollCommand,
+	"countdown":   handleCountdownCommand,
+	"jadwal":      handleJadwalCommand,
+	"sticker":     func(v *events.Message, message string) { handleStickerCommand(v) },
+	"joke":        func(v *events.Message, message string) { handleJokeCommand(v) },
+	"quote":       func(v *events.Message, message string) { handleQuoteCommand(v) },
+}
+
 func EventHandler(evt interface{}) {
+	defer recoverEventPanic(evt)
+
 	switch v := evt.(type) {
 	case *events.Message:
 
+		if markMessageProcessed(string(v.Info.ID)) {
+			log.Printf("[Warning] Skipping already processed message: %s", v.Info.ID)
+			return
+		}
+
 		if v.Info.IsGroup {
 			if utils.ShouldIgnoreGroup(v.Info.Chat.String()) {
 				log.Printf("[Warning] Ignoring command from ignored group: %s", v.Info.Chat.String())
@@ -142,39 +367,78 @@ func EventHandler(evt interface{}) {
 			}
 		}
 
+		if archiveMediaEnabled() && !v.Info.IsFromMe {
+			goSafe("media archive", func() { archiveIncomingMedia(v) })
+		}
+
 		message := utils.GetMessageText(v.Message)
 		if strings.TrimSpace(message) == "" {
 			return
 		}
-		if utils.HasCommandPrefix(message, "/help") || utils.HasCommandPrefix(message, "!help") {
-			handleHelpCommand(v)
-		} else if utils.HasCommandPrefix(message, "/hallo") || utils.HasCommandPrefix(message, "!hallo") {
-			handleHalloCommand(v)
-		} else if utils.HasCommandPrefix(message, "/ping") || utils.HasCommandPrefix(message, "!ping") {
-			handlePingCommand(v)
-		} else if utils.HasCommandPrefix(message, "/status") || utils.HasCommandPrefix(message, "!status") {
-			handleStatusCommand(v)
-		} else if utils.HasCommandPrefix(message, "/info") || utils.HasCommandPrefix(message, "!info") {
-			handleInfoCommand(v)
-		} else if utils.HasCommandPrefix(message, "/groups") || utils.HasCommandPrefix(message, "!groups") {
-			handleGroupsCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/test") || utils.HasCommandPrefix(message, "!test") {
-			handleTestCommand(v)
-		} else if utils.HasCommandPrefix(message, "/echo") || utils.HasCommandPrefix(message, "!echo") {
-			handleEchoCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/fiq") || utils.HasCommandPrefix(message, "!fiq") {
-			handleFiqCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/apik") || utils.HasCommandPrefix(message, "!apik") {
-			handleApikCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/idx") || utils.HasCommandPrefix(message, "!idx") {
-			handleIDXCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/img") || utils.HasCommandPrefix(message, "!img") {
-			handleImgCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/cctv") || utils.HasCommandPrefix(message, "!cctv") {
-			handleCCTVCommand(v, message)
-		} else if utils.HasCommandPrefix(message, "/jid") || utils.HasCommandPrefix(message, "!jid") {
-			handleJIDCommand(v, message)
+
+		if command, ok := resolveMenuSelection(strings.TrimSpace(message)); ok {
+			message = command
+		}
+
+		if mentionReplyEnabled() {
+			if command, ok := rewriteBotMention(v, message); ok {
+				message = command
+			}
+		}
+
+		message = strings.TrimSpace(message)
+		messagesProcessed.Add(1)
+		command := commandName(message)
+		if canonical, ok := commandAliases[command]; ok {
+			command = canonical
+		}
+		recordCommand(command)
+
+		if command != "" {
+			cmdID := newRequestID()
+			start := time.Now()
+			log.Printf("[cmd %s] !%s from %s in %s", cmdID, command, v.Info.Sender.String(), v.Info.Chat.String())
+			defer func() {
+				log.Printf("[cmd %s] !%s finished in %v", cmdID, command, time.Since(start).Round(time.Millisecond))
+			}()
+		}
+
+		if command != "" && markReadEnabled() {
+			goSafe("read receipt", func() { markCommandRead(v) })
+		}
+
+		auditStart := time.Now()
+
+		handle, ok := commandHandlers[command]
+		if !ok {
+			auditCommand(v, command, auditUnknown, auditStart)
+			return
+		}
+
+		if adminCommands[command] && !requireAdmin(v, command) {
+			auditCommand(v, command, auditDenied, auditStart)
+			return
+		}
+
+		if !enforceCommandCooldown(v, command) {
+			auditCommand(v, command, auditCooldown, auditStart)
+			return
+		}
+
+		handle(v, message)
+		status := auditOK
+		if takeCommandFailed(v) {
+			status = auditError
+		}
+		auditCommand(v, command, status, auditStart)
+	case *events.Connected:
+		recordConnected()
+		whatsapp.ClearLoggedOut()
+		if queueOnDisconnect() {
+			goSafe("offline queue flush", flushOfflineQueue)
 		}
+	case *events.LoggedOut:
+		handleLoggedOut(v)
 	default:
 
 		log.Printf("Event type: %T", evt)
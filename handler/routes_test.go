@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-api/config"
+)
+
+var testMessageSeq int
+
+func testMessage(sender, text string) *events.Message {
+	testMessageSeq++
+	return &events.Message{
+		Info: types.MessageInfo{
+			MessageSource: types.MessageSource{
+				Chat:   types.NewJID(sender, types.DefaultUserServer),
+				Sender: types.NewJID(sender, types.DefaultUserServer),
+			},
+			ID: types.MessageID(fmt.Sprintf("TEST%d", testMessageSeq)),
+		},
+		Message: &waE2E.Message{Conversation: proto.String(text)},
+	}
+}
+
+func TestEventHandlerAdminGateUsesExactCommand(t *testing.T) {
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	cfg.AdminJIDs = []string{"628111111111"}
+	cfg.OwnerJIDs = nil
+	config.Set(cfg)
+
+	var calls []string
+	orig := commandHandlers["groups"]
+	commandHandlers["groups"] = func(v *events.Message, message string) {
+		calls = append(calls, v.Info.Sender.User+" "+message)
+	}
+	t.Cleanup(func() { commandHandlers["groups"] = orig })
+
+	tests := []struct {
+		name   string
+		sender string
+		text   string
+		want   bool
+	}{
+		{"admin runs !groups", "628111111111", "!groups", true},
+		{"non-admin is denied !groups", "628222222222", "!groups", false},
+		{"non-admin suffix is not !groups", "628222222222", "!groupsx", false},
+		{"non-admin suffix with args", "628222222222", "/groupsx Braincore", false},
+		{"admin suffix is not !groups either", "628111111111", "!groupsx", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			EventHandler(testMessage(tt.sender, tt.text))
+			if got := len(calls) == 1; got != tt.want {
+				t.Errorf("%q from %s: handler called = %v, want %v (calls: %v)", tt.text, tt.sender, got, tt.want, calls)
+			}
+		})
+	}
+}
+
+func TestAdminCommandsHaveHandlers(t *testing.T) {
+	// broadcast and reminder are reserved for future commands
+	reserved := map[string]bool{"broadcast": true, "reminder": true}
+	for command := range adminCommands {
+		if _, ok := commandHandlers[command]; !ok && !reserved[command] {
+			t.Errorf("admin command %q has no handler, so the gate protects nothing", command)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/services/schedule"
+	"whatsmeow-api/utils"
+	"whatsmeow-api/whatsapp"
+)
+
+var scheduleTimeRe = regexp.MustCompile(`^([01]?\d|2[0-3])[:.]([0-5]\d)$`)
+
+var scheduleWeekdays = map[string]time.Weekday{
+	"minggu": time.Sunday, "sunday": time.Sunday, "sun": time.Sunday,
+	"senin": time.Monday, "monday": time.Monday, "mon": time.Monday,
+	"selasa": time.Tuesday, "tuesday": time.Tuesday, "tue": time.Tuesday,
+	"rabu": time.Wednesday, "wednesday": time.Wednesday, "wed": time.Wednesday,
+	"kamis": time.Thursday, "thursday": time.Thursday, "thu": time.Thursday,
+	"jumat": time.Friday, "friday": time.Friday, "fri": time.Friday,
+	"sabtu": time.Saturday, "saturday": time.Saturday, "sat": time.Saturday,
+}
+
+var scheduleWeekdayNames = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+const scheduleUsage = `[Jadwal Pesan]
+
+Cara menggunakan (khusus admin):
+- !schedule daily 09:00 Selamat pagi!
+- !schedule weekly senin 08:00 Jangan lupa rapat mingguan
+- !schedule list (lihat jadwal di chat ini)
+- !schedule cancel [id] (hapus jadwal)
+
+Waktu menggunakan WIB. Pesan dikirim ke chat tempat perintah ini diketik.`
+
+func describeSchedule(sc schedule.Schedule) string {
+	if sc.Kind == schedule.KindOnce {
+		when := "sekali pada " + time.Unix(sc.RunAt, 0).In(utils.WIB()).Format("02 Jan 2006 15:04") + " WIB"
+		if sc.Topic != "" {
+			when += fmt.Sprintf(" ke semua pelanggan %s", sc.Topic)
+		}
+		return when
+	}
+	when := fmt.Sprintf("setiap hari %02d:%02d", sc.Hour, sc.Minute)
+	if sc.Kind == schedule.KindWeekly {
+		when = fmt.Sprintf("setiap %s %02d:%02d", scheduleWeekdayNames[sc.Weekday], sc.Hour, sc.Minute)
+	}
+	return when + " WIB"
+}
+
+// parseScheduleSpec parses "daily HH:MM message" or "weekly <day> HH:MM message".
+func parseScheduleSpec(args string) (schedule.Schedule, error) {
+	fields := strings.Fields(args)
+	var sc schedule.Schedule
+	if len(fields) == 0 {
+		return sc, fmt.Errorf("empty schedule")
+	}
+
+	sc.Kind = strings.ToLower(fields[0])
+	rest := fields[1:]
+	switch sc.Kind {
+	case schedule.KindDaily:
+	case schedule.KindWeekly:
+		if len(rest) == 0 {
+			return sc, fmt.Errorf("missing day")
+		}
+		day, ok := scheduleWeekdays[strings.ToLower(rest[0])]
+		if !ok {
+			return sc, fmt.Errorf("unknown day %q", rest[0])
+		}
+		sc.Weekday = int(day)
+		rest = rest[1:]
+	default:
+		return sc, fmt.Errorf("unknown kind %q", sc.Kind)
+	}
+
+	if len(rest) < 2 {
+		return sc, fmt.Errorf("missing time or message")
+	}
+	m := scheduleTimeRe.FindStringSubmatch(rest[0])
+	if m == nil {
+		return sc, fmt.Errorf("invalid time %q", rest[0])
+	}
+	sc.Hour, _ = strconv.Atoi(m[1])
+	sc.Minute, _ = strconv.Atoi(m[2])
+
+	// Keep the message's original spacing and line breaks
+	idx := strings.Index(args, rest[0]) + len(rest[0])
+	sc.Message = strings.TrimSpace(args[idx:])
+	if sc.Message == "" {
+		return sc, fmt.Errorf("missing message")
+	}
+	return sc, nil
+}
+
+func handleScheduleCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var args string
+	if parts := strings.SplitN(strings.TrimSpace(originalMessage), " ", 2); len(parts) == 2 {
+		args = strings.TrimSpace(parts[1])
+	}
+
+	chat := v.Info.Chat.String()
+	argsLower := strings.ToLower(args)
+
+	var response string
+	switch {
+	case args == "":
+		response = scheduleUsage
+
+	case argsLower == "list":
+		list := schedule.Store.List(chat)
+		if len(list) == 0 {
+			response = "[Jadwal Pesan]\n\nBelum ada jadwal di chat ini."
+			break
+		}
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("[Jadwal Pesan] %d jadwal aktif\n\n", len(list)))
+		for _, sc := range list {
+			preview := []rune(sc.Message)
+			if len(preview) > 40 {
+				preview = append(preview[:40], []rune("...")...)
+			}
+			sb.WriteString(fmt.Sprintf("#%d %s\n%s\n\n", sc.ID, describeSchedule(sc), string(preview)))
+		}
+		sb.WriteString("Gunakan !schedule cancel [id] untuk menghapus jadwal")
+		response = sb.String()
+
+	case strings.HasPrefix(argsLower, "cancel"):
+		id, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(args[len("cancel"):]), "#"))
+		if err != nil {
+			markCommandFailed(v)
+			response = "[Error] ID jadwal tidak valid. Contoh: !schedule cancel 3"
+			break
+		}
+		if schedule.Store.Remove(chat, id) {
+			response = fmt.Sprintf("[Jadwal Pesan]\n\nJadwal #%d berhasil dihapus.", id)
+		} else {
+			markCommandFailed(v)
+			response = fmt.Sprintf("[Error] Jadwal #%d tidak ditemukan di chat ini.", id)
+		}
+
+	default:
+		sc, err := parseScheduleSpec(args)
+		if err != nil {
+			markCommandFailed(v)
+			response = "[Error] Format jadwal tidak dikenali.\n\n" + scheduleUsage
+			break
+		}
+		sc.Chat = chat
+		sc.CreatedBy = v.Info.Sender.ToNonAD().String()
+
+		// Don't fire immediately if today's slot has just passed
+		if now := time.Now().In(utils.WIB()); sc.Due(now) {
+			sc.LastRun = now.Format("2006-01-02")
+		}
+
+		saved, err := schedule.Store.Add(sc)
+		if err != nil {
+			log.Printf("[schedule] Failed to save schedule: %v", err)
+			markCommandFailed(v)
+			response = "[Error] Gagal menyimpan jadwal. Silakan coba lagi nanti."
+			break
+		}
+		response = fmt.Sprintf("[Jadwal Pesan]\n\nJadwal #%d dibuat: %s.", saved.ID, describeSchedule(saved))
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send schedule response: %v", err)
+	}
+}
+
+// StartScheduler sends recurring scheduled messages when they fall due. It blocks,
+// so run it in its own goroutine.
+func StartScheduler() {
+	log.Printf("[schedule] Scheduler started")
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if !whatsapp.GetClient().IsConnected() {
+			continue
+		}
+
+		for _, sc := range schedule.Store.TakeDue(time.Now().In(utils.WIB())) {
+			if sc.Topic != "" {
+				sendTopicReminder(sc)
+				continue
+			}
+			chatJID, err := types.ParseJID(sc.Chat)
+			if err != nil {
+				log.Printf("[schedule] Invalid chat JID %s for schedule #%d: %v", sc.Chat, sc.ID, err)
+				continue
+			}
+			if err := utils.SendMessageWithRetry(context.Background(), chatJID, sc.Message, utils.DeliveryRetries()); err != nil {
+				log.Printf("[schedule] Failed to send schedule #%d to %s: %v", sc.ID, sc.Chat, err)
+				if utils.IsRetryableSendError(err) {
+					if err := schedule.Store.Restore(sc); err != nil {
+						log.Printf("[schedule] Failed to restore schedule #%d: %v", sc.ID, err)
+					}
+				}
+				continue
+			}
+			log.Printf("[schedule] Sent schedule #%d to %s", sc.ID, sc.Chat)
+		}
+	}
+}
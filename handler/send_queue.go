@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	mrand "math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/utils"
+)
+
+type bulkItem struct {
+	Target         string
+	Message        string
+	IncludeMessage bool
+}
+
+type bulkJob struct {
+	mu         sync.Mutex
+	ID         string
+	Kind       string
+	Status     string
+	CreatedAt  time.Time
+	FinishedAt time.Time
+	Total      int
+	Completed  int
+	Succeeded  int
+	Results    []map[string]interface{}
+}
+
+type bulkTask struct {
+	job   *bulkJob
+	index int
+	item  bulkItem
+}
+
+var (
+	bulkQueue     chan bulkTask
+	bulkQueueOnce sync.Once
+	bulkPacer     *sendPacer
+
+	bulkJobsMu    sync.Mutex
+	bulkJobs      = make(map[string]*bulkJob)
+	bulkJobsOrder []string
+)
+
+// sendPacer spaces out bulk sends. Each send waits a base delay plus a random
+// jitter so the cadence doesn't look mechanical, and consecutive failures back
+// the delay off exponentially until a send goes through again.
+type sendPacer struct {
+	mu       sync.Mutex
+	base     time.Duration
+	jitter   time.Duration
+	next     time.Time
+	failures int
+}
+
+const maxBulkBackoffShift = 4
+
+func newSendPacer(base, jitter time.Duration) *sendPacer {
+	return &sendPacer{base: base, jitter: jitter}
+}
+
+func (p *sendPacer) delay() time.Duration {
+	d := p.base
+	if p.jitter > 0 {
+		d += time.Duration(mrand.Int63n(int64(p.jitter) + 1))
+	}
+	return d << min(p.failures, maxBulkBackoffShift)
+}
+
+// wait blocks until the next send slot. The lock is held while sleeping so
+// workers take slots one at a time.
+func (p *sendPacer) wait() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if d := time.Until(p.next); d > 0 {
+		time.Sleep(d)
+	}
+	p.next = time.Now().Add(p.delay())
+}
+
+func (p *sendPacer) record(success bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if success {
+		p.failures = 0
+	} else {
+		p.failures++
+	}
+}
+
+// startBulkWorkers lazily starts the worker pool. Every send waits on a shared pacer,
+// so the overall send rate stays limited no matter how many workers or jobs are active.
+func startBulkWorkers() {
+	bulkQueueOnce.Do(func() {
+		cfg := config.Get()
+		workers := cfg.BulkWorkers
+		base := cfg.BulkSendInterval
+		jitter := cfg.BulkSendJitter
+
+		bulkQueue = make(chan bulkTask, cfg.BulkQueueSize)
+		bulkPacer = newSendPacer(base, jitter)
+
+		for i := 0; i < workers; i++ {
+			go bulkWorker()
+		}
+		log.Printf("[bulk] Started %d workers (send delay %v + up to %v jitter)", workers, base, jitter)
+	})
+}
+
+func bulkWorker() {
+	for task := range bulkQueue {
+		bulkPacer.wait()
+		result := sendBulkItem(task.item, task.index, task.job.Total)
+		success, _ := result["success"].(bool)
+		bulkPacer.record(success)
+		task.job.complete(task.index, result)
+	}
+}
+
+func sendBulkItem(item bulkItem, index, total int) map[string]interface{} {
+	targetJID := utils.CreateTargetJID(item.Target)
+
+	if targetJID.IsEmpty() {
+		result := map[string]interface{}{
+			"original_target": item.Target,
+			"success":         false,
+			"error":           "Invalid JID format",
+		}
+		if item.IncludeMessage {
+			result["message"] = item.Message
+		}
+		log.Printf("Skipping invalid bulk target: %s", item.Target)
+		return result
+	}
+
+	targetType, displayTarget := utils.DescribeTarget(item.Target)
+
+	log.Printf("Sending bulk message %d/%d to %s: %s", index+1, total, targetType, displayTarget)
+
+	err := utils.SendMessageWithRetry(context.Background(), targetJID, item.Message, utils.SendRetries())
+
+	result := map[string]interface{}{
+		"original_target": item.Target,
+		"target":          displayTarget,
+		"target_type":     targetType,
+		"success":         err == nil,
+	}
+	if item.IncludeMessage {
+		result["message"] = item.Message
+	}
+
+	if err != nil {
+		result["error"] = err.Error()
+		log.Printf("Failed to send bulk message to %s %s: %v", targetType, displayTarget, err)
+	}
+	return result
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
+// enqueueBulkJob registers a job in the bounded job store and queues its items.
+func enqueueBulkJob(kind string, items []bulkItem) *bulkJob {
+	startBulkWorkers()
+
+	job := &bulkJob{
+		ID:        newJobID(),
+		Kind:      kind,
+		Status:    "queued",
+		CreatedAt: time.Now(),
+		Total:     len(items),
+		Results:   make([]map[string]interface{}, len(items)),
+	}
+	if job.Total == 0 {
+		job.Status = "completed"
+		job.FinishedAt = job.CreatedAt
+	}
+
+	limit := config.Get().BulkJobHistory
+
+	bulkJobsMu.Lock()
+	bulkJobs[job.ID] = job
+	bulkJobsOrder = append(bulkJobsOrder, job.ID)
+	for len(bulkJobsOrder) > limit {
+		delete(bulkJobs, bulkJobsOrder[0])
+		bulkJobsOrder = bulkJobsOrder[1:]
+	}
+	bulkJobsMu.Unlock()
+
+	go func() {
+		for i, item := range items {
+			bulkQueue <- bulkTask{job: job, index: i, item: item}
+		}
+	}()
+
+	log.Printf("[bulk] Job %s (%s) queued with %d targets", job.ID, kind, job.Total)
+	return job
+}
+
+func getBulkJob(id string) *bulkJob {
+	bulkJobsMu.Lock()
+	defer bulkJobsMu.Unlock()
+	return bulkJobs[id]
+}
+
+func (j *bulkJob) complete(index int, result map[string]interface{}) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.Results[index] = result
+	j.Completed++
+	if success, _ := result["success"].(bool); success {
+		j.Succeeded++
+	}
+
+	j.Status = "running"
+	if j.Completed >= j.Total {
+		j.Status = "completed"
+		j.FinishedAt = time.Now()
+		log.Printf("[bulk] Job %s completed: %d/%d succeeded", j.ID, j.Succeeded, j.Total)
+	}
+}
+
+func (j *bulkJob) snapshot() map[string]interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	results := make([]map[string]interface{}, 0, j.Completed)
+	for _, r := range j.Results {
+		if r != nil {
+			results = append(results, r)
+		}
+	}
+
+	snap := map[string]interface{}{
+		"job_id":     j.ID,
+		"type":       j.Kind,
+		"status":     j.Status,
+		"created_at": j.CreatedAt.Format(time.RFC3339),
+		"total":      j.Total,
+		"completed":  j.Completed,
+		"succeeded":  j.Succeeded,
+		"failed":     j.Completed - j.Succeeded,
+		"results":    results,
+	}
+	if !j.FinishedAt.IsZero() {
+		snap["finished_at"] = j.FinishedAt.Format(time.RFC3339)
+	}
+	return snap
+}
+
+func writeJobAccepted(w http.ResponseWriter, job *bulkJob, status string) {
+	writeJSON(w, http.StatusAccepted, map[string]interface{}{
+		"status":     status,
+		"job_id":     job.ID,
+		"total":      job.Total,
+		"status_url": fmt.Sprintf("/job/%s", job.ID),
+	})
+}
+
+func handleGetJob(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	job := getBulkJob(id)
+	if job == nil {
+		writeErrorWithFields(w, http.StatusNotFound, ErrCodeNotFound, "Job not found", map[string]interface{}{"job_id": id})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job.snapshot())
+}
@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"log"
+
+	"go.mau.fi/whatsmeow/types/events"
+
+	"whatsmeow-api/config"
+	"whatsmeow-api/whatsapp"
+)
+
+// reloginOnLogout reports whether a new QR login should start automatically
+// after the device is logged out (RELOGIN_ON_LOGOUT=true).
+func reloginOnLogout() bool {
+	return config.Get().ReloginOnLogout
+}
+
+// handleLoggedOut records a remote logout so /health can report it. whatsmeow
+// has already deleted the stored session by the time this event arrives.
+func handleLoggedOut(evt *events.LoggedOut) {
+	reason := "device removed"
+	if evt.OnConnect {
+		reason = evt.Reason.String()
+	}
+	log.Printf("[session] WhatsApp session was logged out (%s). The stored session has been cleared; scan a new QR code to link the device again.", reason)
+	whatsapp.MarkLoggedOut(reason)
+
+	if !reloginOnLogout() {
+		log.Printf("[session] Set RELOGIN_ON_LOGOUT=true to start a new QR login automatically")
+		return
+	}
+
+	// Event handlers run on the client's goroutine; replacing the client from
+	// here would block on its own disconnect.
+	go func() {
+		if err := whatsapp.Relogin(); err != nil {
+			log.Printf("[session] Failed to start QR login: %v", err)
+			return
+		}
+		log.Printf("[session] New QR login started; fetch the code from /qr")
+	}()
+}
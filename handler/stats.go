@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"whatsmeow-api/utils"
+)
+
+// maxTrackedCommands bounds the per-command map so arbitrary "!xyz" messages can't grow it forever
+const maxTrackedCommands = 100
+
+var (
+	statsStartedAt    = time.Now()
+	messagesProcessed atomic.Int64
+	commandCountsMu   sync.Mutex
+	commandCounts     = make(map[string]int64)
+
+	// Lifetime counters are persisted to statsFilePath so they survive restarts
+	statsFilePath string
+	statsSince    = time.Now()
+	statsDirty    atomic.Bool
+
+	lastConnectedAt atomic.Int64
+)
+
+// persistedStats is the on-disk shape of the lifetime counters
+type persistedStats struct {
+	Since             time.Time        `json:"since"`
+	MessagesProcessed int64            `json:"messages_processed"`
+	Commands          map[string]int64 `json:"commands"`
+}
+
+// InitStats loads previously saved counters from path, if any. Missing or
+// unreadable files start the counters from zero.
+func InitStats(path string) error {
+	if path == "" {
+		path = "stats.json"
+	}
+	statsFilePath = path
+
+	if dir := filepath.Dir(path); dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if len(b) == 0 {
+		return nil
+	}
+
+	var saved persistedStats
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+
+	messagesProcessed.Add(saved.MessagesProcessed)
+	commandCountsMu.Lock()
+	for k, v := range saved.Commands {
+		commandCounts[k] += v
+	}
+	if !saved.Since.IsZero() {
+		statsSince = saved.Since
+	}
+	commandCountsMu.Unlock()
+
+	log.Printf("[stats] Loaded %d processed messages and %d command counters from %s", saved.MessagesProcessed, len(saved.Commands), path)
+	return nil
+}
+
+// SaveStats writes the current counters to the stats file.
+func SaveStats() error {
+	if statsFilePath == "" {
+		return nil
+	}
+
+	commandCountsMu.Lock()
+	saved := persistedStats{
+		Since:             statsSince,
+		MessagesProcessed: messagesProcessed.Load(),
+		Commands:          make(map[string]int64, len(commandCounts)),
+	}
+	for k, v := range commandCounts {
+		saved.Commands[k] = v
+	}
+	statsDirty.Store(false)
+	commandCountsMu.Unlock()
+
+	b, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(statsFilePath, b, 0o644)
+}
+
+// StartStatsAutoSave periodically flushes changed counters to disk.
+func StartStatsAutoSave(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !statsDirty.Load() {
+				continue
+			}
+			if err := SaveStats(); err != nil {
+				log.Printf("[stats] Auto-save failed: %v", err)
+			}
+		}
+	}()
+}
+
+// resetStats zeroes all counters and persists the empty state.
+func resetStats() error {
+	commandCountsMu.Lock()
+	messagesProcessed.Store(0)
+	commandCounts = make(map[string]int64)
+	statsSince = time.Now()
+	commandCountsMu.Unlock()
+
+	return SaveStats()
+}
+
+// commandName returns the lower-cased command word of a "!cmd ..." or "/cmd ..." message
+func commandName(message string) string {
+	message = strings.TrimSpace(message)
+	if len(message) < 2 || (message[0] != '!' && message[0] != '/') {
+		return ""
+	}
+	fields := strings.Fields(message[1:])
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(fields[0])
+}
+
+func recordCommand(name string) {
+	// messagesProcessed is bumped alongside every call, so always flag a save
+	statsDirty.Store(true)
+	if name == "" {
+		return
+	}
+
+	commandCountsMu.Lock()
+	defer commandCountsMu.Unlock()
+
+	if _, ok := commandCounts[name]; !ok && len(commandCounts) >= maxTrackedCommands {
+		name = "other"
+	}
+	commandCounts[name]++
+}
+
+func getCommandCounts() map[string]int64 {
+	commandCountsMu.Lock()
+	defer commandCountsMu.Unlock()
+
+	out := make(map[string]int64, len(commandCounts))
+	for k, v := range commandCounts {
+		out[k] = v
+	}
+	return out
+}
+
+func handleStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	attempted, succeeded := utils.GetSendStats()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":             "Success",
+		"started_at":         statsStartedAt.Format(time.RFC3339),
+		"counting_since":     getStatsSince().Format(time.RFC3339),
+		"uptime_seconds":     int64(time.Since(statsStartedAt).Seconds()),
+		"messages_processed": messagesProcessed.Load(),
+		"commands":           getCommandCounts(),
+		"sends_attempted":    attempted,
+		"sends_succeeded":    succeeded,
+		"timestamp":          time.Now().Format(time.RFC3339),
+	})
+}
+
+// recordConnected remembers when the WhatsApp client last (re)connected.
+func recordConnected() {
+	lastConnectedAt.Store(time.Now().Unix())
+}
+
+func getStatsSince() time.Time {
+	commandCountsMu.Lock()
+	defer commandCountsMu.Unlock()
+	return statsSince
+}
+
+func handleResetStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := resetStats(); err != nil {
+		log.Printf("[stats] Failed to persist reset: %v", err)
+		writeError(w, http.StatusInternalServerError, ErrCodeInternal, err.Error())
+		return
+	}
+
+	log.Printf("[stats] Counters reset by %s", r.RemoteAddr)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":         "Success",
+		"counting_since": getStatsSince().Format(time.RFC3339),
+	})
+}
@@ -21,6 +21,7 @@ import (
 	"go.mau.fi/whatsmeow/types"
 	"google.golang.org/protobuf/proto"
 
+	"whatsmeow-api/config"
 	"whatsmeow-api/domain"
 	"whatsmeow-api/utils"
 	"whatsmeow-api/whatsapp"
@@ -32,15 +33,7 @@ var (
 )
 
 func getCooldownDuration() time.Duration {
-	val := os.Getenv("VISERON_COOLDOWN_SECONDS")
-	if val == "" {
-		return 60 * time.Second
-	}
-	secs, err := strconv.Atoi(val)
-	if err != nil || secs < 0 {
-		return 60 * time.Second
-	}
-	return time.Duration(secs) * time.Second
+	return config.Get().ViseronCooldown
 }
 
 func checkCooldown(camera, eventType string) bool {
@@ -62,19 +55,7 @@ func checkCooldown(camera, eventType string) bool {
 }
 
 func getViseronTarget() []string {
-	raw := os.Getenv("VISERON_TARGET")
-	if raw == "" {
-		return []string{}
-	}
-	parts := strings.Split(raw, ",")
-	result := make([]string, 0, len(parts))
-	for _, p := range parts {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			result = append(result, p)
-		}
-	}
-	return result
+	return config.Get().ViseronTargets
 }
 
 func deriveBaseURL(rawURL string) string {
@@ -237,14 +218,14 @@ func sendVideoToJID(ctx context.Context, targetJID types.JID, videoData []byte,
 	}
 
 	log.Printf("[video] uploading %d bytes to WhatsApp...", len(videoData))
-	uploaded, err := whatsapp.Client.Upload(ctx, videoData, whatsmeow.MediaVideo)
+	uploaded, err := whatsapp.GetClient().Upload(ctx, videoData, whatsmeow.MediaVideo)
 	if err != nil {
 		return fmt.Errorf("video upload failed: %v", err)
 	}
 
 	videoMsg := &waE2E.Message{
 		VideoMessage: &waE2E.VideoMessage{
-			Caption:       proto.String(caption),
+			Caption:       proto.String(utils.TruncateCaption(caption)),
 			Mimetype:      proto.String("video/mp4"),
 			URL:           &uploaded.URL,
 			DirectPath:    &uploaded.DirectPath,
@@ -255,7 +236,7 @@ func sendVideoToJID(ctx context.Context, targetJID types.JID, videoData []byte,
 		},
 	}
 
-	_, err = whatsapp.Client.SendMessage(ctx, targetJID, videoMsg)
+	_, err = utils.SendMessageWithTimeout(ctx, targetJID, videoMsg)
 	if err != nil {
 		return fmt.Errorf("send video message failed: %v", err)
 	}
@@ -269,7 +250,7 @@ func sendSnapshotToTargets(ctx context.Context, targets []string, snapshotURL, c
 		for _, target := range targets {
 			jid := utils.CreateTargetJID(target)
 			if !jid.IsEmpty() {
-				_ = utils.SendMessageWithRetry(ctx, jid, caption, 3)
+				_ = utils.SendMessageWithRetry(ctx, jid, caption, utils.DeliveryRetries())
 			}
 		}
 		return
@@ -281,7 +262,7 @@ func sendSnapshotToTargets(ctx context.Context, targets []string, snapshotURL, c
 		for _, target := range targets {
 			jid := utils.CreateTargetJID(target)
 			if !jid.IsEmpty() {
-				_ = utils.SendMessageWithRetry(ctx, jid, caption, 3)
+				_ = utils.SendMessageWithRetry(ctx, jid, caption, utils.DeliveryRetries())
 			}
 		}
 		return
@@ -294,9 +275,9 @@ func sendSnapshotToTargets(ctx context.Context, targets []string, snapshotURL, c
 			continue
 		}
 		log.Printf("[snapshot] sending to %s", target)
-		if err := utils.SendImageWithRetry(ctx, jid, imgBase64, caption, 3); err != nil {
+		if err := utils.SendImageWithRetry(ctx, jid, imgBase64, caption, utils.ImageRetries()); err != nil {
 			log.Printf("[snapshot] image to %s failed: %v -- text fallback", target, err)
-			_ = utils.SendMessageWithRetry(ctx, jid, caption, 3)
+			_ = utils.SendMessageWithRetry(ctx, jid, caption, utils.DeliveryRetries())
 		}
 		if i < len(targets)-1 {
 			time.Sleep(500 * time.Millisecond)
@@ -359,8 +340,11 @@ func handleViseronWebhook(w http.ResponseWriter, r *http.Request) {
 
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{"error": "Failed to read body"})
+		if isBodyTooLarge(err) {
+			writePayloadTooLarge(w, bodyLimitFor(r.URL.Path))
+			return
+		}
+		writeError(w, http.StatusBadRequest, ErrCodeInvalidRequest, "Failed to read body")
 		return
 	}
 	defer r.Body.Close()
@@ -376,16 +360,14 @@ func handleViseronWebhook(w http.ResponseWriter, r *http.Request) {
 	payload.ViseronBaseURL = deriveBaseURL(payload.SnapshotURL)
 	log.Printf("[viseron] baseURL=%s camera=%s eventType=%s", payload.ViseronBaseURL, payload.Camera, payload.EventType)
 
-	if !whatsapp.Client.IsConnected() {
-		w.WriteHeader(http.StatusServiceUnavailable)
-		json.NewEncoder(w).Encode(map[string]string{"error": "WhatsApp not connected"})
+	if !whatsapp.GetClient().IsConnected() {
+		writeError(w, http.StatusServiceUnavailable, ErrCodeNotConnected, "WhatsApp not connected")
 		return
 	}
 
 	targets := getViseronTarget()
 	if len(targets) == 0 {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"status": "VISERON_TARGET not configured"})
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "VISERON_TARGET not configured"})
 		return
 	}
 
@@ -394,8 +376,7 @@ func handleViseronWebhook(w http.ResponseWriter, r *http.Request) {
 	sendVideo := payload.ViseronBaseURL != "" && payload.Camera != ""
 
 	if !checkCooldown(payload.Camera, payload.EventType) {
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(map[string]string{"status": "cooldown"})
+		writeJSON(w, http.StatusOK, map[string]interface{}{"status": "cooldown"})
 		return
 	}
 
@@ -408,8 +389,7 @@ func handleViseronWebhook(w http.ResponseWriter, r *http.Request) {
 		go sendHLSClipToTargets(targets, baseURL, camera, caption, eventTime)
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"status":        "accepted",
 		"event_type":    payload.EventType,
 		"total_targets": len(targets),
@@ -424,9 +404,7 @@ func handleViseronDebug(w http.ResponseWriter, r *http.Request) {
 	baseURL := r.URL.Query().Get("base")
 	camera := r.URL.Query().Get("camera")
 	if baseURL == "" || camera == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error":   "query params 'base' and 'camera' are required",
+		writeErrorWithFields(w, http.StatusBadRequest, ErrCodeInvalidRequest, "query params 'base' and 'camera' are required", map[string]interface{}{
 			"example": "/viseron-debug?base=http://172.24.87.44:1000&camera=camera_1",
 		})
 		return
@@ -437,8 +415,7 @@ func handleViseronDebug(w http.ResponseWriter, r *http.Request) {
 
 	data, err := fetchBytes(apiURL, 15*time.Second)
 	if err != nil {
-		w.WriteHeader(http.StatusBadGateway)
-		json.NewEncoder(w).Encode(map[string]string{"error": err.Error(), "url": apiURL})
+		writeErrorWithFields(w, http.StatusBadGateway, ErrCodeUpstreamFailed, err.Error(), map[string]interface{}{"url": apiURL})
 		return
 	}
 
@@ -449,8 +426,7 @@ func handleViseronDebug(w http.ResponseWriter, r *http.Request) {
 		hlsStatus = hlsErr.Error()
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	writeJSON(w, http.StatusOK, map[string]interface{}{
 		"recordings_api_url":  apiURL,
 		"recordings_response": json.RawMessage(data),
 		"hls_url":             hlsURL,
@@ -3,86 +3,53 @@ package handler
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 
+	"go.mau.fi/whatsmeow/appstate"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 
+	"whatsmeow-api/config"
+	"whatsmeow-api/services/feedback"
+	"whatsmeow-api/services/fx"
 	"whatsmeow-api/services/gemini"
 	"whatsmeow-api/services/idx"
+	"whatsmeow-api/services/media"
+	"whatsmeow-api/services/prayer"
+	"whatsmeow-api/services/shortener"
+	"whatsmeow-api/services/tts"
+	"whatsmeow-api/services/wiki"
 	"whatsmeow-api/utils"
 	"whatsmeow-api/whatsapp"
 )
 
-func handleHelpCommand(v *events.Message) {
-	if !whatsapp.Client.IsConnected() {
+func handleHelpCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
-	helpMessage := `[WhatsApp Bot] Bantuan Penggunaan
-
-[Daftar Perintah]
-
-*!help* atau */help*
-Menampilkan bantuan dan cara penggunaan bot
-
-*!hallo* atau */hallo*
-Menyapa bot dengan ramah
-
-*!fiq [pertanyaan]* atau */fiq [pertanyaan]*
-Tanya apa saja ke asisten AI pribadi Fiq
-
-*!groups* atau */groups*
-Menampilkan daftar grup yang diikuti bot
-
-*!groups [nama grup]* atau */groups [nama grup]*
-Mencari grup berdasarkan nama dan menampilkan ID-nya
-Contoh: *!groups Braincore Community*
-
-*!ping* atau */ping*
-Cek apakah bot sedang aktif
-
-*!status* atau */status*
-Menampilkan status koneksi bot
-
-*!info* atau */info*
-Menampilkan informasi tentang bot
-
-*!test* atau */test*
-Test apakah bot berfungsi dengan baik
-
-*!echo [teks]* atau */echo [teks]*
-Mengulang pesan yang dikirim
-
-*!idx* atau */idx*
-Menampilkan data pasar saham IDX hari ini
-
-*!img [deskripsi]* atau */img [deskripsi]*
-Membuat gambar AI berdasarkan deskripsi yang diberikan
-
-[Tips]
-- Semua perintah bisa menggunakan ! atau /
-- Bot akan merespons secara otomatis
-- Gunakan perintah di chat pribadi atau grup
-
-[Fiq - Asisten AI]
-Fiq adalah asisten pribadi berbasis Google Gemini yang siap membantu Anda dengan berbagai pertanyaan dan tugas sehari-hari.
-
-[Dukungan]
-Jika ada pertanyaan, silakan hubungi administrator bot.`
+	message := helpMessage
+	if parts := strings.Fields(originalMessage); len(parts) > 1 {
+		message = commandHelpText(parts[1])
+	}
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, helpMessage, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send help message: %v", err)
 	}
 }
 
 func handleHalloCommand(v *events.Message) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -93,28 +60,28 @@ func handleHalloCommand(v *events.Message) {
 
 	halloMessage := fmt.Sprintf("[%s] Hallo %s!\n\nSenang bertemu denganmu! Ada yang bisa saya bantu hari ini?\n\nKetik *!help* untuk melihat semua perintah yang tersedia.", "Bot", senderName)
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, halloMessage, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, halloMessage, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send hallo message: %v", err)
 	}
 }
 
 func handlePingCommand(v *events.Message) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
 	pingMessage := "[Ping] Pong! Bot sedang aktif dan siap melayani."
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, pingMessage, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, pingMessage, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send ping message: %v", err)
 	}
 }
 
 func handleStatusCommand(v *events.Message) {
-	if !whatsapp.Client.IsConnected() {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Bot sedang tidak terhubung ke WhatsApp", 2)
+	if !whatsapp.GetClient().IsConnected() {
+		replyError(v, "[Error] Bot sedang tidak terhubung ke WhatsApp")
 		return
 	}
 
@@ -132,14 +99,14 @@ Uptime: Bot sedang berjalan
 
 Semua sistem berfungsi dengan baik!`, time.Now().In(loc).Format("02 Jan 2006, 15:04:05 WIB"))
 
-	err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, statusMessage, 2)
+	err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, statusMessage, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send status message: %v", err)
 	}
 }
 
 func handleInfoCommand(v *events.Message) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -154,14 +121,14 @@ Fitur: Auto-reply, Group Management, Message API
 
 Bot ini dibuat untuk memudahkan komunikasi dan otomasi pesan WhatsApp melalui API.`
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, infoMessage, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, infoMessage, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send info message: %v", err)
 	}
 }
 
 func handleTestCommand(v *events.Message) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -179,14 +146,14 @@ Test berhasil! Bot siap menerima perintah dalam berbagai format:
 
 Semua format akan dikenali dengan benar!`
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, testMessage, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, testMessage, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send test message: %v", err)
 	}
 }
 
 func handleEchoCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -195,8 +162,10 @@ func handleEchoCommand(v *events.Message, originalMessage string) {
 		echoText = strings.TrimSpace(originalMessage[6:])
 	} else if strings.HasPrefix(strings.ToLower(originalMessage), "/echo ") {
 		echoText = strings.TrimSpace(originalMessage[6:])
-	} else {
-		echoText = "Silakan berikan teks setelah perintah echo. Contoh: !echo Halo Dunia"
+	}
+
+	if echoQuotedMedia(v, echoText) {
+		return
 	}
 
 	if echoText == "" {
@@ -205,14 +174,53 @@ func handleEchoCommand(v *events.Message, originalMessage string) {
 
 	echoResponse := fmt.Sprintf("[Echo Response]\n\n%s", echoText)
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, echoResponse, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, echoResponse, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send echo message: %v", err)
 	}
 }
 
+// echoQuotedMedia resends the image or sticker that v replies to, using text as
+// the image caption. It returns false when there is no such media, so the caller
+// falls back to a text echo.
+func echoQuotedMedia(v *events.Message, text string) bool {
+	quoted := utils.GetQuotedMessage(v.Message)
+	media, kind, _ := utils.GetMediaMessage(quoted)
+	if media == nil || (kind != utils.MediaKindImage && kind != utils.MediaKindSticker) {
+		return false
+	}
+
+	ctx := context.Background()
+	data, _, _, err := utils.DownloadMedia(ctx, quoted)
+	if err != nil {
+		log.Printf("Failed to download quoted %s for echo: %v", kind, err)
+		replyError(v, "[Error] Gagal mengunduh media yang dibalas. Silakan coba lagi.")
+		return true
+	}
+
+	if kind == utils.MediaKindSticker {
+		err = utils.SendSticker(ctx, v.Info.Chat, data)
+	} else {
+		err = utils.SendImageWithRetry(ctx, v.Info.Chat, base64.StdEncoding.EncodeToString(data), text, utils.ImageRetries())
+	}
+	if err != nil {
+		log.Printf("Failed to echo %s: %v", kind, err)
+		replyError(v, "[Error] Gagal mengirim ulang media.")
+	}
+	return true
+}
+
+const (
+	groupsPageSize         = 20
+	groupsMaxSearchResults = 10
+	// groupsMinMatchScore is the lowest fuzzy score still shown as a search result.
+	groupsMinMatchScore = 0.6
+)
+
+var groupsPageRe = regexp.MustCompile(`(?i)^page\s+(\d+)$`)
+
 func handleGroupsCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -224,22 +232,33 @@ func handleGroupsCommand(v *events.Message, originalMessage string) {
 		searchName = strings.TrimSpace(originalMessage[8:])
 	}
 
-	groups, err := whatsapp.Client.GetJoinedGroups(context.Background())
+	page := 1
+	if m := groupsPageRe.FindStringSubmatch(searchName); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n > 0 {
+			page = n
+		}
+		searchName = ""
+	}
+
+	groups, err := whatsapp.GetClient().GetJoinedGroups(context.Background())
 	if err != nil {
 		log.Printf("Failed to get joined groups: %v", err)
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Gagal mengambil daftar grup: "+err.Error(), 2)
+		replyError(v, "[Error] Gagal mengambil daftar grup: "+err.Error())
 		return
 	}
 
 	if len(groups) == 0 {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Info] Tidak ada grup yang diikuti.", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Info] Tidak ada grup yang diikuti.", utils.SendRetries())
 		return
 	}
 
 	if searchName != "" {
 
-		var matchedGroups []*types.GroupInfo
-		searchLower := strings.ToLower(searchName)
+		type groupMatch struct {
+			group *types.GroupInfo
+			score float64
+		}
+		var matchedGroups []groupMatch
 
 		for _, group := range groups {
 			groupName := group.Name
@@ -247,46 +266,64 @@ func handleGroupsCommand(v *events.Message, originalMessage string) {
 				groupName = "Tanpa Nama"
 			}
 
-			if strings.Contains(strings.ToLower(groupName), searchLower) {
-				matchedGroups = append(matchedGroups, group)
+			if score := utils.FuzzyScore(searchName, groupName); score >= groupsMinMatchScore {
+				matchedGroups = append(matchedGroups, groupMatch{group: group, score: score})
 			}
 		}
 
 		if len(matchedGroups) == 0 {
 			message := fmt.Sprintf("[Pencarian Grup]\n\nTidak ditemukan grup dengan nama \"%s\"\n\nCoba gunakan kata kunci yang lebih umum atau gunakan !groups untuk melihat semua grup", searchName)
-			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, 2)
+			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, utils.SendRetries())
 			return
 		}
 
+		sort.SliceStable(matchedGroups, func(i, j int) bool {
+			if matchedGroups[i].score != matchedGroups[j].score {
+				return matchedGroups[i].score > matchedGroups[j].score
+			}
+			return strings.ToLower(matchedGroups[i].group.Name) < strings.ToLower(matchedGroups[j].group.Name)
+		})
+
 		message := fmt.Sprintf("[Hasil Pencarian Grup: \"%s\"]\n\nDitemukan %d grup:\n\n", searchName, len(matchedGroups))
+		if len(matchedGroups) > groupsMaxSearchResults {
+			message = fmt.Sprintf("[Hasil Pencarian Grup: \"%s\"]\n\nDitemukan %d grup, menampilkan %d paling relevan:\n\n", searchName, len(matchedGroups), groupsMaxSearchResults)
+			matchedGroups = matchedGroups[:groupsMaxSearchResults]
+		}
 
-		for _, group := range matchedGroups {
-			groupName := group.Name
+		for _, match := range matchedGroups {
+			groupName := match.group.Name
 			if groupName == "" {
 				groupName = "Tanpa Nama"
 			}
 
 			message += fmt.Sprintf("Name: %s\n", groupName)
-			message += fmt.Sprintf("JID: %s\n\n", group.JID.String())
+			message += fmt.Sprintf("JID: %s\n", match.group.JID.String())
+			message += fmt.Sprintf("Kecocokan: %d%%\n\n", int(match.score*100))
 		}
 
 		message += "[Tips: Gunakan !groups [nama grup] untuk mencari grup lain]"
 
-		err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, 2)
+		err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, utils.SendRetries())
 		if err != nil {
 			log.Printf("Failed to send groups search result: %v", err)
 		}
 		return
 	}
 
-	message := fmt.Sprintf("[Daftar Grup yang Diikuti] (%d grup)\n\n", len(groups))
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
 
-	for i, group := range groups {
-		if i >= 20 {
-			message += fmt.Sprintf("_... dan %d grup lainnya_\n", len(groups)-20)
-			break
-		}
+	totalPages := (len(groups) + groupsPageSize - 1) / groupsPageSize
+	if page > totalPages {
+		page = totalPages
+	}
+	start := (page - 1) * groupsPageSize
+	end := utils.Min(start+groupsPageSize, len(groups))
 
+	message := fmt.Sprintf("[Daftar Grup yang Diikuti] (%d grup)\n\n", len(groups))
+
+	for _, group := range groups[start:end] {
 		groupName := group.Name
 		if groupName == "" {
 			groupName = "Tanpa Nama"
@@ -296,17 +333,22 @@ func handleGroupsCommand(v *events.Message, originalMessage string) {
 		message += fmt.Sprintf("JID: %s\n", group.JID.String())
 	}
 
+	message += fmt.Sprintf("\n[Page %d of %d]\n", page, totalPages)
+	if page < totalPages {
+		message += fmt.Sprintf("Ketik !groups page %d untuk halaman berikutnya\n", page+1)
+	}
+
 	message += "\n[Tips] Gunakan !groups [nama grup] untuk mencari grup tertentu\n"
 	message += "Contoh: !groups Braincore Community"
 
-	err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, 2)
+	err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send groups list: %v", err)
 	}
 }
 
 func handleFiqCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -317,40 +359,96 @@ func handleFiqCommand(v *events.Message, originalMessage string) {
 		userMessage = strings.TrimSpace(originalMessage[5:])
 	} else {
 
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Fiq - Asisten Pribadi]\n\nHalo! Saya adalah Fiq, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !fiq [pertanyaan Anda]\n- !fiq apa kabar?\n- !fiq bantu saya dengan...\n\nContoh: !fiq jelaskan tentang Go programming", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Fiq - Asisten Pribadi]\n\nHalo! Saya adalah Fiq, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !fiq [pertanyaan Anda]\n- !fiq apa kabar?\n- !fiq bantu saya dengan...\n\nContoh: !fiq jelaskan tentang Go programming", utils.SendRetries())
 		return
 	}
 
 	if userMessage == "" {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Fiq - Asisten Pribadi]\n\nHalo! Saya adalah Fiq, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !fiq [pertanyaan Anda]\n- !fiq apa kabar?\n- !fiq bantu saya dengan...\n\nContoh: !fiq jelaskan tentang Go programming", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Fiq - Asisten Pribadi]\n\nHalo! Saya adalah Fiq, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !fiq [pertanyaan Anda]\n- !fiq apa kabar?\n- !fiq bantu saya dengan...\n\nContoh: !fiq jelaskan tentang Go programming", utils.SendRetries())
 		return
 	}
 
-	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Fiq] Sedang berpikir...\n\nMohon tunggu sebentar ya, saya sedang memproses permintaan Anda.", 2)
+	if matchesAIDenylist(userMessage) {
+		log.Printf("[ai-filter] Declined Fiq prompt from %s in %s", v.Info.Sender.String(), v.Info.Chat.String())
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, aiBlockedReply, utils.SendRetries())
+		return
+	}
 
-	response, err := gemini.GetGeminiResponseWithMemory(context.Background(), v.Info.Chat.String(), "Fiq", userMessage)
+	stopTyping := utils.StartTyping(v.Info.Chat)
+	persona := gemini.MemStore.GetPersona(v.Info.Chat.String())
+	response, err := gemini.GetGeminiResponseWithMemory(context.Background(), v.Info.Chat.String(), persona, userMessage)
+	stopTyping()
+	if err == nil && matchesAIDenylist(response) {
+		log.Printf("[ai-filter] Blocked %s response in %s", persona, v.Info.Chat.String())
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, aiBlockedReply, utils.SendRetries())
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to get Gemini response: %v", err)
 
 		if strings.Contains(err.Error(), "API key not configured") {
-			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.\n\nSilakan set environment variable API_KEY_GEMINI dengan Google Gemini API key Anda.", 2)
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.\n\nSilakan set environment variable API_KEY_GEMINI dengan Google Gemini API key Anda.")
 			return
 		}
 
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Maaf, terjadi kesalahan saat memproses permintaan Anda. Silakan coba lagi nanti.", 2)
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat memproses permintaan Anda. Silakan coba lagi nanti.")
 		return
 	}
 
-	formattedResponse := fmt.Sprintf("[Fiq]\n\n%s\n\n---\n[Ketik !fiq [pertanyaan] untuk bertanya lagi]", response)
+	formattedResponse := fmt.Sprintf("[%s]\n\n%s\n\n---\n[Ketik !fiq [pertanyaan] untuk bertanya lagi]", persona, utils.FormatForWhatsApp(response))
 
-	err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, formattedResponse, 2)
+	err = utils.SendMessageWithRetry(context.Background(), v.Info.Chat, formattedResponse, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send Fiq response: %v", err)
 	}
 }
 
+var personaNameRe = regexp.MustCompile(`^[\pL\pN_-]{1,30}$`)
+
+func handlePersonaCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var name string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!persona ") || strings.HasPrefix(lower, "/persona ") {
+		name = strings.TrimSpace(originalMessage[9:])
+	}
+	chat := v.Info.Chat.String()
+
+	if name == "" {
+		available := strings.Join(gemini.ListPersonas(), ", ")
+		if available == "" {
+			available = "-"
+		}
+		message := fmt.Sprintf("[Persona]\n\nPersona aktif di chat ini: *%s*\nPersona tersedia: %s\n\nGunakan:\n- !persona [nama] untuk mengganti persona\n- !persona reset untuk kembali ke %s", gemini.MemStore.GetPersona(chat), available, gemini.DefaultPersona)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, message, utils.SendRetries())
+		return
+	}
+
+	if strings.EqualFold(name, "reset") {
+		gemini.MemStore.SetPersona(chat, "")
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Persona] Persona chat ini dikembalikan ke *%s*.", gemini.DefaultPersona), utils.SendRetries())
+		return
+	}
+
+	if !personaNameRe.MatchString(name) {
+		replyError(v, "[Error] Nama persona hanya boleh berisi huruf, angka, - atau _ (maksimal 30 karakter).")
+		return
+	}
+
+	if strings.EqualFold(name, gemini.DefaultPersona) {
+		name = gemini.DefaultPersona
+	}
+
+	gemini.MemStore.SetPersona(chat, name)
+	log.Printf("[persona] %s set persona %s in %s", v.Info.Sender.String(), name, chat)
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Persona] Persona chat ini sekarang *%s*. Gunakan !fiq untuk bertanya.", name), utils.SendRetries())
+}
+
 func handleApikCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -361,36 +459,47 @@ func handleApikCommand(v *events.Message, originalMessage string) {
 	} else if strings.HasPrefix(lower, "/apik ") {
 		userMessage = strings.TrimSpace(originalMessage[6:])
 	} else {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[!apik - Asisten Pribadi]\n\nHalo! Saya adalah !apik, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !apik [pertanyaan Anda]\n- !apik apa kabar?\n- !apik bantu saya dengan...\n\nContoh: !apik jelaskan tentang Go programming", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[!apik - Asisten Pribadi]\n\nHalo! Saya adalah !apik, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !apik [pertanyaan Anda]\n- !apik apa kabar?\n- !apik bantu saya dengan...\n\nContoh: !apik jelaskan tentang Go programming", utils.SendRetries())
 		return
 	}
 
 	if userMessage == "" {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[!apik - Asisten Pribadi]\n\nHalo! Saya adalah !apik, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !apik [pertanyaan Anda]\n- !apik apa kabar?\n- !apik bantu saya dengan...\n\nContoh: !apik jelaskan tentang Go programming", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[!apik - Asisten Pribadi]\n\nHalo! Saya adalah !apik, asisten pribadi Anda yang siap membantu.\n\nCara menggunakan:\n- !apik [pertanyaan Anda]\n- !apik apa kabar?\n- !apik bantu saya dengan...\n\nContoh: !apik jelaskan tentang Go programming", utils.SendRetries())
 		return
 	}
 
-	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[!apik] Sedang berpikir...\n\nMohon tunggu sebentar ya, saya sedang memproses permintaan Anda.", 2)
+	if matchesAIDenylist(userMessage) {
+		log.Printf("[ai-filter] Declined !apik prompt from %s in %s", v.Info.Sender.String(), v.Info.Chat.String())
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, aiBlockedReply, utils.SendRetries())
+		return
+	}
 
+	stopTyping := utils.StartTyping(v.Info.Chat)
 	response, err := gemini.GetGeminiResponseWithMemory(context.Background(), v.Info.Chat.String(), "!apik", userMessage)
+	stopTyping()
+	if err == nil && matchesAIDenylist(response) {
+		log.Printf("[ai-filter] Blocked !apik response in %s", v.Info.Chat.String())
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, aiBlockedReply, utils.SendRetries())
+		return
+	}
 	if err != nil {
 		log.Printf("Failed to get Gemini response (!apik): %v", err)
 		if strings.Contains(err.Error(), "API key not configured") {
-			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.", 2)
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.")
 			return
 		}
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Maaf, terjadi kesalahan saat memproses permintaan Anda. Silakan coba lagi nanti.", 2)
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat memproses permintaan Anda. Silakan coba lagi nanti.")
 		return
 	}
 
-	formattedResponse := fmt.Sprintf("[!apik]\n\n%s\n\n---\n[Ketik !apik [pertanyaan] untuk bertanya lagi]", response)
-	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, formattedResponse, 2); err != nil {
+	formattedResponse := fmt.Sprintf("[!apik]\n\n%s\n\n---\n[Ketik !apik [pertanyaan] untuk bertanya lagi]", utils.FormatForWhatsApp(response))
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, formattedResponse, utils.SendRetries()); err != nil {
 		log.Printf("Failed to send !apik response: %v", err)
 	}
 }
 
 func handleIDXCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -450,7 +559,7 @@ func handleIDXCommand(v *events.Message, originalMessage string) {
 		}
 
 		if !parsed {
-			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Format tanggal tidak dikenali. Contoh: !idx 27 februari 2026", 2)
+			replyError(v, "[Error] Format tanggal tidak dikenali. Contoh: !idx 27 februari 2026")
 			return
 		}
 	} else {
@@ -459,25 +568,80 @@ func handleIDXCommand(v *events.Message, originalMessage string) {
 
 	dateFmt := targetDate.Format("02 Jan 2006")
 	loadingMessage := fmt.Sprintf("[IDX] Mengambil data pasar IDX untuk tanggal %s...\n\nSilakan tunggu sebentar...", dateFmt)
-	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, loadingMessage, 2); err != nil {
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, loadingMessage, utils.SendRetries()); err != nil {
 		log.Printf("Failed to send loading message: %v", err)
 	}
 
 	data, err := idx.GetIDXMarketData(targetDate)
 	if err != nil {
-		errorMessage := "[Error] Gagal mengambil data pasar IDX. Silakan coba lagi nanti."
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, errorMessage, 2)
+		replyError(v, "[Error] Gagal mengambil data pasar IDX. Silakan coba lagi nanti.")
 		return
 	}
 
 	response := idx.FormatIDXResponse(data)
-	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, 2); err != nil {
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
 		log.Printf("Failed to send IDX response: %v", err)
 	}
 }
 
+const topMoversLimit = 10
+
+// handleMoversCommand replies with the day's biggest IDX gainers and/or losers.
+func handleMoversCommand(v *events.Message, showGainers, showLosers bool) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	movers, err := idx.GetTopMovers(ctx, topMoversLimit)
+	if err != nil {
+		log.Printf("Failed to fetch IDX movers: %v", err)
+		replyError(v, "[Error] Gagal mengambil data saham teratas IDX. Silakan coba lagi nanti.")
+		return
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, idx.FormatMovers(movers, showGainers, showLosers), utils.SendRetries()); err != nil {
+		log.Printf("Failed to send IDX movers: %v", err)
+	}
+}
+
+// showThinking tells the chat a slow command (AI, downloads) is working. By default it sends
+// message as an interim chat message; with SHOW_THINKING=false it shows the typing
+// indicator instead. Call the returned function when the command finishes.
+func showThinking(chat types.JID, message string) func() {
+	if config.Get().ShowThinking {
+		utils.SendMessageWithRetry(context.Background(), chat, message, utils.SendRetries())
+		return func() {}
+	}
+	return utils.StartTyping(chat)
+}
+
+const newsHeadlineLimit = 5
+
+func handleNewsCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	headlines, err := idx.GetMarketNews(ctx, newsHeadlineLimit)
+	if err != nil {
+		log.Printf("Failed to fetch market news: %v", err)
+		replyError(v, "[Error] Gagal mengambil berita pasar. Silakan coba lagi nanti.")
+		return
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, idx.FormatNews(headlines), utils.SendRetries()); err != nil {
+		log.Printf("Failed to send market news: %v", err)
+	}
+}
+
 func handleImgCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -488,71 +652,139 @@ func handleImgCommand(v *events.Message, originalMessage string) {
 		prompt = strings.TrimSpace(originalMessage[5:])
 	} else {
 
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Generator Gambar AI]\n\nHalo! Saya dapat membuat gambar berdasarkan deskripsi Anda.\n\nCara menggunakan:\n- !img [deskripsi gambar]\n- !img pemandangan gunung dengan matahari terbenam\n- !img kucing lucu bermain di taman\n\nContoh: !img robot futuristik di kota masa depan", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Generator Gambar AI]\n\nHalo! Saya dapat membuat gambar berdasarkan deskripsi Anda.\n\nCara menggunakan:\n- !img [deskripsi gambar]\n- !img [jumlah 1-4] [deskripsi gambar]\n- !img pemandangan gunung dengan matahari terbenam\n- !img kucing lucu bermain di taman\n\nContoh: !img robot futuristik di kota masa depan", utils.SendRetries())
 		return
 	}
 
 	if prompt == "" {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Generator Gambar AI]\n\nHalo! Saya dapat membuat gambar berdasarkan deskripsi Anda.\n\nCara menggunakan:\n- !img [deskripsi gambar]\n- !img pemandangan gunung dengan matahari terbenam\n- !img kucing lucu bermain di taman\n\nContoh: !img robot futuristik di kota masa depan", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Generator Gambar AI]\n\nHalo! Saya dapat membuat gambar berdasarkan deskripsi Anda.\n\nCara menggunakan:\n- !img [deskripsi gambar]\n- !img [jumlah 1-4] [deskripsi gambar]\n- !img pemandangan gunung dengan matahari terbenam\n- !img kucing lucu bermain di taman\n\nContoh: !img robot futuristik di kota masa depan", utils.SendRetries())
 		return
 	}
 
-	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[AI] Sedang membuat gambar...\n\nMohon tunggu sebentar ya, saya sedang membuat gambar berdasarkan deskripsi Anda. Proses ini mungkin membutuhkan waktu 30-60 detik.", 2)
+	count, prompt := splitImageCount(prompt)
 
-	imageBase64, err := gemini.GetGeminiImage(context.Background(), prompt)
-	if err != nil {
-		log.Printf("Failed to generate image: %v", err)
-		if strings.Contains(err.Error(), "API key not configured") {
-			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.\n\nSilakan set environment variable API_KEY_GEMINI dengan Google Gemini API key Anda.", 2)
-			return
+	waitMessage := "[AI] Sedang membuat gambar...\n\nMohon tunggu sebentar ya, saya sedang membuat gambar berdasarkan deskripsi Anda. Proses ini mungkin membutuhkan waktu 30-60 detik."
+	if count > 1 {
+		waitMessage = fmt.Sprintf("[AI] Sedang membuat %d gambar...\n\nMohon tunggu sebentar ya, setiap gambar membutuhkan waktu 30-60 detik.", count)
+	}
+	defer showThinking(v.Info.Chat, waitMessage)()
+
+	sent := 0
+	var lastErr error
+	for i := 1; i <= count; i++ {
+		if i > 1 {
+			time.Sleep(imageSendDelay)
 		}
-		if strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "rate limit") {
-			utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Quota Gemini Habis\n\nMaaf, quota API Gemini untuk hari ini sudah habis atau rate limit tercapai. Silakan coba lagi nanti (biasanya reset setiap 24 jam) atau upgrade ke paid plan untuk quota lebih besar.", 2)
-			return
+		label := ""
+		if count > 1 {
+			label = fmt.Sprintf(" (%d/%d)", i, count)
 		}
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Maaf, terjadi kesalahan saat membuat gambar. Silakan coba lagi nanti atau gunakan deskripsi yang lebih sederhana.", 2)
-		return
+		if err := generateAndSendImage(v.Info.Chat, prompt, label); err != nil {
+			log.Printf("Image%s failed for prompt %q: %v", label, prompt, err)
+			lastErr = err
+			// Quota and missing key errors will fail every remaining attempt too
+			if isFatalImageError(err) {
+				break
+			}
+			continue
+		}
+		sent++
+	}
+
+	switch {
+	case sent == 0 && lastErr != nil:
+		replyError(v, imageErrorMessage(lastErr, prompt))
+	case sent < count:
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Info] %d dari %d gambar berhasil dibuat. Sisanya gagal, silakan coba lagi nanti.", sent, count), utils.SendRetries())
+	default:
+		log.Printf("Successfully generated and sent %d image(s) for prompt: %s", sent, prompt)
+	}
+}
+
+const (
+	maxImagesPerRequest = 4
+	imageSendDelay      = 2 * time.Second
+)
+
+// splitImageCount takes a leading image count off an !img prompt. The first
+// word only counts when it is between 1 and maxImagesPerRequest, so prompts
+// such as "1984 poster" are left alone.
+func splitImageCount(prompt string) (int, string) {
+	fields := strings.Fields(prompt)
+	if len(fields) < 2 {
+		return 1, prompt
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n < 1 || n > maxImagesPerRequest {
+		return 1, prompt
 	}
+	return n, strings.TrimSpace(prompt[len(fields[0]):])
+}
 
-	caption := fmt.Sprintf("[Gambar AI Generated]\n\nPrompt: %s\n\nDibuat menggunakan Gemini 2.0 Flash Preview Image Generation", prompt)
+// errImageNotDelivered marks an image that was generated but could not be sent.
+var errImageNotDelivered = errors.New("image generated but not delivered")
 
-	err = utils.SendImageWithRetry(context.Background(), v.Info.Chat, imageBase64, caption, 3)
+// generateAndSendImage creates one image for prompt and sends it to chat. label
+// is appended to the caption header, e.g. " (2/3)".
+func generateAndSendImage(chat types.JID, prompt, label string) error {
+	imageBase64, err := gemini.GetGeminiImage(context.Background(), prompt)
 	if err != nil {
-		log.Printf("Failed to send generated image: %v", err)
+		return err
+	}
+
+	if scaled, scaleErr := utils.DownscaleImageBase64(imageBase64); scaleErr != nil {
+		log.Printf("Failed to downscale generated image, sending original: %v", scaleErr)
+	} else {
+		imageBase64 = scaled
+	}
 
+	caption := utils.TruncateCaption(fmt.Sprintf("[Gambar AI Generated%s]\n\nPrompt: %s\n\nDibuat menggunakan Gemini 2.0 Flash Preview Image Generation", label, prompt))
+
+	err = utils.SendImageWithRetry(context.Background(), chat, imageBase64, caption, utils.ImageRetries())
+	if err != nil {
 		if strings.Contains(err.Error(), "data URL") || strings.Contains(err.Error(), "fallback message") || strings.Contains(err.Error(), "thumbnail") {
 			log.Printf("Image sent successfully (as data URL, thumbnail, or fallback)")
-			return
+			return nil
 		}
-
-		fallbackMessage := fmt.Sprintf("[Gambar Berhasil Dibuat]\n\nPrompt: %s\n\n[Error]\n\nGambar berhasil dibuat oleh AI tetapi gagal dikirim ke WhatsApp. Kemungkinan penyebab:\n- Ukuran file terlalu besar\n- Masalah koneksi\n- Format tidak didukung\n\nSilakan coba lagi dengan deskripsi yang lebih sederhana atau tunggu beberapa saat.", prompt)
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fallbackMessage, 2)
-		return
+		return fmt.Errorf("%w: %v", errImageNotDelivered, err)
 	}
+	return nil
+}
 
-	log.Printf("Successfully generated and sent image for prompt: %s", prompt)
+func isFatalImageError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "API key not configured") || strings.Contains(msg, "quota") || strings.Contains(msg, "rate limit")
+}
+
+// imageErrorMessage turns a failed generation into the reply shown to the user.
+func imageErrorMessage(err error, prompt string) string {
+	switch {
+	case strings.Contains(err.Error(), "API key not configured"):
+		return "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.\n\nSilakan set environment variable API_KEY_GEMINI dengan Google Gemini API key Anda."
+	case strings.Contains(err.Error(), "quota") || strings.Contains(err.Error(), "rate limit"):
+		return "[Error] Quota Gemini Habis\n\nMaaf, quota API Gemini untuk hari ini sudah habis atau rate limit tercapai. Silakan coba lagi nanti (biasanya reset setiap 24 jam) atau upgrade ke paid plan untuk quota lebih besar."
+	case errors.Is(err, errImageNotDelivered):
+		return fmt.Sprintf("[Gambar Berhasil Dibuat]\n\nPrompt: %s\n\n[Error]\n\nGambar berhasil dibuat oleh AI tetapi gagal dikirim ke WhatsApp. Kemungkinan penyebab:\n- Ukuran file terlalu besar\n- Masalah koneksi\n- Format tidak didukung\n\nSilakan coba lagi dengan deskripsi yang lebih sederhana atau tunggu beberapa saat.", prompt)
+	default:
+		return "[Error] Maaf, terjadi kesalahan saat membuat gambar. Silakan coba lagi nanti atau gunakan deskripsi yang lebih sederhana."
+	}
 }
 
 func handleCCTVCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
-	ownerJidStr := os.Getenv("OWNER_JID")
-	if ownerJidStr == "" {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] OWNER_JID belum dikonfigurasi pada server.", 2)
+	owners := config.Get().OwnerJIDs
+	if len(owners) == 0 {
+		replyError(v, "[Error] OWNER_JID belum dikonfigurasi pada server.")
 		return
 	}
 
 	senderJID := v.Info.Sender.ToNonAD()
 	isOwner := false
 
-	owners := strings.Split(ownerJidStr, ",")
 	for _, ownerCandidate := range owners {
-		ownerCandidate = strings.TrimSpace(ownerCandidate)
-		if ownerCandidate == "" {
-			continue
-		}
 
 		candidateJid := utils.CreateTargetJID(ownerCandidate)
 
@@ -574,19 +806,19 @@ func handleCCTVCommand(v *events.Message, originalMessage string) {
 	// Check if sender is the owner
 	if !isOwner {
 		log.Printf("[CCTV] Unauthorized access attempt by: %s (Base: %s, User: %s)", v.Info.Sender.String(), senderJID.String(), senderJID.User)
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Anda tidak memiliki izin untuk menggunakan perintah ini.", 2)
+		replyError(v, "[Error] Anda tidak memiliki izin untuk menggunakan perintah ini.")
 		return
 	}
 
-	baseURL := os.Getenv("VISERON_BASE_URL")
-	camera := os.Getenv("VISERON_DEFAULT_CAMERA")
+	baseURL := config.Get().ViseronBaseURL
+	camera := config.Get().ViseronDefaultCamera
 
 	if baseURL == "" || camera == "" {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Konfigurasi Viseron (VISERON_BASE_URL, VISERON_DEFAULT_CAMERA) belum lengkap.", 2)
+		replyError(v, "[Error] Konfigurasi Viseron (VISERON_BASE_URL, VISERON_DEFAULT_CAMERA) belum lengkap.")
 		return
 	}
 
-	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[CCTV] Sedang mengambil gambar dari kamera...", 2)
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[CCTV] Sedang mengambil gambar dari kamera...", utils.SendRetries())
 
 	// Build the API endpoint to get the latest snapshot
 	// Viseron typically provides a latest snapshot endpoint such as /api/v1/camera/camera_1/snapshot
@@ -595,7 +827,7 @@ func handleCCTVCommand(v *events.Message, originalMessage string) {
 	imgData, err := fetchBytes(snapshotURL, 15*time.Second)
 	if err != nil {
 		log.Printf("[CCTV] Failed to fetch manual snapshot: %v", err)
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Error] Gagal mengambil gambar dari CCTV: %v", err), 2)
+		replyError(v, fmt.Sprintf("[Error] Gagal mengambil gambar dari CCTV: %v", err))
 		return
 	}
 
@@ -607,22 +839,22 @@ func handleCCTVCommand(v *events.Message, originalMessage string) {
 	imgBase64 := base64.StdEncoding.EncodeToString(imgData)
 	caption := fmt.Sprintf("[CCTV Manual Snapshot]\n\nKamera: %s\nWaktu: %s", camera, time.Now().In(loc).Format("02 Jan 2006, 15:04:05 WIB"))
 
-	err = utils.SendImageWithRetry(context.Background(), v.Info.Chat, imgBase64, caption, 3)
+	err = utils.SendImageWithRetry(context.Background(), v.Info.Chat, imgBase64, caption, utils.ImageRetries())
 	if err != nil {
 		log.Printf("Failed to send manual CCTV snapshot: %v", err)
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Error] Gagal mengirim gambar CCTV ke WhatsApp.", 2)
+		replyError(v, "[Error] Gagal mengirim gambar CCTV ke WhatsApp.")
 	}
 
 	// We can optionally trigger a video clip capture
 	// We run it as a goroutine because it takes 30s to record
 	go func() {
-		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[CCTV] Sedang merekam video klip (30 detik)...", 2)
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[CCTV] Sedang merekam video klip (30 detik)...", utils.SendRetries())
 		sendHLSClipToTargets([]string{v.Info.Chat.String()}, baseURL, camera, "Manual Request Video", time.Now())
 	}()
 }
 
 func handleJIDCommand(v *events.Message, originalMessage string) {
-	if !whatsapp.Client.IsConnected() {
+	if !whatsapp.GetClient().IsConnected() {
 		return
 	}
 
@@ -644,8 +876,944 @@ func handleJIDCommand(v *events.Message, originalMessage string) {
 		response = fmt.Sprintf("[Info JID]\n\nInput: %s\nJID Format: %s", target, jid.String())
 	}
 
-	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, 2)
+	err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries())
 	if err != nil {
 		log.Printf("Failed to send JID info: %v", err)
 	}
 }
+
+func handleDescribeCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	imageMsg := utils.GetImageMessage(v.Message)
+	if imageMsg == nil {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Deskripsi Gambar AI]\n\nKirim sebuah gambar dengan caption *!describe* untuk mendapatkan penjelasan isi gambar.\n\nCara menggunakan:\n- !describe\n- !describe apa merek mobil ini?\n\nContoh: kirim foto dengan caption !describe jelaskan suasana di foto ini", utils.SendRetries())
+		return
+	}
+
+	var prompt string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!describe ") || strings.HasPrefix(lower, "/describe ") {
+		prompt = strings.TrimSpace(originalMessage[10:])
+	}
+
+	defer showThinking(v.Info.Chat, "[AI] Sedang menganalisis gambar...\n\nMohon tunggu sebentar ya.")()
+
+	imageData, err := whatsapp.GetClient().Download(context.Background(), imageMsg)
+	if err != nil {
+		log.Printf("Failed to download image for describe: %v", err)
+		replyError(v, "[Error] Gagal mengunduh gambar dari WhatsApp. Silakan kirim ulang gambarnya.")
+		return
+	}
+
+	description, err := gemini.GetGeminiImageDescription(context.Background(), imageData, imageMsg.GetMimetype(), prompt)
+	if err != nil {
+		log.Printf("Failed to describe image: %v", err)
+		if strings.Contains(err.Error(), "API key not configured") {
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.")
+			return
+		}
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat menganalisis gambar. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Deskripsi Gambar AI]\n\n%s", description)
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send image description: %v", err)
+	}
+}
+
+func handleEditCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	usage := "[Edit Gambar AI]\n\nBalas sebuah gambar (atau kirim gambar dengan caption) *!edit [instruksi]* untuk mengubah gambar dengan AI.\n\nContoh:\n- !edit ubah langit menjadi ungu\n- !edit tambahkan topi di kepala kucing"
+
+	imageMsg := utils.GetImageMessage(v.Message)
+	if imageMsg == nil {
+		imageMsg = utils.GetImageMessage(utils.GetQuotedMessage(v.Message))
+	}
+
+	var prompt string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!edit ") || strings.HasPrefix(lower, "/edit ") {
+		prompt = strings.TrimSpace(originalMessage[6:])
+	}
+
+	if imageMsg == nil || prompt == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, usage, utils.SendRetries())
+		return
+	}
+
+	defer showThinking(v.Info.Chat, "[AI] Sedang mengedit gambar...\n\nMohon tunggu sebentar ya. Proses ini mungkin membutuhkan waktu 30-60 detik.")()
+
+	imageData, err := whatsapp.GetClient().Download(context.Background(), imageMsg)
+	if err != nil {
+		log.Printf("Failed to download image for edit: %v", err)
+		replyError(v, "[Error] Gagal mengunduh gambar dari WhatsApp. Silakan kirim ulang gambarnya.")
+		return
+	}
+
+	edited, err := gemini.GetGeminiImageEdit(context.Background(), imageData, imageMsg.GetMimetype(), prompt)
+	if err != nil {
+		log.Printf("Failed to edit image: %v", err)
+		if errors.Is(err, gemini.ErrImageEditUnsupported) {
+			replyError(v, "[Error] Model gambar yang dikonfigurasi (GEMINI_IMAGE_MODEL) tidak mendukung edit gambar. Coba gunakan model yang mendukung input dan output gambar.")
+			return
+		}
+		replyError(v, imageErrorMessage(err, prompt))
+		return
+	}
+
+	if scaled, scaleErr := utils.DownscaleImageBase64(edited); scaleErr != nil {
+		log.Printf("Failed to downscale edited image, sending original: %v", scaleErr)
+	} else {
+		edited = scaled
+	}
+
+	caption := utils.TruncateCaption(fmt.Sprintf("[Gambar AI Edited]\n\nInstruksi: %s", prompt))
+	if err := utils.SendImageWithRetry(context.Background(), v.Info.Chat, edited, caption, utils.ImageRetries()); err != nil {
+		log.Printf("Failed to send edited image: %v", err)
+		replyError(v, "[Error] Gambar berhasil diedit tetapi gagal dikirim ke WhatsApp. Silakan coba lagi.")
+	}
+}
+
+func handleJokeCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	joke := pickRandom(getContentPool().Jokes)
+	if joke == "" {
+		joke = "Belum ada lelucon yang tersedia."
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Joke]\n\n"+joke, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send joke: %v", err)
+	}
+}
+
+func handleQuoteCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	quote := pickRandom(getContentPool().Quotes)
+	if quote == "" {
+		quote = "Belum ada kutipan yang tersedia."
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Quote]\n\n"+quote, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send quote: %v", err)
+	}
+}
+
+func handleStickerCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	imageMsg := utils.GetImageMessage(v.Message)
+	if imageMsg == nil {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Sticker]\n\nKirim sebuah gambar dengan caption *!sticker* untuk mengubahnya menjadi stiker WhatsApp.", utils.SendRetries())
+		return
+	}
+
+	imageData, err := whatsapp.GetClient().Download(context.Background(), imageMsg)
+	if err != nil {
+		log.Printf("Failed to download image for sticker: %v", err)
+		replyError(v, "[Error] Gagal mengunduh gambar dari WhatsApp. Silakan kirim ulang gambarnya.")
+		return
+	}
+
+	webpData, err := utils.ConvertToStickerWebP(imageData)
+	if err != nil {
+		log.Printf("Failed to convert image to sticker: %v", err)
+		replyError(v, "[Error] Gagal mengubah gambar menjadi stiker. Pastikan format gambar didukung (JPEG/PNG/WebP).")
+		return
+	}
+
+	if err := utils.SendSticker(context.Background(), v.Info.Chat, webpData); err != nil {
+		log.Printf("Failed to send sticker: %v", err)
+		replyError(v, "[Error] Gagal mengirim stiker ke WhatsApp.")
+	}
+}
+
+func handleSummarizeCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	quotedText := strings.TrimSpace(utils.GetMessageText(utils.GetQuotedMessage(v.Message)))
+	if quotedText == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Ringkasan]\n\nBalas (reply) pesan yang ingin diringkas dengan perintah *!summarize*.\n\nPesan yang dibalas harus berisi teks atau caption.", utils.SendRetries())
+		return
+	}
+
+	defer showThinking(v.Info.Chat, "[AI] Sedang meringkas pesan...")()
+
+	summary, err := gemini.GetGeminiSummary(context.Background(), quotedText)
+	if err != nil {
+		log.Printf("Failed to summarize message: %v", err)
+		if strings.Contains(err.Error(), "API key not configured") {
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.")
+			return
+		}
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat meringkas pesan. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Ringkasan]\n\n%s", summary)
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send summary: %v", err)
+	}
+}
+
+func handleSentimentCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	quotedText := strings.TrimSpace(utils.GetMessageText(utils.GetQuotedMessage(v.Message)))
+	if quotedText == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Sentimen]\n\nBalas (reply) pesan yang ingin dianalisis dengan perintah *!sentiment*.\n\nPesan yang dibalas harus berisi teks atau caption.", utils.SendRetries())
+		return
+	}
+
+	sentiment, err := gemini.GetGeminiSentiment(context.Background(), quotedText)
+	if err != nil {
+		log.Printf("Failed to analyze sentiment: %v", err)
+		if strings.Contains(err.Error(), "API key not configured") {
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.")
+			return
+		}
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat menganalisis sentimen. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Sentimen]\n\nHasil: *%s*\nKeyakinan: %s", strings.ToUpper(sentiment.Label), sentiment.Confidence)
+	if sentiment.Reason != "" {
+		response += "\nAlasan: " + sentiment.Reason
+	}
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send sentiment result: %v", err)
+	}
+}
+
+func handleYtdlCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var sourceURL string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!ytdl ") || strings.HasPrefix(lower, "/ytdl ") {
+		sourceURL = strings.TrimSpace(originalMessage[6:])
+	}
+
+	if sourceURL == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Video Downloader]\n\nCara menggunakan:\n- !ytdl [link video]\n\nContoh: !ytdl https://youtu.be/xxxx\n\nBatas: durasi %d detik, ukuran %d MB", media.MaxDurationSeconds(), media.MaxVideoBytes()/(1024*1024)), utils.SendRetries())
+		return
+	}
+
+	if _, err := media.ValidateURL(sourceURL); err != nil {
+		replyError(v, "[Error] Link tidak valid atau situs tidak didukung.")
+		return
+	}
+
+	defer showThinking(v.Info.Chat, "[Video Downloader] Sedang mengunduh video...\n\nMohon tunggu sebentar ya.")()
+
+	video, err := media.DownloadVideo(context.Background(), sourceURL)
+	if err != nil {
+		log.Printf("Failed to download media from %s: %v", sourceURL, err)
+
+		errMsg := "[Error] Gagal mengunduh video. Silakan coba lagi nanti."
+		switch {
+		case strings.Contains(err.Error(), "not configured"):
+			errMsg = "[Error] MEDIA_DOWNLOADER_URL belum dikonfigurasi di server."
+		case strings.Contains(err.Error(), "too large"), strings.Contains(err.Error(), "too long"):
+			errMsg = fmt.Sprintf("[Error] Video terlalu besar atau terlalu panjang.\n\nBatas: durasi %d detik, ukuran %d MB", media.MaxDurationSeconds(), media.MaxVideoBytes()/(1024*1024))
+		}
+		replyError(v, errMsg)
+		return
+	}
+
+	caption := "[Video Downloader]"
+	if video.Title != "" {
+		caption += "\n\n" + video.Title
+	}
+
+	if err := sendVideoToJID(context.Background(), v.Info.Chat, video.Data, caption); err != nil {
+		log.Printf("Failed to send downloaded video: %v", err)
+		replyError(v, "[Error] Video berhasil diunduh tetapi gagal dikirim ke WhatsApp.")
+	}
+}
+
+func handleDefineCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var term string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!define ") || strings.HasPrefix(lower, "/define ") {
+		term = strings.TrimSpace(originalMessage[8:])
+	}
+
+	if term == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Kamus]\n\nGunakan: !define [kata atau istilah]\n\nContoh:\n- !define algoritma\n- !define inflasi", utils.SendRetries())
+		return
+	}
+
+	stopTyping := utils.StartTyping(v.Info.Chat)
+	definition, err := gemini.GetGeminiDefinition(context.Background(), term)
+	stopTyping()
+	if err != nil {
+		log.Printf("Failed to define term: %v", err)
+		if strings.Contains(err.Error(), "API key not configured") {
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.")
+			return
+		}
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat mencari definisi. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Kamus] *%s*\n\n%s", term, utils.FormatForWhatsApp(definition))
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send definition: %v", err)
+	}
+}
+
+func handleJadwalCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var city string
+	if fields := strings.SplitN(strings.TrimSpace(originalMessage), " ", 2); len(fields) == 2 {
+		city = strings.TrimSpace(fields[1])
+	}
+	if city == "" {
+		city = prayer.DefaultCity()
+	}
+
+	times, err := prayer.GetTimes(context.Background(), city)
+	if err != nil {
+		log.Printf("Failed to get prayer times for %s: %v", city, err)
+		if strings.Contains(err.Error(), "not found") {
+			replyError(v, fmt.Sprintf("[Error] Jadwal sholat untuk kota *%s* tidak ditemukan.\n\nContoh: !jadwal Bandung", city))
+			return
+		}
+		replyError(v, "[Error] Gagal mengambil jadwal sholat. Silakan coba lagi nanti.")
+		return
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, prayer.FormatTimes(times), utils.SendRetries()); err != nil {
+		log.Printf("Failed to send prayer times: %v", err)
+	}
+}
+
+func handleWikiCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var topic string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!wiki ") || strings.HasPrefix(lower, "/wiki ") {
+		topic = strings.TrimSpace(originalMessage[6:])
+	}
+
+	if topic == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Wikipedia]\n\nGunakan: !wiki [topik]\n\nContoh:\n- !wiki Borobudur\n- !wiki Bank Indonesia", utils.SendRetries())
+		return
+	}
+
+	summary, err := wiki.GetSummary(context.Background(), topic)
+	switch {
+	case errors.Is(err, wiki.ErrNotFound):
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Wikipedia]\n\nArtikel *%s* tidak ditemukan. Coba gunakan kata kunci lain.", topic), utils.SendRetries())
+		return
+	case errors.Is(err, wiki.ErrDisambiguated):
+		msg := fmt.Sprintf("[Wikipedia]\n\n*%s* memiliki beberapa arti. Coba gunakan kata kunci yang lebih spesifik.", topic)
+		if summary != nil && summary.URL != "" {
+			msg += "\n\nDaftar arti: " + summary.URL
+		}
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, msg, utils.SendRetries())
+		return
+	case err != nil:
+		log.Printf("Failed to get Wikipedia summary for %s: %v", topic, err)
+		replyError(v, "[Error] Gagal mengambil data dari Wikipedia. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Wikipedia] *%s*\n\n%s", summary.Title, summary.Extract)
+	if summary.URL != "" {
+		response += "\n\n" + summary.URL
+	}
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send Wikipedia summary: %v", err)
+	}
+}
+
+func handleCalcCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var expr string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!calc ") || strings.HasPrefix(lower, "/calc ") {
+		expr = strings.TrimSpace(originalMessage[6:])
+	}
+
+	usage := "[Kalkulator]\n\nGunakan: !calc [ekspresi]\n\nOperator: + - * / % dan tanda kurung\n\nContoh:\n- !calc 2*(3+4)/5\n- !calc 17 % 5\n- !calc -2.5 * 4"
+	if expr == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, usage, utils.SendRetries())
+		return
+	}
+
+	result, err := utils.EvaluateExpression(expr)
+	if errors.Is(err, utils.ErrDivisionByZero) {
+		replyError(v, "[Error] Tidak bisa membagi dengan nol.")
+		return
+	}
+	if err != nil {
+		replyError(v, "[Error] Ekspresi tidak valid.\n\n"+usage)
+		return
+	}
+
+	response := fmt.Sprintf("[Kalkulator]\n\n%s = *%s*", expr, utils.FormatNumber(result))
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send calc result: %v", err)
+	}
+}
+
+func handleConvertCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	fields := strings.Fields(originalMessage)
+	usage := "[Kurs]\n\nGunakan: !convert [jumlah] [dari] [ke]\n\nContoh:\n- !convert 100 USD IDR\n- !convert 1,5 EUR USD"
+	if len(fields) < 4 {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, usage, utils.SendRetries())
+		return
+	}
+
+	amount, err := fx.ParseAmount(fields[1])
+	if err != nil {
+		replyError(v, "[Error] Jumlah tidak valid.\n\n"+usage)
+		return
+	}
+	from, to := strings.ToUpper(fields[2]), strings.ToUpper(fields[3])
+
+	result, rate, err := fx.Convert(context.Background(), amount, from, to)
+	if errors.Is(err, fx.ErrUnknownCurrency) {
+		replyError(v, fmt.Sprintf("[Error] Kode mata uang tidak dikenal: %s -> %s\n\nGunakan kode 3 huruf seperti USD, IDR, EUR, SGD.", from, to))
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to convert currency: %v", err)
+		replyError(v, "[Error] Gagal mengambil kurs. Silakan coba lagi nanti.")
+		return
+	}
+
+	response := fmt.Sprintf("[Kurs]\n\n%s %s = *%s %s*\n\n1 %s = %s %s", fx.FormatAmount(amount), from, fx.FormatAmount(result), to, from, fx.FormatAmount(rate), to)
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send conversion result: %v", err)
+	}
+}
+
+func handleLocationCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	fields := strings.Fields(originalMessage)
+	usage := "[Lokasi]\n\nGunakan: !location [lat] [lng] [nama]\n\nLatitude -90 s/d 90, longitude -180 s/d 180\n\nContoh:\n- !location -6.1754 106.8272 Monas"
+	if len(fields) < 3 {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, usage, utils.SendRetries())
+		return
+	}
+
+	lat, lng, err := utils.ParseCoordinates(fields[1], fields[2])
+	if err != nil {
+		replyError(v, "[Error] Koordinat tidak valid.\n\n"+usage)
+		return
+	}
+	name := strings.Join(fields[3:], " ")
+
+	if err := utils.SendLocationWithRetry(context.Background(), v.Info.Chat, lat, lng, name, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send location: %v", err)
+		replyError(v, "[Error] Gagal mengirim lokasi")
+	}
+}
+
+const maxFeedbackLength = 2000
+
+func handleFeedbackCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var text string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!feedback ") || strings.HasPrefix(lower, "/feedback ") {
+		text = strings.TrimSpace(originalMessage[10:])
+	}
+
+	if text == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Feedback]\n\nGunakan: !feedback [pesan]\n\nContoh: !feedback Tolong tambahkan fitur kurs mata uang", utils.SendRetries())
+		return
+	}
+	if len([]rune(text)) > maxFeedbackLength {
+		text = string([]rune(text)[:maxFeedbackLength])
+	}
+
+	if feedback.Store == nil {
+		replyError(v, "[Error] Penyimpanan feedback belum siap. Silakan coba lagi nanti.")
+		return
+	}
+
+	entry := feedback.Entry{
+		Chat:     v.Info.Chat.String(),
+		Sender:   v.Info.Sender.ToNonAD().String(),
+		PushName: v.Info.PushName,
+		Message:  text,
+	}
+	if err := feedback.Store.Append(entry); err != nil {
+		log.Printf("Failed to save feedback: %v", err)
+		replyError(v, "[Error] Gagal menyimpan feedback. Silakan coba lagi nanti.")
+		return
+	}
+
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Feedback] Terima kasih! Masukan Anda sudah dicatat.", utils.SendRetries())
+
+	if forward := config.Get().FeedbackForwardJID; forward != "" {
+		forwardJID := utils.CreateTargetJID(forward)
+		if forwardJID.IsEmpty() {
+			log.Printf("Invalid FEEDBACK_FORWARD_JID: %s", forward)
+			return
+		}
+		name := v.Info.PushName
+		if name == "" {
+			name = "-"
+		}
+		notice := fmt.Sprintf("[Feedback Baru]\n\nDari: %s (%s)\nChat: %s\n\n%s", name, entry.Sender, entry.Chat, text)
+		if err := utils.SendMessageWithRetry(context.Background(), forwardJID, notice, utils.SendRetries()); err != nil {
+			log.Printf("Failed to forward feedback: %v", err)
+		}
+	}
+}
+
+func handleShortenCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var longURL string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!shorten ") || strings.HasPrefix(lower, "/shorten ") {
+		longURL = strings.TrimSpace(originalMessage[9:])
+	}
+
+	usage := "[Shorten]\n\nGunakan: !shorten [url]\n\nContoh: !shorten https://www.idx.co.id/id/berita/suspensi"
+	if longURL == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, usage, utils.SendRetries())
+		return
+	}
+
+	short, err := shortener.Shorten(context.Background(), longURL)
+	if errors.Is(err, shortener.ErrInvalidURL) {
+		replyError(v, "[Error] URL tidak valid. Pastikan diawali dengan http:// atau https://\n\n"+usage)
+		return
+	}
+	if err != nil {
+		log.Printf("Failed to shorten URL: %v", err)
+		replyError(v, "[Error] Gagal memperpendek URL. Silakan coba lagi nanti.")
+		return
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Shorten]\n\n%s", short), utils.SendRetries()); err != nil {
+		log.Printf("Failed to send short URL: %v", err)
+	}
+}
+
+func handleWhoisCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	sender := v.Info.Sender.ToNonAD()
+	pushName := v.Info.PushName
+	if pushName == "" {
+		pushName = "-"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("[Whois]\n\n")
+	fmt.Fprintf(&sb, "Nama: %s\n", pushName)
+	fmt.Fprintf(&sb, "JID: %s\n", sender.String())
+	if !v.Info.SenderAlt.IsEmpty() {
+		fmt.Fprintf(&sb, "JID Alternatif: %s\n", v.Info.SenderAlt.ToNonAD().String())
+	}
+	if isAdmin(v.Info.Sender) {
+		sb.WriteString("Admin bot: Ya\n")
+	} else {
+		sb.WriteString("Admin bot: Tidak\n")
+	}
+
+	if v.Info.IsGroup {
+		fmt.Fprintf(&sb, "\nGrup: %s\n", v.Info.Chat.String())
+		info, err := whatsapp.GetClient().GetGroupInfo(context.Background(), v.Info.Chat)
+		if err != nil {
+			log.Printf("Failed to get group info for whois: %v", err)
+			sb.WriteString("Status di grup: tidak diketahui")
+		} else {
+			if info.Name != "" {
+				fmt.Fprintf(&sb, "Nama grup: %s\n", info.Name)
+			}
+			role := "Anggota"
+			for _, p := range info.Participants {
+				if p.JID.User != sender.User && p.LID.User != sender.User && p.PhoneNumber.User != sender.User {
+					continue
+				}
+				if p.IsSuperAdmin {
+					role = "Super admin"
+				} else if p.IsAdmin {
+					role = "Admin"
+				}
+				break
+			}
+			fmt.Fprintf(&sb, "Status di grup: %s", role)
+		}
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, strings.TrimRight(sb.String(), "\n"), utils.SendRetries()); err != nil {
+		log.Printf("Failed to send whois info: %v", err)
+	}
+}
+
+func handleGroupInfoCommand(v *events.Message) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	if !v.Info.IsGroup {
+		replyError(v, "[Error] Perintah !groupinfo hanya bisa digunakan di dalam grup")
+		return
+	}
+
+	info, err := whatsapp.GetClient().GetGroupInfo(context.Background(), v.Info.Chat)
+	if err != nil {
+		log.Printf("Failed to get group info: %v", err)
+		replyError(v, "[Error] Gagal mengambil informasi grup")
+		return
+	}
+
+	name := info.Name
+	if name == "" {
+		name = "-"
+	}
+	owner := "-"
+	if !info.OwnerPN.IsEmpty() {
+		owner = info.OwnerPN.ToNonAD().String()
+	} else if !info.OwnerJID.IsEmpty() {
+		owner = info.OwnerJID.ToNonAD().String()
+	}
+	memberCount := info.ParticipantCount
+	if memberCount == 0 {
+		memberCount = len(info.Participants)
+	}
+	created := "-"
+	if !info.GroupCreated.IsZero() {
+		created = info.GroupCreated.In(utils.WIB()).Format("02 Jan 2006, 15:04 WIB")
+	}
+
+	groupMessage := fmt.Sprintf(`[Info Grup]
+
+Nama: %s
+JID: %s
+Pemilik: %s
+Jumlah anggota: %d
+Dibuat: %s`, name, info.JID.String(), owner, memberCount, created)
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, groupMessage, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send group info: %v", err)
+	}
+}
+
+// shortenForVoice cuts text to at most maxChars, preferring to end on a sentence
+// boundary so the voice note doesn't stop mid-sentence.
+func shortenForVoice(text string, maxChars int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= maxChars {
+		return string(runes)
+	}
+	cut := string(runes[:maxChars])
+	if idx := strings.LastIndexAny(cut, ".!?"); idx > len(cut)/2 {
+		return cut[:idx+1]
+	}
+	return strings.TrimSpace(cut) + "..."
+}
+
+func handleVoiceCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var question string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!voice ") || strings.HasPrefix(lower, "/voice ") {
+		question = strings.TrimSpace(originalMessage[7:])
+	}
+
+	if question == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Voice]\n\nGunakan: !voice [pertanyaan]\n\nContoh: !voice apa itu fotosintesis?", utils.SendRetries())
+		return
+	}
+
+	stopTyping := utils.StartTyping(v.Info.Chat)
+	persona := gemini.MemStore.GetPersona(v.Info.Chat.String())
+	answer, err := gemini.GetGeminiResponseWithName(context.Background(), persona, question+"\n\n(Jawab singkat dalam maksimal 3 kalimat, tanpa format markdown, karena jawaban akan dibacakan.)")
+	stopTyping()
+	if err != nil {
+		log.Printf("Failed to get voice answer: %v", err)
+		if strings.Contains(err.Error(), "API key not configured") {
+			replyError(v, "[Error] API_KEY_GEMINI belum dikonfigurasi di environment variable.")
+			return
+		}
+		replyError(v, "[Error] Maaf, terjadi kesalahan saat memproses pertanyaan Anda. Silakan coba lagi nanti.")
+		return
+	}
+
+	answer = shortenForVoice(answer, config.Get().VoiceMaxChars)
+
+	if err := sendSpokenText(v.Info.Chat, answer, ""); err != nil {
+		log.Printf("Voice note failed, falling back to text: %v", err)
+		if sendErr := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[Fiq]\n\n"+utils.FormatForWhatsApp(answer), utils.SendRetries()); sendErr != nil {
+			log.Printf("Failed to send voice fallback text: %v", sendErr)
+		}
+	}
+}
+
+// sendSpokenText synthesizes text in lang ("" for TTS_LANG) and sends it to
+// chat as a voice note.
+func sendSpokenText(chat types.JID, text, lang string) error {
+	audio, err := tts.Synthesize(context.Background(), text, lang)
+	if err != nil {
+		return err
+	}
+	audio, seconds, err := utils.ConvertToOpus(audio)
+	if err != nil {
+		return err
+	}
+	return utils.SendVoiceNote(context.Background(), chat, audio, seconds)
+}
+
+// ttsLanguages are the codes !tts accepts as an optional first word. The list
+// is fixed so ordinary words are never mistaken for a language.
+var ttsLanguages = map[string]bool{
+	"id": true, "en": true, "ms": true, "jv": true, "su": true, "ar": true,
+	"ja": true, "ko": true, "zh": true, "fr": true, "de": true, "es": true,
+	"it": true, "nl": true, "pt": true, "ru": true, "th": true, "vi": true,
+	"hi": true, "tr": true,
+}
+
+func handleTTSCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var text string
+	lower := strings.ToLower(originalMessage)
+	if strings.HasPrefix(lower, "!tts ") || strings.HasPrefix(lower, "/tts ") {
+		text = strings.TrimSpace(originalMessage[5:])
+	}
+
+	lang := ""
+	if fields := strings.Fields(text); len(fields) > 1 && ttsLanguages[strings.ToLower(fields[0])] {
+		lang = strings.ToLower(fields[0])
+		text = strings.TrimSpace(text[len(fields[0]):])
+	}
+
+	if text == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, "[TTS]\n\nGunakan: !tts [bahasa] [teks]\n\nBahasa opsional (default: "+tts.Language()+"), contoh: id, en, ja, ar\n\nContoh:\n- !tts selamat pagi semuanya\n- !tts en hello world", utils.SendRetries())
+		return
+	}
+
+	maxChars := config.Get().TTSMaxChars
+	if len([]rune(text)) > maxChars {
+		replyError(v, fmt.Sprintf("[Error] Teks terlalu panjang. Maksimal %d karakter.", maxChars))
+		return
+	}
+
+	if err := sendSpokenText(v.Info.Chat, text, lang); err != nil {
+		log.Printf("TTS voice note failed: %v", err)
+		if strings.Contains(err.Error(), "not configured") {
+			replyError(v, "[Error] TTS_SERVICE_URL belum dikonfigurasi di environment variable.")
+			return
+		}
+		replyError(v, "[Error] Gagal membuat pesan suara. Silakan coba lagi nanti.\n\n"+text)
+	}
+}
+
+func handleRollCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var spec string
+	if fields := strings.Fields(originalMessage); len(fields) > 1 {
+		spec = strings.Join(fields[1:], "")
+	}
+
+	count, sides, err := utils.ParseDice(spec)
+	if err != nil {
+		msg := "[Error] Format dadu tidak valid."
+		switch {
+		case errors.Is(err, utils.ErrTooManyDice):
+			msg = fmt.Sprintf("[Error] Maksimal %d dadu sekali lempar.", utils.MaxDiceCount)
+		case errors.Is(err, utils.ErrTooManySides):
+			msg = fmt.Sprintf("[Error] Maksimal %d sisi per dadu.", utils.MaxDiceSides)
+		}
+		replyError(v, msg+"\n\nGunakan:\n- !roll (1 dadu 6 sisi)\n- !roll 2d6\n- !roll 100 (angka 1-100)")
+		return
+	}
+
+	rolls, total := utils.RollDice(count, sides)
+
+	var response string
+	if count == 1 {
+		response = fmt.Sprintf("[Roll] 1d%d\n\nHasil: *%d*", sides, total)
+	} else {
+		parts := make([]string, len(rolls))
+		for i, r := range rolls {
+			parts[i] = strconv.Itoa(r)
+		}
+		response = fmt.Sprintf("[Roll] %dd%d\n\nHasil: %s\nTotal: *%d*", count, sides, strings.Join(parts, ", "), total)
+	}
+	if pushName := v.Info.PushName; pushName != "" {
+		response += "\n\nDilempar oleh " + pushName
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send roll result: %v", err)
+	}
+}
+
+func handleCountdownCommand(v *events.Message, originalMessage string) {
+	if !whatsapp.GetClient().IsConnected() {
+		return
+	}
+
+	var args string
+	if fields := strings.SplitN(strings.TrimSpace(originalMessage), " ", 2); len(fields) == 2 {
+		args = strings.TrimSpace(fields[1])
+	}
+
+	usage := "[Countdown]\n\nGunakan: !countdown [tanggal] [jam opsional] [nama acara]\n\nContoh:\n- !countdown 2025-12-31 Tahun Baru\n- !countdown 17/08/2025 08:00 Upacara\n- !countdown 1 Januari 2026"
+	if args == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, usage, utils.SendRetries())
+		return
+	}
+
+	loc := utils.WIB()
+	target, label, err := utils.ParseEventDate(args, loc)
+	if err != nil {
+		replyError(v, "[Error] Format tanggal tidak dikenali.\n\n"+usage)
+		return
+	}
+	if label == "" {
+		label = target.Format("02 Jan 2006 15:04")
+	}
+
+	remaining := time.Until(target)
+	var response string
+	if remaining <= 0 {
+		response = fmt.Sprintf("[Countdown] *%s*\n\n%s sudah lewat %s yang lalu.", label, target.Format("02 Jan 2006 15:04 WIB"), formatDuration(-remaining))
+	} else {
+		response = fmt.Sprintf("[Countdown] *%s*\n\nTersisa %s lagi\n(%s)", label, formatDuration(remaining), target.Format("02 Jan 2006 15:04 WIB"))
+	}
+
+	if err := utils.SendMessageWithRetry(context.Background(), v.Info.Chat, response, utils.SendRetries()); err != nil {
+		log.Printf("Failed to send countdown: %v", err)
+	}
+}
+
+// formatDuration renders d as "X hari Y jam Z menit", omitting leading zero units.
+func formatDuration(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+
+	var parts []string
+	if days > 0 {
+		parts = append(parts, fmt.Sprintf("%d hari", days))
+	}
+	if hours > 0 || days > 0 {
+		parts = append(parts, fmt.Sprintf("%d jam", hours))
+	}
+	parts = append(parts, fmt.Sprintf("%d menit", minutes))
+	return strings.Join(parts, " ")
+}
+
+// maxPushNameLen is the longest display name the WhatsApp app accepts.
+const maxPushNameLen = 25
+
+// validatePushName trims name and rejects empty, overlong or multi-line names
+// and names with control or invisible formatting characters.
+func validatePushName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("nama tidak boleh kosong")
+	}
+	if n := utf8.RuneCountInString(name); n > maxPushNameLen {
+		return "", fmt.Errorf("nama terlalu panjang (%d karakter, maksimal %d)", n, maxPushNameLen)
+	}
+	for _, r := range name {
+		if unicode.IsControl(r) || unicode.Is(unicode.Cf, r) {
+			return "", fmt.Errorf("nama tidak boleh berisi baris baru atau karakter khusus")
+		}
+	}
+	return name, nil
+}
+
+func handleSetNameCommand(v *events.Message, originalMessage string) {
+	client := whatsapp.GetClient()
+	if !client.IsConnected() {
+		return
+	}
+
+	var args string
+	if parts := strings.SplitN(strings.TrimSpace(originalMessage), " ", 2); len(parts) == 2 {
+		args = parts[1]
+	}
+	if strings.TrimSpace(args) == "" {
+		utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Nama Bot]\n\nNama saat ini: *%s*\n\nGunakan: !setname [nama baru] (maksimal %d karakter)", client.Store.PushName, maxPushNameLen), utils.SendRetries())
+		return
+	}
+
+	name, err := validatePushName(args)
+	if err != nil {
+		replyError(v, "[Error] Nama bot tidak valid: "+err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	old := client.Store.PushName
+	if err := client.SendAppState(ctx, appstate.BuildSettingPushName(name)); err != nil {
+		log.Printf("[Admin] Failed to set push name to %q: %v", name, err)
+		replyError(v, "[Error] Gagal mengganti nama bot. Silakan coba lagi nanti.")
+		return
+	}
+
+	log.Printf("[Admin] %s changed push name from %q to %q", v.Info.Sender.String(), old, name)
+	utils.SendMessageWithRetry(context.Background(), v.Info.Chat, fmt.Sprintf("[Nama Bot]\n\nNama bot berhasil diganti menjadi *%s*.", name), utils.SendRetries())
+}
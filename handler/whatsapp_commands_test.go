@@ -0,0 +1,26 @@
+package handler
+
+import "testing"
+
+func TestSplitImageCount(t *testing.T) {
+	tests := []struct {
+		prompt     string
+		wantCount  int
+		wantPrompt string
+	}{
+		{"kucing lucu", 1, "kucing lucu"},
+		{"3 kucing lucu", 3, "kucing lucu"},
+		{"4 robot", 4, "robot"},
+		{"1984 poster", 1, "1984 poster"},
+		{"5 kucing", 1, "5 kucing"},
+		{"0 kucing", 1, "0 kucing"},
+		{"-2 kucing", 1, "-2 kucing"},
+		{"2", 1, "2"},
+	}
+	for _, tt := range tests {
+		count, prompt := splitImageCount(tt.prompt)
+		if count != tt.wantCount || prompt != tt.wantPrompt {
+			t.Errorf("splitImageCount(%q) = %d, %q; want %d, %q", tt.prompt, count, prompt, tt.wantCount, tt.wantPrompt)
+		}
+	}
+}
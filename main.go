@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "github.com/glebarez/sqlite"
 	"github.com/joho/godotenv"
@@ -13,9 +16,15 @@ import (
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	waLog "go.mau.fi/whatsmeow/util/log"
 
+	"whatsmeow-api/config"
 	"whatsmeow-api/handler"
 
+	"whatsmeow-api/services/alerts"
+	"whatsmeow-api/services/audit"
+	"whatsmeow-api/services/feedback"
 	"whatsmeow-api/services/gemini"
+	"whatsmeow-api/services/schedule"
+	"whatsmeow-api/services/subscription"
 	"whatsmeow-api/whatsapp"
 )
 
@@ -28,13 +37,41 @@ func main() {
 
 	logger := waLog.Stdout("whatsapp", "INFO", true)
 
-	memoryPath := os.Getenv("MEMORY_FILE")
-	if memoryPath == "" {
-		memoryPath = "memory.json"
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Printf("[config] Invalid configuration values, falling back to defaults:\n%v", err)
 	}
-	if err := gemini.InitMemory(memoryPath); err != nil {
+	config.Set(cfg)
+
+	if err := gemini.InitMemory(cfg.MemoryFile); err != nil {
 		log.Printf("Failed to initialize memory store: %v", err)
 	}
+	gemini.MemStore.StartAutoSave(cfg.MemorySaveInterval)
+
+	if err := handler.InitStats(cfg.StatsFile); err != nil {
+		log.Printf("Failed to load stats: %v", err)
+	}
+	handler.StartStatsAutoSave(cfg.MemorySaveInterval)
+
+	if err := alerts.InitAlerts(cfg.AlertsFile); err != nil {
+		log.Printf("Failed to initialize price alerts: %v", err)
+	}
+
+	if err := schedule.InitSchedules(cfg.SchedulesFile); err != nil {
+		log.Printf("Failed to initialize schedules: %v", err)
+	}
+
+	if err := feedback.InitFeedback(cfg.FeedbackFile); err != nil {
+		log.Printf("Failed to initialize feedback store: %v", err)
+	}
+
+	if err := subscription.InitSubscriptions(cfg.SubscriptionsFile); err != nil {
+		log.Printf("Failed to initialize subscriptions: %v", err)
+	}
+
+	if err := audit.InitAudit(cfg.AuditLogFile, cfg.AuditLogMaxBytes, cfg.AuditLogBackups); err != nil {
+		log.Printf("Failed to initialize audit log: %v", err)
+	}
 
 	if err := os.MkdirAll("session", 0755); err != nil {
 		log.Fatalf("Failed to create session directory: %v", err)
@@ -45,47 +82,95 @@ func main() {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// On a fresh store there is no device yet; start a new one and fall through to QR login
 	deviceStore, err := container.GetFirstDevice(ctx)
 	if err != nil {
-		log.Fatalf("Failed to get device: %v", err)
+		log.Printf("Failed to load device from store, creating a new one: %v", err)
+		deviceStore = nil
+	}
+	if deviceStore == nil {
+		deviceStore = container.NewDevice()
 	}
 
-	whatsapp.Client = whatsmeow.NewClient(deviceStore, logger)
-	whatsapp.Client.AddEventHandler(handler.EventHandler)
+	client := whatsmeow.NewClient(deviceStore, logger)
+	client.AddEventHandler(handler.EventHandler)
+	whatsapp.SetClient(client)
+
+	// whatsmeow deletes the stored device when the session is logged out, so a
+	// relogin needs a brand new device and client.
+	whatsapp.SetReloginFunc(func() error {
+		fresh := whatsmeow.NewClient(container.NewDevice(), logger)
+		fresh.AddEventHandler(handler.EventHandler)
+		if old := whatsapp.GetClient(); old != nil {
+			old.Disconnect()
+		}
+		whatsapp.SetClient(fresh)
+		return whatsapp.StartQRLogin(context.Background(), fresh)
+	})
 
-	if whatsapp.Client.Store.ID == nil {
-		qrChan, _ := whatsapp.Client.GetQRChannel(ctx)
-		err = whatsapp.Client.Connect()
-		if err != nil {
+	if client.Store.ID == nil {
+		if err := whatsapp.StartQRLogin(ctx, client); err != nil {
 			log.Fatalf("Failed to connect: %v", err)
 		}
-		for evt := range qrChan {
-			if evt.Event == "code" {
-				fmt.Println("QR Code:")
-				fmt.Println(evt.Code)
-			} else {
-				fmt.Println("Login event:", evt.Event)
-			}
-		}
 	} else {
-		err = whatsapp.Client.Connect()
+		err = client.Connect()
 		if err != nil {
 			log.Fatalf("Failed to connect: %v", err)
 		}
 	}
 
+	go handler.StartPriceAlertWatcher()
+	go handler.StartScheduler()
+	go handler.StartIDXBroadcast()
+
 	r := handler.SetupRoutes()
 	httpHandler := handler.SetupCORS(r)
 
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
-	}
+	port := cfg.Port
 
 	log.Printf("[server] WhatsApp Bot Server starting...")
 	log.Printf("[server] Port: %s", port)
-	log.Printf("[server] WhatsApp Connected: %t", whatsapp.Client.IsConnected())
+	log.Printf("[server] WhatsApp Connected: %t", client.IsConnected())
 	log.Printf("[server] Server is ready and listening on port %s", port)
 
-	log.Fatal(http.ListenAndServe(":"+port, httpHandler))
+	server := &http.Server{
+		Addr:    ":" + port,
+		Handler: httpHandler,
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.ListenAndServe()
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-serverErr:
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("[server] HTTP server failed: %v", err)
+		}
+	case sig := <-stop:
+		log.Printf("[server] Received %s, shutting down...", sig)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("[server] HTTP server shutdown error: %v", err)
+	}
+
+	if err := gemini.MemStore.Save(); err != nil {
+		log.Printf("[server] Failed to save memory store: %v", err)
+	}
+
+	if err := handler.SaveStats(); err != nil {
+		log.Printf("[server] Failed to save stats: %v", err)
+	}
+
+	// A relogin may have replaced the client created at startup.
+	whatsapp.GetClient().Disconnect()
+	log.Printf("[server] Shutdown complete")
 }
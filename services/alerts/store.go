@@ -0,0 +1,211 @@
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type PriceAlert struct {
+	ID        int     `json:"id"`
+	Owner     string  `json:"owner"`
+	Code      string  `json:"code"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	CreatedAt int64   `json:"created_at"`
+}
+
+// Crossed reports whether price satisfies the alert condition
+func (a PriceAlert) Crossed(price float64) bool {
+	switch a.Operator {
+	case ">":
+		return price > a.Threshold
+	case "<":
+		return price < a.Threshold
+	}
+	return false
+}
+
+type AlertStore struct {
+	mu       sync.Mutex
+	FilePath string       `json:"-"`
+	NextID   int          `json:"next_id"`
+	Alerts   []PriceAlert `json:"alerts"`
+}
+
+var Store *AlertStore
+
+func InitAlerts(filePath string) error {
+	if filePath == "" {
+		filePath = "alerts.json"
+	}
+
+	dir := filepath.Dir(filePath)
+	if dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+
+	store := &AlertStore{
+		FilePath: filePath,
+		NextID:   1,
+		Alerts:   []PriceAlert{},
+	}
+
+	if b, err := os.ReadFile(filePath); err == nil && len(b) > 0 {
+		if err := json.Unmarshal(b, store); err != nil {
+			Store = store
+			return fmt.Errorf("failed to parse %s: %v", filePath, err)
+		}
+		if store.NextID < 1 {
+			store.NextID = 1
+		}
+	}
+
+	Store = store
+	return nil
+}
+
+func (s *AlertStore) Add(owner, code, operator string, threshold float64) (PriceAlert, error) {
+	if s == nil {
+		return PriceAlert{}, fmt.Errorf("alert store not initialized")
+	}
+	if operator != ">" && operator != "<" {
+		return PriceAlert{}, fmt.Errorf("unsupported operator %q", operator)
+	}
+
+	s.mu.Lock()
+	alert := PriceAlert{
+		ID:        s.NextID,
+		Owner:     owner,
+		Code:      strings.ToUpper(code),
+		Operator:  operator,
+		Threshold: threshold,
+		CreatedAt: time.Now().Unix(),
+	}
+	s.NextID++
+	s.Alerts = append(s.Alerts, alert)
+	s.mu.Unlock()
+
+	return alert, s.Save()
+}
+
+func (s *AlertStore) List(owner string) []PriceAlert {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []PriceAlert
+	for _, a := range s.Alerts {
+		if a.Owner == owner {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// Remove deletes an alert owned by owner and reports whether it existed
+func (s *AlertStore) Remove(owner string, id int) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	removed := false
+	kept := s.Alerts[:0]
+	for _, a := range s.Alerts {
+		if a.ID == id && a.Owner == owner {
+			removed = true
+			continue
+		}
+		kept = append(kept, a)
+	}
+	s.Alerts = kept
+	s.mu.Unlock()
+
+	if removed {
+		_ = s.Save()
+	}
+	return removed
+}
+
+// Codes returns the distinct tickers that have at least one active alert
+func (s *AlertStore) Codes() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var codes []string
+	for _, a := range s.Alerts {
+		if !seen[a.Code] {
+			seen[a.Code] = true
+			codes = append(codes, a.Code)
+		}
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// TakeTriggered removes and returns every alert on code crossed by price (alerts are one-shot).
+// Callers that fail to deliver the notification should hand the alert back with Restore.
+func (s *AlertStore) TakeTriggered(code string, price float64) []PriceAlert {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	var triggered []PriceAlert
+	kept := s.Alerts[:0]
+	for _, a := range s.Alerts {
+		if a.Code == code && a.Crossed(price) {
+			triggered = append(triggered, a)
+			continue
+		}
+		kept = append(kept, a)
+	}
+	s.Alerts = kept
+	s.mu.Unlock()
+
+	if len(triggered) > 0 {
+		_ = s.Save()
+	}
+	return triggered
+}
+
+// Restore puts back an alert taken by TakeTriggered, keeping its ID, so it is checked
+// again on the next poll
+func (s *AlertStore) Restore(alert PriceAlert) error {
+	if s == nil {
+		return fmt.Errorf("alert store not initialized")
+	}
+
+	s.mu.Lock()
+	s.Alerts = append(s.Alerts, alert)
+	sort.Slice(s.Alerts, func(i, j int) bool { return s.Alerts[i].ID < s.Alerts[j].ID })
+	s.mu.Unlock()
+
+	return s.Save()
+}
+
+func (s *AlertStore) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.FilePath, b, 0o644)
+}
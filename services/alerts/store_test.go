@@ -0,0 +1,38 @@
+package alerts
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreKeepsTriggeredAlert(t *testing.T) {
+	if err := InitAlerts(filepath.Join(t.TempDir(), "alerts.json")); err != nil {
+		t.Fatal(err)
+	}
+	first, _ := Store.Add("owner", "BBCA", ">", 10000)
+	second, _ := Store.Add("owner", "BBCA", "<", 5000)
+
+	taken := Store.TakeTriggered("BBCA", 10500)
+	if len(taken) != 1 || taken[0].ID != first.ID {
+		t.Fatalf("TakeTriggered = %+v, want only alert #%d", taken, first.ID)
+	}
+	if got := Store.List("owner"); len(got) != 1 {
+		t.Fatalf("after take: %d alerts, want 1", len(got))
+	}
+
+	if err := Store.Restore(taken[0]); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	got := Store.List("owner")
+	if len(got) != 2 || got[0].ID != first.ID || got[1].ID != second.ID {
+		t.Fatalf("after restore: %+v, want alerts #%d and #%d in order", got, first.ID, second.ID)
+	}
+
+	// The restored alert is persisted and reloads with the same ID
+	if err := InitAlerts(Store.FilePath); err != nil {
+		t.Fatal(err)
+	}
+	if got := Store.List("owner"); len(got) != 2 || got[0].ID != first.ID {
+		t.Errorf("after reload: %+v", got)
+	}
+}
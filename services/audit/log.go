@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry is one executed command. Status is "ok", "error" (the command reported
+// a failure), "unknown" (no such command), "denied", "cooldown" or "panic".
+type Entry struct {
+	Time       string `json:"time"`
+	Sender     string `json:"sender"`
+	Chat       string `json:"chat"`
+	Command    string `json:"command"`
+	Status     string `json:"status"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AuditLog appends entries to a JSON Lines file. Once the file would grow past
+// MaxBytes it is rotated to path.1, path.1 to path.2 and so on, keeping at most
+// Backups old files.
+type AuditLog struct {
+	mu       sync.Mutex
+	FilePath string
+	MaxBytes int64
+	Backups  int
+}
+
+// Log is nil when AUDIT_LOG is unset; Append on a nil log is a no-op.
+var Log *AuditLog
+
+func InitAudit(filePath string, maxBytes int64, backups int) error {
+	if filePath == "" {
+		Log = nil
+		return nil
+	}
+
+	dir := filepath.Dir(filePath)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	Log = &AuditLog{FilePath: filePath, MaxBytes: maxBytes, Backups: backups}
+	return nil
+}
+
+func (l *AuditLog) Append(entry Entry) error {
+	if l == nil {
+		return nil
+	}
+	if entry.Time == "" {
+		entry.Time = time.Now().Format(time.RFC3339)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.MaxBytes > 0 {
+		if info, err := os.Stat(l.FilePath); err == nil && info.Size() > 0 && info.Size()+int64(len(line)) > l.MaxBytes {
+			if err := l.rotate(); err != nil {
+				return fmt.Errorf("rotate %s: %v", l.FilePath, err)
+			}
+		}
+	}
+
+	f, err := os.OpenFile(l.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// rotate shifts path.N-1 -> path.N ... path -> path.1, dropping the oldest
+// backup. With no backups configured the current file is simply truncated.
+func (l *AuditLog) rotate() error {
+	if l.Backups <= 0 {
+		return os.Truncate(l.FilePath, 0)
+	}
+
+	backup := func(n int) string { return fmt.Sprintf("%s.%d", l.FilePath, n) }
+
+	if err := os.Remove(backup(l.Backups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for n := l.Backups - 1; n >= 1; n-- {
+		if err := os.Rename(backup(n), backup(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(l.FilePath, backup(1))
+}
@@ -0,0 +1,68 @@
+package feedback
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Entry struct {
+	Chat      string `json:"chat"`
+	Sender    string `json:"sender"`
+	PushName  string `json:"push_name"`
+	Message   string `json:"message"`
+	CreatedAt string `json:"created_at"`
+}
+
+// FeedbackStore appends entries to a JSON Lines file, one entry per line, so the
+// file can be tailed or imported without loading everything into memory.
+type FeedbackStore struct {
+	mu       sync.Mutex
+	FilePath string
+}
+
+var Store *FeedbackStore
+
+func InitFeedback(filePath string) error {
+	if filePath == "" {
+		filePath = "feedback.jsonl"
+	}
+
+	dir := filepath.Dir(filePath)
+	if dir != "." && dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			Store = &FeedbackStore{FilePath: filePath}
+			return fmt.Errorf("failed to create %s: %v", dir, err)
+		}
+	}
+
+	Store = &FeedbackStore{FilePath: filePath}
+	return nil
+}
+
+func (s *FeedbackStore) Append(entry Entry) error {
+	if entry.CreatedAt == "" {
+		entry.CreatedAt = time.Now().Format(time.RFC3339)
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.FilePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
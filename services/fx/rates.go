@@ -0,0 +1,167 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+const cacheTTL = time.Hour
+
+var (
+	ErrUnknownCurrency = errors.New("unknown currency")
+	ErrInvalidAmount   = errors.New("invalid amount")
+)
+
+// ratesResponse is the subset of the open.er-api.com "latest" response we need.
+type ratesResponse struct {
+	Result    string             `json:"result"`
+	ErrorType string             `json:"error-type"`
+	BaseCode  string             `json:"base_code"`
+	Rates     map[string]float64 `json:"rates"`
+}
+
+type cachedRates struct {
+	rates     map[string]float64
+	fetchedAt time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cachedRates)
+)
+
+// getEndpoint returns FX_API_URL. "{base}" is replaced with the source currency.
+func getEndpoint() string {
+	return config.Get().FXAPIURL
+}
+
+// ParseAmount reads an amount such as "100", "2.5" or "2,5".
+func ParseAmount(raw string) (float64, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.Contains(raw, ",") && !strings.Contains(raw, ".") {
+		raw = strings.ReplaceAll(raw, ",", ".")
+	}
+	amount, err := strconv.ParseFloat(raw, 64)
+	if err != nil || amount < 0 || amount != amount {
+		return 0, ErrInvalidAmount
+	}
+	return amount, nil
+}
+
+// Convert converts amount from one currency to another using rates cached for
+// an hour per source currency. The returned rate is the price of one unit of from.
+func Convert(ctx context.Context, amount float64, from, to string) (result, rate float64, err error) {
+	from = strings.ToUpper(strings.TrimSpace(from))
+	to = strings.ToUpper(strings.TrimSpace(to))
+	if !isCurrencyCode(from) {
+		return 0, 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, from)
+	}
+	if !isCurrencyCode(to) {
+		return 0, 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+
+	rates, err := getRates(ctx, from)
+	if err != nil {
+		return 0, 0, err
+	}
+	rate, ok := rates[to]
+	if !ok {
+		return 0, 0, fmt.Errorf("%w: %s", ErrUnknownCurrency, to)
+	}
+	return amount * rate, rate, nil
+}
+
+func isCurrencyCode(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func getRates(ctx context.Context, base string) (map[string]float64, error) {
+	cacheMu.Lock()
+	if cached, ok := cache[base]; ok && time.Since(cached.fetchedAt) < cacheTTL {
+		cacheMu.Unlock()
+		return cached.rates, nil
+	}
+	cacheMu.Unlock()
+
+	reqURL := strings.ReplaceAll(getEndpoint(), "{base}", base)
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach exchange rate API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body ratesResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse exchange rates: %v", err)
+	}
+	// The API answers unsupported base codes with result "error".
+	if body.ErrorType == "unsupported-code" {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCurrency, base)
+	}
+	if resp.StatusCode != http.StatusOK || body.Result != "success" || len(body.Rates) == 0 {
+		return nil, fmt.Errorf("exchange rate API error (HTTP %d, %s)", resp.StatusCode, body.ErrorType)
+	}
+
+	cacheMu.Lock()
+	cache[base] = cachedRates{rates: body.Rates, fetchedAt: time.Now()}
+	cacheMu.Unlock()
+
+	return body.Rates, nil
+}
+
+// FormatAmount renders v in Indonesian notation, e.g. 1234567.5 -> "1.234.567,50".
+// Small values keep more decimals so rates like 0.000061 stay readable.
+func FormatAmount(v float64) string {
+	sign := ""
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+
+	prec := 2
+	if v != 0 && v < 1 {
+		prec = 6
+	}
+	fixed := strconv.FormatFloat(v, 'f', prec, 64)
+	intPart, fracPart := fixed, ""
+	if i := strings.IndexByte(fixed, '.'); i >= 0 {
+		intPart, fracPart = fixed[:i], strings.TrimRight(fixed[i+1:], "0")
+	}
+
+	var grouped strings.Builder
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(ch)
+	}
+
+	result := sign + grouped.String()
+	if fracPart != "" {
+		result += "," + fracPart
+	}
+	return result
+}
@@ -3,14 +3,18 @@ package gemini
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"whatsmeow-api/config"
 )
 
 type GeminiRequest struct {
@@ -60,6 +64,10 @@ type GeminiInlineData struct {
 	Data     string `json:"data"`
 }
 
+type GeminiVisionRequest struct {
+	Contents []GeminiImageContent `json:"contents"`
+}
+
 type GeminiExecutableCode struct {
 	Language string `json:"language"`
 	Code     string `json:"code"`
@@ -69,21 +77,25 @@ type GeminiClient struct {
 	APIKey       string
 	BaseURL      string
 	ImageBaseURL string
+	UserAgent    string
 	HTTPClient   *http.Client
 }
 
+const (
+	geminiModelsURL = "https://generativelanguage.googleapis.com/v1beta/models/"
+	geminiUserAgent = "whatsmeow-api-bot/1.0"
+)
+
 func NewGeminiClient() *GeminiClient {
-	apiKey := os.Getenv("API_KEY_GEMINI")
-	if apiKey == "" {
-		log.Println("warning: API_KEY_GEMINI environment variable not set")
-	}
+	cfg := config.Get()
 
 	return &GeminiClient{
-		APIKey:       apiKey,
-		BaseURL:      "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash:generateContent",
-		ImageBaseURL: "https://generativelanguage.googleapis.com/v1beta/models/gemini-2.5-flash-preview-image-generation:generateContent",
+		APIKey:       cfg.GeminiAPIKey,
+		BaseURL:      geminiModelsURL + cfg.GeminiModel + ":generateContent",
+		ImageBaseURL: geminiModelsURL + cfg.GeminiImageModel + ":generateContent",
+		UserAgent:    geminiUserAgent,
 		HTTPClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: cfg.GeminiTimeout,
 		},
 	}
 }
@@ -101,87 +113,64 @@ Jangan sebutkan bahwa kamu adalah AI atau bot kecuali ditanya secara spesifik.
 
 Pesan pengguna: `
 
-	fullPrompt := systemPrompt + message
-
-	requestData := GeminiRequest{
-		Contents: []GeminiContent{
-			{
-				Parts: []GeminiPart{
-					{Text: fullPrompt},
-				},
-			},
-		},
-	}
-
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %v", err)
-	}
-
-	url := fmt.Sprintf("%s?key=%s", c.BaseURL, c.APIKey)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
+	return c.GenerateRawResponse(ctx, systemPrompt+message)
+}
 
-	resp, err := c.HTTPClient.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
+func (c *GeminiClient) GenerateResponseWithName(ctx context.Context, assistantName string, message string) (string, error) {
+	return c.generateWithName(ctx, assistantName, message, replyLanguage(message))
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
+// generateWithName builds the assistant prompt for lang. It is separate from
+// GenerateResponseWithName so callers that wrap the message in extra context can
+// detect the language on the user's own words.
+func (c *GeminiClient) generateWithName(ctx context.Context, assistantName string, message string, lang string) (string, error) {
+	if c.APIKey == "" {
+		return "", fmt.Errorf("gemini API key not configured")
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini API error: %s (status: %d)", string(body), resp.StatusCode)
-	}
+	return c.GenerateRawResponse(ctx, assistantPrompt(assistantName, lang)+message)
+}
 
-	var geminiResp GeminiResponse
-	if err := json.Unmarshal(body, &geminiResp); err != nil {
-		return "", fmt.Errorf("failed to parse response: %v", err)
+// assistantPrompt returns the system prompt for assistantName, ending with the
+// "Pesan pengguna: " lead-in. A persona file's "{language}" placeholder is replaced
+// with the reply-language rule; persona files without it only get the English rule
+// appended.
+func assistantPrompt(assistantName string, lang string) string {
+	if strings.TrimSpace(assistantName) == "" {
+		assistantName = "Asisten"
 	}
 
-	if len(geminiResp.Candidates) == 0 {
-		return "", fmt.Errorf("no response from gemini")
+	languageRule := "Selalu jawab dalam bahasa Indonesia yang sopan dan mudah dipahami."
+	if lang == LangEnglish {
+		languageRule = "Pengguna menulis dalam bahasa Inggris, jadi jawab dalam bahasa Inggris yang sopan dan mudah dipahami."
 	}
 
-	if len(geminiResp.Candidates[0].Content.Parts) == 0 {
-		return "", fmt.Errorf("empty response from gemini")
+	if persona := loadPersonaPrompt(assistantName); persona != "" {
+		if strings.Contains(persona, "{language}") {
+			persona = strings.ReplaceAll(persona, "{language}", languageRule)
+		} else if lang == LangEnglish {
+			persona += "\n" + languageRule
+		}
+		return persona + "\n\nPesan pengguna: "
 	}
 
-	responseText := geminiResp.Candidates[0].Content.Parts[0].Text
-
-	responseText = strings.TrimSpace(responseText)
+	return fmt.Sprintf(`Kamu adalah %s, asisten pribadi yang cerdas, membantu, dan ramah. 
+Kamu dibuat untuk membantu pengguna dengan berbagai hal sehari-hari.
+%s
+Jika ditanya tentang identitasmu, katakan bahwa kamu adalah %s, asisten pribadi yang dibuat untuk membantu.
+Jangan sebutkan bahwa kamu adalah AI atau bot kecuali ditanya secara spesifik.
 
-	return responseText, nil
+Pesan pengguna: `, assistantName, languageRule, assistantName)
 }
 
-func (c *GeminiClient) GenerateResponseWithName(ctx context.Context, assistantName string, message string) (string, error) {
+// GenerateRawResponse sends the prompt as-is, without any assistant persona.
+func (c *GeminiClient) GenerateRawResponse(ctx context.Context, prompt string) (string, error) {
 	if c.APIKey == "" {
 		return "", fmt.Errorf("gemini API key not configured")
 	}
 
-	if strings.TrimSpace(assistantName) == "" {
-		assistantName = "Asisten"
-	}
-
-	systemPrompt := fmt.Sprintf(`Kamu adalah %s, asisten pribadi yang cerdas, membantu, dan ramah. 
-Kamu dibuat untuk membantu pengguna dengan berbagai hal sehari-hari.
-Selalu jawab dalam bahasa Indonesia yang sopan dan mudah dipahami.
-Jika ditanya tentang identitasmu, katakan bahwa kamu adalah %s, asisten pribadi yang dibuat untuk membantu.
-Jangan sebutkan bahwa kamu adalah AI atau bot kecuali ditanya secara spesifik.
-
-Pesan pengguna: `, assistantName, assistantName)
-
-	fullPrompt := systemPrompt + message
-
 	requestData := GeminiRequest{
-		Contents: []GeminiContent{{Parts: []GeminiPart{{Text: fullPrompt}}}},
+		Contents: []GeminiContent{{Parts: []GeminiPart{{Text: prompt}}}},
 	}
 
 	jsonData, err := json.Marshal(requestData)
@@ -190,24 +179,12 @@ Pesan pengguna: `, assistantName, assistantName)
 	}
 
 	url := fmt.Sprintf("%s?key=%s", c.BaseURL, c.APIKey)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create request: %v", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.HTTPClient.Do(req)
+	body, status, err := c.postWithRetry(ctx, url, jsonData)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response: %v", err)
+		return "", err
 	}
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("gemini API error: %s (status: %d)", string(body), resp.StatusCode)
+	if status != http.StatusOK {
+		return "", fmt.Errorf("gemini API error: %s (status: %d)", string(body), status)
 	}
 
 	var geminiResp GeminiResponse
@@ -222,6 +199,90 @@ Pesan pengguna: `, assistantName, assistantName)
 	return responseText, nil
 }
 
+const (
+	geminiMaxAttempts   = 3
+	geminiRetryBaseWait = 1 * time.Second
+	geminiRetryMaxWait  = 30 * time.Second
+)
+
+// isRetryableStatus reports whether a Gemini HTTP status is transient and worth retrying.
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfterDelay parses a Retry-After header (seconds or HTTP date), falling back to the given backoff.
+func retryAfterDelay(header string, fallback time.Duration) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return fallback
+	}
+
+	delay := fallback
+	if secs, err := strconv.Atoi(header); err == nil && secs >= 0 {
+		delay = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(header); err == nil {
+		delay = time.Until(t)
+		if delay < 0 {
+			delay = 0
+		}
+	}
+
+	if delay > geminiRetryMaxWait {
+		delay = geminiRetryMaxWait
+	}
+	return delay
+}
+
+// postWithRetry POSTs a JSON payload to Gemini, retrying transient 429/5xx responses with exponential backoff.
+// The body and status of the last attempt are returned; non-retryable statuses are returned immediately.
+func (c *GeminiClient) postWithRetry(ctx context.Context, url string, jsonData []byte) (body []byte, status int, err error) {
+	defer func() { recordCall(status, err) }()
+
+	backoff := geminiRetryBaseWait
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to create request: %v", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", c.UserAgent)
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to send request: %v", err)
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, resp.StatusCode, fmt.Errorf("failed to read response: %v", err)
+		}
+
+		if !isRetryableStatus(resp.StatusCode) || attempt >= geminiMaxAttempts {
+			return body, resp.StatusCode, nil
+		}
+
+		delay := retryAfterDelay(resp.Header.Get("Retry-After"), backoff)
+		log.Printf("[gemini] transient status %d (attempt %d/%d), retrying in %v", resp.StatusCode, attempt, geminiMaxAttempts, delay)
+
+		select {
+		case <-ctx.Done():
+			return nil, resp.StatusCode, ctx.Err()
+		case <-time.After(delay):
+		}
+		backoff *= 2
+	}
+}
+
 var geminiClient *GeminiClient
 
 func InitGemini() {
@@ -242,6 +303,94 @@ func GetGeminiResponseWithName(ctx context.Context, assistantName string, messag
 	return geminiClient.GenerateResponseWithName(ctx, assistantName, message)
 }
 
+func GetGeminiSummary(ctx context.Context, text string) (string, error) {
+	if geminiClient == nil {
+		InitGemini()
+	}
+
+	prompt := `Ringkas teks berikut dalam bahasa Indonesia secara singkat dan jelas.
+Gunakan maksimal 5 poin penting, tanpa menambahkan informasi yang tidak ada di teks.
+
+Teks:
+` + text
+
+	return geminiClient.GenerateRawResponse(ctx, prompt)
+}
+
+func GetGeminiDefinition(ctx context.Context, term string) (string, error) {
+	if geminiClient == nil {
+		InitGemini()
+	}
+
+	prompt := `Kamu adalah Kamus, kamus singkat berbahasa Indonesia.
+Berikan definisi istilah berikut dalam satu atau dua kalimat, lalu satu contoh kalimat.
+Jangan menambahkan penjelasan lain. Gunakan format persis seperti ini:
+Definisi: <definisi>
+Contoh: <contoh kalimat>
+
+Istilah: ` + term
+
+	return geminiClient.GenerateRawResponse(ctx, prompt)
+}
+
+// Sentiment is the parsed result of a sentiment classification. Label is one of
+// "positif", "netral" or "negatif"; Confidence is "tinggi", "sedang" or "rendah".
+type Sentiment struct {
+	Label      string
+	Confidence string
+	Reason     string
+}
+
+func GetGeminiSentiment(ctx context.Context, text string) (*Sentiment, error) {
+	if geminiClient == nil {
+		InitGemini()
+	}
+
+	prompt := `Klasifikasikan sentimen teks berikut sebagai positif, netral, atau negatif.
+Jawab hanya dengan tiga baris dalam format persis seperti ini, tanpa teks lain:
+Sentimen: <positif|netral|negatif>
+Keyakinan: <tinggi|sedang|rendah>
+Alasan: <satu kalimat singkat>
+
+Teks:
+` + text
+
+	raw, err := geminiClient.GenerateRawResponse(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	return parseSentiment(raw)
+}
+
+func parseSentiment(raw string) (*Sentiment, error) {
+	result := &Sentiment{}
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), "*")
+		switch strings.ToLower(strings.Trim(strings.TrimSpace(key), "*")) {
+		case "sentimen":
+			result.Label = strings.ToLower(value)
+		case "keyakinan":
+			result.Confidence = strings.ToLower(value)
+		case "alasan":
+			result.Reason = value
+		}
+	}
+
+	switch result.Label {
+	case "positif", "netral", "negatif":
+	default:
+		return nil, fmt.Errorf("unexpected sentiment response: %q", raw)
+	}
+	if result.Confidence == "" {
+		result.Confidence = "sedang"
+	}
+	return result, nil
+}
+
 func GetGeminiResponseWithMemory(ctx context.Context, chatJID string, assistantName string, userMessage string) (string, error) {
 	if geminiClient == nil {
 		InitGemini()
@@ -249,12 +398,17 @@ func GetGeminiResponseWithMemory(ctx context.Context, chatJID string, assistantN
 
 	var historyText string
 	if MemStore != nil {
-		history := MemStore.GetHistory(chatJID, assistantName, 6)
+		history := MemStore.GetHistory(chatJID, assistantName, MemStore.ContextTurns)
 		for _, m := range history {
 			if m.Role == "user" {
 				historyText += "Pengguna: " + m.Text + "\n"
 			} else if m.Role == "assistant" {
-				historyText += assistantName + ": " + m.Text + "\n"
+				// In shared memory the timeline mixes assistants, so attribute each turn.
+				speaker := m.Assistant
+				if speaker == "" {
+					speaker = assistantName
+				}
+				historyText += speaker + ": " + m.Text + "\n"
 			}
 		}
 	}
@@ -264,14 +418,14 @@ func GetGeminiResponseWithMemory(ctx context.Context, chatJID string, assistantN
 		combined = "Riwayat percakapan singkat (konteks):\n" + historyText + "\nPertanyaan baru pengguna: " + userMessage
 	}
 
-	reply, err := geminiClient.GenerateResponseWithName(ctx, assistantName, combined)
+	reply, err := geminiClient.generateWithName(ctx, assistantName, combined, replyLanguage(userMessage))
 	if err != nil {
 		return "", err
 	}
 
 	if MemStore != nil {
-		MemStore.AppendAndSave(chatJID, assistantName, "user", userMessage)
-		MemStore.AppendAndSave(chatJID, assistantName, "assistant", reply)
+		MemStore.Append(chatJID, assistantName, "user", userMessage)
+		MemStore.Append(chatJID, assistantName, "assistant", reply)
 	}
 
 	return reply, nil
@@ -305,35 +459,28 @@ func (c *GeminiClient) GenerateImage(ctx context.Context, prompt string) (string
 	}
 
 	url := fmt.Sprintf("%s?key=%s", c.ImageBaseURL, c.APIKey)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create image request: %v", err)
-	}
-
-	req.Header.Set("Content-Type", "application/json")
 
 	log.Printf("Sending image generation request to Gemini API...")
-	resp, err := c.HTTPClient.Do(req)
+	body, status, err := c.postWithRetry(ctx, url, jsonData)
 	if err != nil {
-		return "", fmt.Errorf("failed to send image request: %v", err)
+		return "", fmt.Errorf("image request: %v", err)
 	}
-	defer resp.Body.Close()
 
-	log.Printf("Gemini API response status: %d", resp.StatusCode)
+	log.Printf("Gemini API response status: %d", status)
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read image response: %v", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
+	if status != http.StatusOK {
 
-		if resp.StatusCode == 429 {
-			return "", fmt.Errorf("quota gemini habis atau rate limit tercapai. Silakan coba lagi nanti (status: %d)", resp.StatusCode)
+		if status == http.StatusTooManyRequests {
+			return "", fmt.Errorf("quota gemini habis atau rate limit tercapai. Silakan coba lagi nanti (status: %d)", status)
 		}
-		return "", fmt.Errorf("gemini image API error: %s (status: %d)", string(body), resp.StatusCode)
+		return "", fmt.Errorf("gemini image API error: %s (status: %d)", string(body), status)
 	}
 
+	return extractInlineImage(body)
+}
+
+// extractInlineImage returns the first base64 image in a Gemini response.
+func extractInlineImage(body []byte) (string, error) {
 	var response map[string]interface{}
 	if err := json.Unmarshal(body, &response); err != nil {
 		return "", fmt.Errorf("failed to parse image response: %v", err)
@@ -390,3 +537,131 @@ func GetGeminiImage(ctx context.Context, prompt string) (string, error) {
 	}
 	return geminiClient.GenerateImage(ctx, prompt)
 }
+
+// ErrImageEditUnsupported is returned when the configured image model can't
+// edit an input image.
+var ErrImageEditUnsupported = errors.New("image model does not support editing")
+
+// EditImage sends data together with an instruction to the image model and
+// returns the edited image as base64.
+func (c *GeminiClient) EditImage(ctx context.Context, data []byte, mimeType string, prompt string) (string, error) {
+	if c.APIKey == "" {
+		return "", fmt.Errorf("gemini API key not configured")
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("image data is empty")
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+
+	requestData := map[string]interface{}{
+		"contents": []map[string]interface{}{
+			{
+				"parts": []map[string]interface{}{
+					{"text": "Edit this image: " + prompt},
+					{"inlineData": map[string]string{"mimeType": mimeType, "data": base64.StdEncoding.EncodeToString(data)}},
+				},
+			},
+		},
+		"generationConfig": map[string]interface{}{
+			"responseModalities": []string{"TEXT", "IMAGE"},
+		},
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal image edit request: %v", err)
+	}
+
+	url := fmt.Sprintf("%s?key=%s", c.ImageBaseURL, c.APIKey)
+	body, status, err := c.postWithRetry(ctx, url, jsonData)
+	if err != nil {
+		return "", fmt.Errorf("image edit request: %v", err)
+	}
+
+	if status != http.StatusOK {
+		if status == http.StatusTooManyRequests {
+			return "", fmt.Errorf("quota gemini habis atau rate limit tercapai. Silakan coba lagi nanti (status: %d)", status)
+		}
+		// Models without image input or image output reject the request outright
+		lower := strings.ToLower(string(body))
+		if status == http.StatusBadRequest && (strings.Contains(lower, "not support") || strings.Contains(lower, "modalit")) {
+			return "", fmt.Errorf("%w: %s", ErrImageEditUnsupported, string(body))
+		}
+		return "", fmt.Errorf("gemini image edit API error: %s (status: %d)", string(body), status)
+	}
+
+	image, err := extractInlineImage(body)
+	if err != nil {
+		// A text-only answer means the model understood but can't return images
+		return "", fmt.Errorf("%w: %v", ErrImageEditUnsupported, err)
+	}
+	return image, nil
+}
+
+func GetGeminiImageEdit(ctx context.Context, data []byte, mimeType string, prompt string) (string, error) {
+	if geminiClient == nil {
+		InitGemini()
+	}
+	return geminiClient.EditImage(ctx, data, mimeType, prompt)
+}
+
+func (c *GeminiClient) DescribeImage(ctx context.Context, data []byte, mimeType string, prompt string) (string, error) {
+	if c.APIKey == "" {
+		return "", fmt.Errorf("gemini API key not configured")
+	}
+	if len(data) == 0 {
+		return "", fmt.Errorf("image data is empty")
+	}
+	if mimeType == "" {
+		mimeType = http.DetectContentType(data)
+	}
+	if strings.TrimSpace(prompt) == "" {
+		prompt = "Jelaskan isi gambar ini secara singkat dan jelas dalam bahasa Indonesia."
+	}
+
+	requestData := GeminiVisionRequest{
+		Contents: []GeminiImageContent{{
+			Parts: []GeminiImagePart{
+				{Text: prompt},
+				{InlineData: &GeminiInlineData{MimeType: mimeType, Data: base64.StdEncoding.EncodeToString(data)}},
+			},
+		}},
+	}
+
+	jsonData, err := json.Marshal(requestData)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal vision request: %v", err)
+	}
+
+	url := fmt.Sprintf("%s?key=%s", c.BaseURL, c.APIKey)
+	body, status, err := c.postWithRetry(ctx, url, jsonData)
+	if err != nil {
+		return "", fmt.Errorf("vision request: %v", err)
+	}
+	if status != http.StatusOK {
+		return "", fmt.Errorf("gemini vision API error: %s (status: %d)", string(body), status)
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return "", fmt.Errorf("failed to parse vision response: %v", err)
+	}
+	if len(geminiResp.Candidates) == 0 || len(geminiResp.Candidates[0].Content.Parts) == 0 {
+		return "", fmt.Errorf("empty response from gemini")
+	}
+
+	var sb strings.Builder
+	for _, part := range geminiResp.Candidates[0].Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
+
+func GetGeminiImageDescription(ctx context.Context, data []byte, mimeType string, prompt string) (string, error) {
+	if geminiClient == nil {
+		InitGemini()
+	}
+	return geminiClient.DescribeImage(ctx, data, mimeType, prompt)
+}
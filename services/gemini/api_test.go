@@ -0,0 +1,136 @@
+package gemini
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+func TestPostWithRetry(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		wantStatus   int
+		wantAttempts int32
+	}{
+		{"success", []int{200}, 200, 1},
+		{"transient then success", []int{503, 429, 200}, 200, 3},
+		{"client error is not retried", []int{400, 200}, 400, 1},
+		{"gives up after max attempts", []int{500, 502, 503, 200}, 503, geminiMaxAttempts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.statuses[n-1])
+				w.Write([]byte(`{}`))
+			}))
+			defer srv.Close()
+
+			c := &GeminiClient{HTTPClient: srv.Client()}
+			_, status, err := c.postWithRetry(context.Background(), srv.URL, []byte(`{}`))
+			if err != nil {
+				t.Fatalf("postWithRetry: %v", err)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+}
+
+func TestPostWithRetryStopsOnCancel(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "10")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	c := &GeminiClient{HTTPClient: srv.Client()}
+	if _, _, err := c.postWithRetry(ctx, srv.URL, []byte(`{}`)); err != context.DeadlineExceeded {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 2 * time.Second},
+		{"5", 5 * time.Second},
+		{"600", geminiRetryMaxWait},
+		{"soon", 2 * time.Second},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfterDelay(tt.header, 2*time.Second); got != tt.want {
+			t.Errorf("retryAfterDelay(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestAssistantPromptLanguageRule(t *testing.T) {
+	dir := t.TempDir()
+	persona := "Kamu adalah {name}.\n{language}\nJawab singkat."
+	if err := os.WriteFile(filepath.Join(dir, "fiq.txt"), []byte(persona), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := config.LoadConfig()
+	cfg.PromptsDir = dir
+	config.Set(cfg)
+
+	const indonesianOnly = "Selalu jawab dalam bahasa Indonesia"
+	for _, name := range []string{"Fiq", "Tamu"} {
+		en := assistantPrompt(name, LangEnglish)
+		if strings.Contains(en, indonesianOnly) {
+			t.Errorf("%s: English prompt still asks for Indonesian:\n%s", name, en)
+		}
+		if !strings.Contains(en, "bahasa Inggris") {
+			t.Errorf("%s: English prompt missing the English rule:\n%s", name, en)
+		}
+		if strings.Contains(en, "{language}") {
+			t.Errorf("%s: placeholder not substituted:\n%s", name, en)
+		}
+
+		id := assistantPrompt(name, LangIndonesian)
+		if !strings.Contains(id, indonesianOnly) {
+			t.Errorf("%s: Indonesian prompt missing the Indonesian rule:\n%s", name, id)
+		}
+	}
+}
+
+func TestBundledPersonasUseLanguagePlaceholder(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "prompts", "*.txt"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no bundled persona files found: %v", err)
+	}
+	for _, f := range files {
+		b, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(b), "{language}") {
+			t.Errorf("%s has no {language} placeholder", f)
+		}
+		if strings.Contains(string(b), "bahasa Indonesia") {
+			t.Errorf("%s hard-codes the reply language", f)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package gemini
+
+import (
+	"strings"
+	"unicode"
+
+	"whatsmeow-api/config"
+)
+
+const (
+	LangIndonesian = "id"
+	LangEnglish    = "en"
+)
+
+// Common function words that are distinctive for each language. Words shared by
+// both (e.g. "ok", "bro") are left out so they don't skew the count.
+var (
+	englishStopwords = wordSet(`the a an is are was were be been am i you he she it we they my your
+		his her its our their this that these those what which who whom why how when where
+		do does did have has had can could would should will shall may might must not no
+		and or but if then so because of to in on at for with from by about as into than
+		please thanks thank hello hi hey yes there here me him them us any some`)
+	indonesianStopwords = wordSet(`yang dan di ke dari ini itu adalah ada tidak bukan saya aku kamu
+		anda dia kami kita mereka apa siapa kenapa mengapa bagaimana gimana kapan dimana mana
+		untuk dengan pada dalam akan sudah belum bisa dapat harus juga atau tapi tetapi jika kalau
+		karena jadi sangat lebih sama tolong terima kasih halo hai ya tidak nggak gak enggak apakah
+		bagaimanakah dong deh sih kok nya lagi mau ingin buat bikin tentang`)
+)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// autoLanguageEnabled reports whether replies should follow the language of the
+// user's message (AI_AUTO_LANGUAGE=true). When off, replies are always Indonesian.
+func autoLanguageEnabled() bool {
+	return config.Get().AutoLanguage
+}
+
+// DetectLanguage guesses whether text is English or Indonesian by counting
+// stopwords. It only returns LangEnglish when English words clearly dominate;
+// anything short or ambiguous is treated as Indonesian.
+func DetectLanguage(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	})
+
+	var en, id int
+	for _, w := range words {
+		if englishStopwords[w] {
+			en++
+		}
+		if indonesianStopwords[w] {
+			id++
+		}
+	}
+
+	if en >= 2 && en > 2*id {
+		return LangEnglish
+	}
+	return LangIndonesian
+}
+
+// replyLanguage picks the language to answer userMessage in.
+func replyLanguage(userMessage string) string {
+	if !autoLanguageEnabled() {
+		return LangIndonesian
+	}
+	return DetectLanguage(userMessage)
+}
@@ -1,28 +1,64 @@
 package gemini
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
+
+	"whatsmeow-api/config"
 )
 
 type MemoryMessage struct {
 	Role      string `json:"role"`
 	Text      string `json:"text"`
 	Timestamp int64  `json:"timestamp"`
+	Assistant string `json:"assistant,omitempty"`
 }
 
 type MemoryStore struct {
-	mu         sync.RWMutex
-	FilePath   string
-	Data       map[string][]MemoryMessage
-	MaxPerChat int
+	mu           sync.RWMutex
+	FilePath     string
+	Data         map[string][]MemoryMessage
+	Personas     map[string]string
+	MaxPerChat   int
+	ContextTurns int
+	// Shared keeps one timeline per chat for all assistants instead of one
+	// per chat and assistant (MEMORY_SHARED).
+	Shared bool
+
+	// version counts changes; savedVersion is the version last written to
+	// disk, so the store is dirty while they differ.
+	version      uint64
+	savedVersion uint64
+	// saveMu keeps concurrent saves from replacing a newer file with an older
+	// snapshot.
+	saveMu sync.Mutex
+	// saveErr disables Save, set when a corrupt file could not be moved aside.
+	saveErr error
+}
+
+// memoryFile is the on-disk layout. Older files hold only the messages map at
+// the top level; those are still read and rewritten in this format on save.
+type memoryFile struct {
+	Messages map[string][]MemoryMessage `json:"messages"`
+	Personas map[string]string          `json:"personas,omitempty"`
 }
 
 var MemStore *MemoryStore
 
+// DefaultPersona is the assistant used by !fiq when a chat hasn't picked one.
+const DefaultPersona = "Fiq"
+
+// sharedAssistantKey replaces the assistant name in the key when memory is shared.
+const sharedAssistantKey = "*"
+
 func InitMemory(filePath string) error {
 	if filePath == "" {
 		filePath = "memory.json"
@@ -33,27 +69,152 @@ func InitMemory(filePath string) error {
 		_ = os.MkdirAll(dir, 0o755)
 	}
 
+	// LoadConfig already clamps MEMORY_CONTEXT_TURNS to MEMORY_MAX_PER_CHAT
+	maxPerChat := config.Get().MemoryMaxPerChat
+	contextTurns := config.Get().MemoryContextTurns
+
 	store := &MemoryStore{
-		FilePath:   filePath,
-		Data:       make(map[string][]MemoryMessage),
-		MaxPerChat: 50,
+		FilePath:     filePath,
+		Data:         make(map[string][]MemoryMessage),
+		Personas:     make(map[string]string),
+		MaxPerChat:   maxPerChat,
+		ContextTurns: contextTurns,
+		Shared:       config.Get().SharedMemory,
 	}
 
-	if _, err := os.Stat(filePath); err == nil {
-		b, err := os.ReadFile(filePath)
-		if err == nil && len(b) > 0 {
-			_ = json.Unmarshal(b, &store.Data)
+	if b, err := os.ReadFile(filePath); err == nil && len(bytes.TrimSpace(b)) > 0 {
+		file, err := parseMemoryFile(b)
+		if err != nil {
+			backup, bakErr := backupCorruptMemory(filePath)
+			if bakErr != nil {
+				// Keep the bad file where it is rather than overwrite it on the next save
+				store.saveErr = fmt.Errorf("not overwriting corrupt %s", filePath)
+				MemStore = store
+				return fmt.Errorf("memory file %s is corrupt (%v) and could not be backed up: %v", filePath, err, bakErr)
+			}
+			log.Printf("[memory] [Warning] %s is corrupt (%v); moved it to %s and starting with empty memory", filePath, err, backup)
+		} else {
+			store.Data = file.Messages
+			if file.Personas != nil {
+				store.Personas = file.Personas
+			}
 		}
 	}
 
+	if store.rekey() {
+		log.Printf("[memory] Converted stored history to %s mode", store.mode())
+		store.version++
+	}
+
 	MemStore = store
 	return nil
 }
 
+// parseMemoryFile decodes either the current layout or the older bare messages
+// map. Nothing is returned from a file that only partially decodes.
+func parseMemoryFile(b []byte) (memoryFile, error) {
+	var file memoryFile
+	err := json.Unmarshal(b, &file)
+	if err == nil && file.Messages != nil {
+		return file, nil
+	}
+
+	var legacy map[string][]MemoryMessage
+	if legacyErr := json.Unmarshal(b, &legacy); legacyErr != nil {
+		if err == nil {
+			err = legacyErr
+		}
+		return memoryFile{}, err
+	}
+	if legacy == nil {
+		return memoryFile{}, fmt.Errorf("no messages found")
+	}
+	return memoryFile{Messages: legacy}, nil
+}
+
+// backupCorruptMemory renames filePath to filePath.bak.<timestamp> and returns
+// the new name.
+func backupCorruptMemory(filePath string) (string, error) {
+	backup := fmt.Sprintf("%s.bak.%s", filePath, time.Now().Format("20060102-150405"))
+	if err := os.Rename(filePath, backup); err != nil {
+		return "", err
+	}
+	return backup, nil
+}
+
 func (s *MemoryStore) key(chatJID, assistantName string) string {
+	if s.Shared {
+		assistantName = sharedAssistantKey
+	}
 	return chatJID + "|" + assistantName
 }
 
+func (s *MemoryStore) mode() string {
+	if s.Shared {
+		return "shared"
+	}
+	return "per-assistant"
+}
+
+// rekey moves history saved under the other memory mode to the keys the current
+// mode uses, so toggling MEMORY_SHARED keeps existing conversations. Merged
+// timelines are ordered by timestamp, exact duplicates are dropped and the result
+// is trimmed to MaxPerChat. Messages saved before the assistant was recorded are
+// attributed to the assistant from their old key. It reports whether anything moved.
+func (s *MemoryStore) rekey() bool {
+	merged := make(map[string][]MemoryMessage)
+	for oldKey, msgs := range s.Data {
+		chat, assistant, ok := strings.Cut(oldKey, "|")
+		if !ok {
+			continue
+		}
+		for _, m := range msgs {
+			if m.Assistant == "" && assistant != sharedAssistantKey {
+				m.Assistant = assistant
+			}
+			newKey := s.key(chat, m.Assistant)
+			if m.Assistant == "" {
+				// No attribution to split on; keep it with the default assistant.
+				newKey = s.key(chat, DefaultPersona)
+			}
+			merged[newKey] = append(merged[newKey], m)
+		}
+	}
+
+	changed := len(merged) != len(s.Data)
+	for k, msgs := range merged {
+		if _, ok := s.Data[k]; !ok || len(s.Data[k]) != len(msgs) {
+			changed = true
+		}
+		merged[k] = s.tidy(msgs)
+	}
+	if !changed {
+		return false
+	}
+	s.Data = merged
+	return true
+}
+
+// tidy orders msgs by timestamp, drops exact duplicates and trims to MaxPerChat.
+func (s *MemoryStore) tidy(msgs []MemoryMessage) []MemoryMessage {
+	sort.SliceStable(msgs, func(i, j int) bool { return msgs[i].Timestamp < msgs[j].Timestamp })
+
+	out := msgs[:0]
+	seen := make(map[MemoryMessage]bool, len(msgs))
+	for _, m := range msgs {
+		if seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+
+	if s.MaxPerChat > 0 && len(out) > s.MaxPerChat {
+		out = out[len(out)-s.MaxPerChat:]
+	}
+	return out
+}
+
 func (s *MemoryStore) GetHistory(chatJID, assistantName string, limit int) []MemoryMessage {
 	if s == nil {
 		return nil
@@ -69,6 +230,18 @@ func (s *MemoryStore) GetHistory(chatJID, assistantName string, limit int) []Mem
 	return append([]MemoryMessage(nil), h[len(h)-limit:]...)
 }
 
+// KeyCount returns the number of chat/assistant conversations stored.
+func (s *MemoryStore) KeyCount() int {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.Data)
+}
+
+// Append adds a message to the chat's history; it is written to disk on the
+// next auto-save tick.
 func (s *MemoryStore) Append(chatJID, assistantName, role, text string) {
 	if s == nil {
 		return
@@ -77,29 +250,126 @@ func (s *MemoryStore) Append(chatJID, assistantName, role, text string) {
 	defer s.mu.Unlock()
 
 	key := s.key(chatJID, assistantName)
-	msg := MemoryMessage{Role: role, Text: text, Timestamp: time.Now().Unix()}
+	msg := MemoryMessage{Role: role, Text: text, Timestamp: time.Now().Unix(), Assistant: assistantName}
 	s.Data[key] = append(s.Data[key], msg)
+	s.version++
 	if s.MaxPerChat > 0 && len(s.Data[key]) > s.MaxPerChat {
 		over := len(s.Data[key]) - s.MaxPerChat
 		s.Data[key] = s.Data[key][over:]
 	}
 }
 
+// GetPersona returns the assistant persona selected for chatJID, or
+// DefaultPersona when none is set.
+func (s *MemoryStore) GetPersona(chatJID string) string {
+	if s == nil {
+		return DefaultPersona
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if persona := s.Personas[chatJID]; persona != "" {
+		return persona
+	}
+	return DefaultPersona
+}
+
+// SetPersona selects the assistant persona for chatJID. An empty name resets
+// the chat to DefaultPersona.
+func (s *MemoryStore) SetPersona(chatJID, persona string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if persona == "" || persona == DefaultPersona {
+		delete(s.Personas, chatJID)
+	} else {
+		s.Personas[chatJID] = persona
+	}
+	s.version++
+}
+
+// Save writes the store to disk immediately. The file is written to a
+// temporary name and renamed into place, so a failed write leaves the previous
+// file intact and the store still dirty.
 func (s *MemoryStore) Save() error {
 	if s == nil {
 		return nil
 	}
+	if s.saveErr != nil {
+		return s.saveErr
+	}
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
 	s.mu.RLock()
-	defer s.mu.RUnlock()
+	version := s.version
+	b, err := json.MarshalIndent(memoryFile{Messages: s.Data, Personas: s.Personas}, "", "  ")
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
 
-	b, err := json.MarshalIndent(s.Data, "", "  ")
+	if err := writeFileAtomic(s.FilePath, b); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.savedVersion = version
+	s.mu.Unlock()
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// over path.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(s.FilePath, b, 0o644)
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
 }
 
-func (s *MemoryStore) AppendAndSave(chatJID, assistantName, role, text string) {
-	s.Append(chatJID, assistantName, role, text)
-	_ = s.Save()
+// saveIfDirty flushes the store only when something was appended since the
+// last save.
+func (s *MemoryStore) saveIfDirty() error {
+	s.mu.RLock()
+	dirty := s.version != s.savedVersion
+	s.mu.RUnlock()
+	if !dirty {
+		return nil
+	}
+	return s.Save()
+}
+
+// StartAutoSave periodically flushes pending changes to disk. Call Save on
+// shutdown to persist anything appended since the last tick.
+func (s *MemoryStore) StartAutoSave(interval time.Duration) {
+	if s == nil || interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.saveIfDirty(); err != nil {
+				log.Printf("[memory] Auto-save failed: %v", err)
+			}
+		}
+	}()
 }
@@ -0,0 +1,95 @@
+package gemini
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInitMemoryBacksUpCorruptFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "memory.json")
+	corrupt := []byte(`{"messages": {"chat|Fiq": [{"role": "user", "text": "hal`)
+	if err := os.WriteFile(path, corrupt, 0o644); err != nil {
+		t.Fatalf("write memory file: %v", err)
+	}
+
+	if err := InitMemory(path); err != nil {
+		t.Fatalf("InitMemory: %v", err)
+	}
+	if n := MemStore.KeyCount(); n != 0 {
+		t.Errorf("store has %d keys, want an empty store", n)
+	}
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("corrupt file should have been moved away, stat err = %v", err)
+	}
+	backups, _ := filepath.Glob(path + ".bak.*")
+	if len(backups) != 1 {
+		t.Fatalf("found backups %v, want exactly one", backups)
+	}
+	if b, _ := os.ReadFile(backups[0]); string(b) != string(corrupt) {
+		t.Errorf("backup content = %q, want the original bytes", b)
+	}
+
+	MemStore.Append("chat", "Fiq", "user", "halo")
+	if err := MemStore.Save(); err != nil {
+		t.Fatalf("Save after recovery: %v", err)
+	}
+	if err := InitMemory(path); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if got := MemStore.GetHistory("chat", "Fiq", 10); len(got) != 1 || got[0].Text != "halo" {
+		t.Errorf("reloaded history = %+v, want the saved message", got)
+	}
+}
+
+func TestInitMemoryReadsLegacyLayout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memory.json")
+	legacy := `{"chat|Fiq": [{"role": "user", "text": "lama", "timestamp": 1}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o644); err != nil {
+		t.Fatalf("write memory file: %v", err)
+	}
+
+	if err := InitMemory(path); err != nil {
+		t.Fatalf("InitMemory: %v", err)
+	}
+	if got := MemStore.GetHistory("chat", "Fiq", 10); len(got) != 1 || got[0].Text != "lama" {
+		t.Errorf("history = %+v, want the legacy message", got)
+	}
+	if backups, _ := filepath.Glob(path + ".bak.*"); len(backups) != 0 {
+		t.Errorf("a valid legacy file should not be backed up, found %v", backups)
+	}
+}
+
+func TestSaveKeepsStoreDirtyOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "memory.json")
+	if err := InitMemory(path); err != nil {
+		t.Fatalf("InitMemory: %v", err)
+	}
+	store := MemStore
+	store.Append("chat", "Fiq", "user", "halo")
+
+	store.FilePath = filepath.Join(dir, "missing", "memory.json")
+	if err := store.Save(); err == nil {
+		t.Fatal("Save into a missing directory should fail")
+	}
+	if store.version == store.savedVersion {
+		t.Error("a failed save must leave the store dirty")
+	}
+
+	store.FilePath = path
+	if err := store.saveIfDirty(); err != nil {
+		t.Fatalf("saveIfDirty: %v", err)
+	}
+	if store.version != store.savedVersion {
+		t.Error("a successful save should clear the dirty state")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("memory file not written: %v", err)
+	}
+	if tmps, _ := filepath.Glob(path + ".tmp*"); len(tmps) != 0 {
+		t.Errorf("temporary files left behind: %v", tmps)
+	}
+}
@@ -0,0 +1,25 @@
+package gemini
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+var (
+	callsTotal  atomic.Int64
+	callsFailed atomic.Int64
+)
+
+// recordCall counts one Gemini API call, including its retries. Calls that
+// errored or ended on a non-200 status count as failed.
+func recordCall(status int, err error) {
+	callsTotal.Add(1)
+	if err != nil || status != http.StatusOK {
+		callsFailed.Add(1)
+	}
+}
+
+// CallStats returns how many Gemini API calls were made and how many failed.
+func CallStats() (total, failed int64) {
+	return callsTotal.Load(), callsFailed.Load()
+}
@@ -0,0 +1,90 @@
+package gemini
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+type cachedPrompt struct {
+	text    string
+	modTime time.Time
+}
+
+var (
+	promptCacheMu sync.Mutex
+	promptCache   = make(map[string]cachedPrompt)
+)
+
+func getPromptsDir() string {
+	return config.Get().PromptsDir
+}
+
+// promptKey maps an assistant name such as "Fiq" or "!apik" to its file name ("fiq", "apik")
+func promptKey(assistantName string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(assistantName) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// loadPersonaPrompt returns the persona prompt from <PROMPTS_DIR>/<assistant>.txt, or ""
+// when no file exists. The file is re-read only when its modification time changes.
+// A "{name}" placeholder in the file is replaced with the assistant name; a
+// "{language}" placeholder is left for the caller to fill with the reply-language rule.
+func loadPersonaPrompt(assistantName string) string {
+	key := promptKey(assistantName)
+	if key == "" {
+		return ""
+	}
+
+	path := filepath.Join(getPromptsDir(), key+".txt")
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+
+	promptCacheMu.Lock()
+	defer promptCacheMu.Unlock()
+
+	if cached, ok := promptCache[path]; ok && cached.modTime.Equal(info.ModTime()) {
+		return strings.ReplaceAll(cached.text, "{name}", assistantName)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("[gemini] Failed to read persona prompt %s: %v", path, err)
+		return ""
+	}
+
+	text := strings.TrimSpace(string(b))
+	promptCache[path] = cachedPrompt{text: text, modTime: info.ModTime()}
+	log.Printf("[gemini] Loaded persona prompt for %s from %s", assistantName, path)
+
+	return strings.ReplaceAll(text, "{name}", assistantName)
+}
+
+// ListPersonas returns the assistant names that have a prompt file in PROMPTS_DIR,
+// sorted alphabetically.
+func ListPersonas() []string {
+	matches, err := filepath.Glob(filepath.Join(getPromptsDir(), "*.txt"))
+	if err != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(matches))
+	for _, m := range matches {
+		names = append(names, strings.TrimSuffix(filepath.Base(m), ".txt"))
+	}
+	sort.Strings(names)
+	return names
+}
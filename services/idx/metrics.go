@@ -0,0 +1,26 @@
+package idx
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var (
+	scrapeCount    atomic.Int64
+	scrapeFailures atomic.Int64
+	scrapeNanos    atomic.Int64
+)
+
+func recordScrape(d time.Duration, err error) {
+	scrapeCount.Add(1)
+	scrapeNanos.Add(int64(d))
+	if err != nil {
+		scrapeFailures.Add(1)
+	}
+}
+
+// ScrapeStats returns how many IDX market data scrapes ran, how many failed
+// and how long they took in total.
+func ScrapeStats() (count, failures int64, total time.Duration) {
+	return scrapeCount.Load(), scrapeFailures.Load(), time.Duration(scrapeNanos.Load())
+}
@@ -0,0 +1,225 @@
+package idx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	moversCacheTTL = 5 * time.Minute
+	// moversLookbackDays is how far back to look for the last trading day when
+	// today has no data (weekend, holiday or before the open)
+	moversLookbackDays = 7
+)
+
+// StockMover is one stock's daily price change from the IDX trading summary
+type StockMover struct {
+	Code      string  `json:"code"`
+	Name      string  `json:"name"`
+	Previous  float64 `json:"previous"`
+	Close     float64 `json:"close"`
+	Change    float64 `json:"change"`
+	ChangePct float64 `json:"change_pct"`
+}
+
+// Movers holds the biggest gainers and losers of a trading day. MarketClosed is
+// set when the data is from an earlier day because today had no trading yet.
+type Movers struct {
+	Date         time.Time
+	MarketClosed bool
+	Gainers      []StockMover
+	Losers       []StockMover
+}
+
+type idxStockSummary struct {
+	Data []struct {
+		StockCode string  `json:"StockCode"`
+		StockName string  `json:"StockName"`
+		Previous  float64 `json:"Previous"`
+		Close     float64 `json:"Close"`
+		Change    float64 `json:"Change"`
+		Volume    float64 `json:"Volume"`
+	} `json:"data"`
+}
+
+var (
+	moversMu       sync.Mutex
+	moversCache    *Movers
+	moversCachedAt time.Time
+)
+
+// GetTopMovers returns the top limit gainers and losers for the latest trading
+// day, sorted by percentage change. Results are cached for a few minutes.
+func GetTopMovers(ctx context.Context, limit int) (*Movers, error) {
+	moversMu.Lock()
+	defer moversMu.Unlock()
+
+	if moversCache != nil && time.Since(moversCachedAt) < moversCacheTTL {
+		return trimMovers(moversCache, limit), nil
+	}
+
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*3600)
+	}
+	today := time.Now().In(loc)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	for back := 0; back < moversLookbackDays; back++ {
+		day := today.AddDate(0, 0, -back)
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+
+		stocks, err := fetchStockSummary(ctx, client, day)
+		if err != nil {
+			return nil, err
+		}
+		if len(stocks) == 0 {
+			log.Printf("[IDX] No trading summary for %s, trying the previous day", day.Format("2006-01-02"))
+			continue
+		}
+
+		movers := buildMovers(stocks)
+		movers.Date = day
+		movers.MarketClosed = back > 0
+		moversCache = movers
+		moversCachedAt = time.Now()
+		return trimMovers(movers, limit), nil
+	}
+
+	return nil, fmt.Errorf("no trading data in the last %d days", moversLookbackDays)
+}
+
+func fetchStockSummary(ctx context.Context, client *http.Client, day time.Time) ([]StockMover, error) {
+	url := "https://www.idx.co.id/primary/TradingSummary/GetStockSummary?length=9999&start=0&date=" + day.Format("20060102")
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range defaultFetchHeaders {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Referer", "https://www.idx.co.id/id/data-pasar/ringkasan-perdagangan/ringkasan-saham/")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned HTTP %d", url, resp.StatusCode)
+	}
+
+	body, err := decodeResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	var summary idxStockSummary
+	if err := json.NewDecoder(body).Decode(&summary); err != nil {
+		return nil, fmt.Errorf("failed to parse stock summary: %v", err)
+	}
+
+	stocks := make([]StockMover, 0, len(summary.Data))
+	for _, row := range summary.Data {
+		// Untraded stocks carry the previous close forward; they aren't movers
+		if row.Previous <= 0 || row.Close <= 0 || row.Volume <= 0 {
+			continue
+		}
+		change := row.Close - row.Previous
+		stocks = append(stocks, StockMover{
+			Code:      strings.TrimSpace(row.StockCode),
+			Name:      strings.TrimSpace(row.StockName),
+			Previous:  row.Previous,
+			Close:     row.Close,
+			Change:    change,
+			ChangePct: change / row.Previous * 100,
+		})
+	}
+	return stocks, nil
+}
+
+func buildMovers(stocks []StockMover) *Movers {
+	gainers := make([]StockMover, 0, len(stocks))
+	losers := make([]StockMover, 0, len(stocks))
+	for _, s := range stocks {
+		if s.Change > 0 {
+			gainers = append(gainers, s)
+		} else if s.Change < 0 {
+			losers = append(losers, s)
+		}
+	}
+
+	sort.Slice(gainers, func(i, j int) bool { return gainers[i].ChangePct > gainers[j].ChangePct })
+	sort.Slice(losers, func(i, j int) bool { return losers[i].ChangePct < losers[j].ChangePct })
+
+	return &Movers{Gainers: gainers, Losers: losers}
+}
+
+func trimMovers(m *Movers, limit int) *Movers {
+	out := *m
+	if limit > 0 && len(out.Gainers) > limit {
+		out.Gainers = out.Gainers[:limit]
+	}
+	if limit > 0 && len(out.Losers) > limit {
+		out.Losers = out.Losers[:limit]
+	}
+	return &out
+}
+
+// FormatMovers renders the gainers and/or losers list for WhatsApp
+func FormatMovers(m *Movers, showGainers, showLosers bool) string {
+	var sb strings.Builder
+
+	title := "Top Movers"
+	if showGainers && !showLosers {
+		title = "Top Gainers"
+	} else if showLosers && !showGainers {
+		title = "Top Losers"
+	}
+	fmt.Fprintf(&sb, "[IDX %s] %s\n", title, m.Date.Format("02 Jan 2006"))
+	if m.MarketClosed {
+		sb.WriteString("_Pasar belum dibuka atau sedang libur hari ini, menampilkan data hari perdagangan terakhir._\n")
+	}
+
+	writeList := func(label string, list []StockMover) {
+		fmt.Fprintf(&sb, "\n*%s*\n", label)
+		if len(list) == 0 {
+			sb.WriteString("Tidak ada data\n")
+			return
+		}
+		for i, s := range list {
+			fmt.Fprintf(&sb, "%d. %s  %s  %s (%s%%)\n", i+1, s.Code, strconv.FormatFloat(s.Close, 'f', -1, 64), formatSigned(s.Change, -1), formatSigned(s.ChangePct, 2))
+		}
+	}
+
+	if showGainers {
+		writeList("Gainers", m.Gainers)
+	}
+	if showLosers {
+		writeList("Losers", m.Losers)
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func formatSigned(v float64, prec int) string {
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if v > 0 {
+		s = "+" + s
+	}
+	return s
+}
@@ -0,0 +1,143 @@
+package idx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"whatsmeow-api/config"
+)
+
+const (
+	newsCacheTTL = 10 * time.Minute
+	// newsMinTitleLen filters out navigation links ("Market", "Lihat semua")
+	// that the fallback selectors also match.
+	newsMinTitleLen = 20
+)
+
+// newsSelectors are tried in order when NEWS_SELECTOR is unset. They go from the
+// markup most news sites use for article lists to plain headings, so a redesign
+// of the source degrades to fewer or noisier headlines instead of none.
+var newsSelectors = []string{
+	"article h2 a[href]",
+	"article h3 a[href]",
+	"article a[href]",
+	"h2 a[href]",
+	"h3 a[href]",
+}
+
+var ErrNoHeadlines = errors.New("no headlines found")
+
+// Headline is one news article title and its absolute link.
+type Headline struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+var (
+	newsMu       sync.Mutex
+	newsCache    []Headline
+	newsCacheSrc string
+	newsCachedAt time.Time
+)
+
+// GetMarketNews returns up to limit headlines from NEWS_URL. Results are cached for
+// a few minutes per source. The page is fetched with fetchDoc, so transient errors
+// are retried.
+func GetMarketNews(ctx context.Context, limit int) ([]Headline, error) {
+	source := config.Get().NewsURL
+	if source == "" {
+		return nil, errors.New("NEWS_URL is not configured")
+	}
+
+	newsMu.Lock()
+	defer newsMu.Unlock()
+
+	if newsCache != nil && newsCacheSrc == source && time.Since(newsCachedAt) < newsCacheTTL {
+		return trimHeadlines(newsCache, limit), nil
+	}
+
+	base, err := url.Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NEWS_URL: %v", err)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	doc, err := fetchDoc(ctx, client, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	headlines := parseHeadlines(doc, base, config.Get().NewsSelector)
+	if len(headlines) == 0 {
+		return nil, ErrNoHeadlines
+	}
+
+	newsCache = headlines
+	newsCacheSrc = source
+	newsCachedAt = time.Now()
+	return trimHeadlines(headlines, limit), nil
+}
+
+// parseHeadlines collects article links using selector, or the first of
+// newsSelectors that yields anything. Links are resolved against base and
+// de-duplicated.
+func parseHeadlines(doc *goquery.Document, base *url.URL, selector string) []Headline {
+	selectors := newsSelectors
+	if selector != "" {
+		selectors = []string{selector}
+	}
+
+	for _, sel := range selectors {
+		var headlines []Headline
+		seen := make(map[string]bool)
+		doc.Find(sel).Each(func(i int, a *goquery.Selection) {
+			title := strings.Join(strings.Fields(a.Text()), " ")
+			if title == "" {
+				title = strings.TrimSpace(a.AttrOr("title", ""))
+			}
+			href, ok := a.Attr("href")
+			if !ok || utf8.RuneCountInString(title) < newsMinTitleLen {
+				return
+			}
+			link, err := base.Parse(strings.TrimSpace(href))
+			if err != nil || (link.Scheme != "http" && link.Scheme != "https") {
+				return
+			}
+			if seen[link.String()] {
+				return
+			}
+			seen[link.String()] = true
+			headlines = append(headlines, Headline{Title: title, URL: link.String()})
+		})
+		if len(headlines) > 0 {
+			return headlines
+		}
+	}
+	return nil
+}
+
+func trimHeadlines(h []Headline, limit int) []Headline {
+	if limit <= 0 || len(h) <= limit {
+		return append([]Headline(nil), h...)
+	}
+	return append([]Headline(nil), h[:limit]...)
+}
+
+// FormatNews renders headlines as a numbered WhatsApp message.
+func FormatNews(headlines []Headline) string {
+	var sb strings.Builder
+	sb.WriteString("[Berita Pasar]\n")
+	for i, h := range headlines {
+		sb.WriteString(fmt.Sprintf("\n%d. *%s*\n%s\n", i+1, h.Title, h.URL))
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
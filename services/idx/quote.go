@@ -0,0 +1,67 @@
+package idx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// yahooChartResponse is the subset of the Yahoo Finance chart API we need
+type yahooChartResponse struct {
+	Chart struct {
+		Result []struct {
+			Meta struct {
+				Symbol             string  `json:"symbol"`
+				Currency           string  `json:"currency"`
+				RegularMarketPrice float64 `json:"regularMarketPrice"`
+				PreviousClose      float64 `json:"chartPreviousClose"`
+			} `json:"meta"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+// GetStockPrice returns the latest traded price (in Rupiah) for an IDX ticker such as "BBCA"
+func GetStockPrice(ctx context.Context, code string) (float64, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return 0, fmt.Errorf("empty stock code")
+	}
+
+	url := fmt.Sprintf("https://query1.finance.yahoo.com/v8/finance/chart/%s.JK?interval=1d&range=1d", code)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var chart yahooChartResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chart); err != nil {
+		return 0, fmt.Errorf("failed to parse quote for %s: %v", code, err)
+	}
+
+	if chart.Chart.Error != nil {
+		return 0, fmt.Errorf("quote for %s: %s", code, chart.Chart.Error.Description)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("quote for %s returned HTTP %d", code, resp.StatusCode)
+	}
+	if len(chart.Chart.Result) == 0 || chart.Chart.Result[0].Meta.RegularMarketPrice <= 0 {
+		return 0, fmt.Errorf("no price data for %s", code)
+	}
+
+	return chart.Chart.Result[0].Meta.RegularMarketPrice, nil
+}
@@ -1,7 +1,10 @@
 package idx
 
 import (
+	"bufio"
+	"compress/flate"
 	"compress/gzip"
+	"compress/zlib"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -11,11 +14,13 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"whatsmeow-api/domain"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/brotli"
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
 )
@@ -26,8 +31,23 @@ type idxNuxtItem struct {
 	Date string `json:"date"`
 }
 
+const (
+	chromedpSourceTimeout = 150 * time.Second
+	httpSourceTimeout     = 90 * time.Second
+	// chromedpPageTimeout bounds a single page load, so a hung candidate URL
+	// still leaves time for the fallbacks within chromedpSourceTimeout
+	chromedpPageTimeout = 45 * time.Second
+)
+
 // GetIDXMarketData is the main entry point to fetch all market data for a target date
 func GetIDXMarketData(targetDate time.Time) (*domain.IDXData, error) {
+	start := time.Now()
+	data, err := getIDXMarketData(targetDate)
+	recordScrape(time.Since(start), err)
+	return data, err
+}
+
+func getIDXMarketData(targetDate time.Time) (*domain.IDXData, error) {
 	if targetDate.IsZero() {
 		targetDate = time.Now()
 	}
@@ -49,28 +69,128 @@ func GetIDXMarketData(targetDate time.Time) (*domain.IDXData, error) {
 
 	client := &http.Client{Timeout: 30 * time.Second}
 
-	// Fetch everything in sequence
-	if uma, err := scrapeUMAData(targetDate); err == nil {
-		data.UMA = uma
+	// Fetch every source concurrently; each one has its own timeout so a slow
+	// source only loses its own section instead of delaying the whole report
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+	run := func(name string, timeout time.Duration, scrape func(ctx context.Context) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			start := time.Now()
+			if err := scrape(ctx); err != nil {
+				log.Printf("[IDX] %s scrape failed after %v: %v", name, time.Since(start).Round(time.Millisecond), err)
+				return
+			}
+			log.Printf("[IDX] %s scrape finished in %v", name, time.Since(start).Round(time.Millisecond))
+		}()
 	}
-	if susp, unsusp, err := scrapeSuspensiData(targetDate); err == nil {
+
+	run("UMA", chromedpSourceTimeout, func(ctx context.Context) error {
+		uma, err := scrapeUMAData(ctx, targetDate)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		data.UMA = uma
+		mu.Unlock()
+		return nil
+	})
+	run("Suspensi", chromedpSourceTimeout, func(ctx context.Context) error {
+		susp, unsusp, err := scrapeSuspensiData(ctx, targetDate)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
 		data.Suspensi = susp
 		data.Unsuspensi = unsusp
-	}
-	if rups, err := scrapeRUPSData(client, targetDate); err == nil {
+		mu.Unlock()
+		return nil
+	})
+	run("RUPS", httpSourceTimeout, func(ctx context.Context) error {
+		rups, err := scrapeRUPSData(ctx, client, targetDate)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
 		data.RUPS = rups
-	}
-	if dividend, err := scrapeDividendData(client, targetDate); err == nil {
+		mu.Unlock()
+		return nil
+	})
+	run("Dividend", httpSourceTimeout, func(ctx context.Context) error {
+		dividend, err := scrapeDividendData(ctx, client, targetDate)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
 		data.Dividend = dividend
-	}
+		mu.Unlock()
+		return nil
+	})
+
+	wg.Wait()
 
 	return data, nil
 }
 
 // --- Scraper Implementations ---
 
-func scrapeUMAData(targetDate time.Time) ([]string, error) {
-	items, err := scrapeIDXWithChromedp("https://www.idx.co.id/id/berita/unusual-market-activity-uma", "", "")
+// Candidate pages for the chromedp sources, tried in order. idx.co.id moves pages
+// around and sometimes redirects by region, so the bare domain and the English
+// page are kept as fallbacks.
+var (
+	umaURLs = []string{
+		"https://www.idx.co.id/id/berita/unusual-market-activity-uma",
+		"https://idx.co.id/id/berita/unusual-market-activity-uma",
+		"https://www.idx.co.id/en/news/unusual-market-activity-uma",
+	}
+	suspensiURLs = []string{
+		"https://www.idx.co.id/id/berita/suspensi",
+		"https://idx.co.id/id/berita/suspensi",
+		"https://www.idx.co.id/en/news/suspension",
+	}
+)
+
+// scrapeIDXCandidates scrapes each URL in turn and returns the items of the first
+// page that yields any. It only fails when every candidate failed or was empty.
+func scrapeIDXCandidates(ctx context.Context, name string, urls []string) ([]idxNuxtItem, error) {
+	var lastErr error
+	for i, pageURL := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+
+		items, err := scrapeIDXWithChromedp(ctx, pageURL, "", "")
+		if err != nil {
+			lastErr = err
+			log.Printf("[IDX] %s candidate %d/%d failed (%s): %v", name, i+1, len(urls), pageURL, err)
+			continue
+		}
+		if len(items) == 0 {
+			log.Printf("[IDX] %s candidate %d/%d returned no items (%s)", name, i+1, len(urls), pageURL)
+			continue
+		}
+
+		log.Printf("[IDX] %s scraped %d items from %s", name, len(items), pageURL)
+		return items, nil
+	}
+
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
+	if lastErr == nil {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("all %d %s URLs failed: %w", len(urls), name, lastErr)
+}
+
+func scrapeUMAData(ctx context.Context, targetDate time.Time) ([]string, error) {
+	items, err := scrapeIDXCandidates(ctx, "UMA", umaURLs)
 	if err != nil {
 		return nil, err
 	}
@@ -87,41 +207,72 @@ func scrapeUMAData(targetDate time.Time) ([]string, error) {
 	return results, nil
 }
 
-func scrapeSuspensiData(targetDate time.Time) ([]string, []string, error) {
-	items, err := scrapeIDXWithChromedp("https://www.idx.co.id/id/berita/suspensi", "", "")
+func scrapeSuspensiData(ctx context.Context, targetDate time.Time) ([]string, []string, error) {
+	items, err := scrapeIDXCandidates(ctx, "Suspensi", suspensiURLs)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	parenRe := regexp.MustCompile(`\(([A-Z]{2,6})\)`)
-	var suspensi, unsuspensi []string
+	suspensi, unsuspensi := splitSuspensiItems(items, targetDate)
+	return suspensi, unsuspensi, nil
+}
+
+var (
+	suspensiCodeRe = regexp.MustCompile(`\(([A-Z]{2,6})\)`)
+
+	suspendKeywords   = []string{"penghentian sementara", "suspensi", "suspend"}
+	unsuspendKeywords = []string{"pembukaan kembali", "pencabutan", "dibuka", "unsuspend", "batal"}
+)
+
+// classifySuspensiText reports whether an announcement on the suspensi page is a
+// suspension or an un-suspension. Un-suspension wins because its titles usually
+// also mention "suspensi" (e.g. "Pencabutan Penghentian Sementara").
+func classifySuspensiText(text string) (suspend, unsuspend bool) {
+	low := strings.ToLower(text)
+	for _, kw := range unsuspendKeywords {
+		if strings.Contains(low, kw) {
+			return false, true
+		}
+	}
+	for _, kw := range suspendKeywords {
+		if strings.Contains(low, kw) {
+			return true, false
+		}
+	}
+	return false, false
+}
+
+// splitSuspensiItems separates the target date's announcements into suspended and
+// un-suspended stock codes. An announcement may list several codes.
+func splitSuspensiItems(items []idxNuxtItem, targetDate time.Time) (suspensi, unsuspensi []string) {
+	seenS := make(map[string]bool)
+	seenU := make(map[string]bool)
 
 	for _, item := range items {
 		if !isTargetDateImproved(item.Date, targetDate) || item.Text == "" {
 			continue
 		}
 
-		low := strings.ToLower(item.Text)
-		isS := strings.Contains(low, "penghentian sementara") || strings.Contains(low, "suspensi")
-		isU := strings.Contains(low, "pembukaan kembali") || strings.Contains(low, "pencabutan") || strings.Contains(low, "dibuka")
-
+		isS, isU := classifySuspensiText(item.Text)
 		if !isS && !isU {
 			continue
 		}
 
-		if m := parenRe.FindStringSubmatch(item.Text); len(m) > 1 {
+		for _, m := range suspensiCodeRe.FindAllStringSubmatch(item.Text, -1) {
 			code := m[1]
-			if isU {
+			if isU && !seenU[code] {
 				unsuspensi = append(unsuspensi, code)
-			} else {
+				seenU[code] = true
+			} else if isS && !seenS[code] {
 				suspensi = append(suspensi, code)
+				seenS[code] = true
 			}
 		}
 	}
-	return suspensi, unsuspensi, nil
+	return suspensi, unsuspensi
 }
 
-func scrapeRUPSData(client *http.Client, targetDate time.Time) ([]string, error) {
+func scrapeRUPSData(ctx context.Context, client *http.Client, targetDate time.Time) ([]string, error) {
 	var results []string
 	seen := make(map[string]bool)
 
@@ -132,28 +283,24 @@ func scrapeRUPSData(client *http.Client, targetDate time.Time) ([]string, error)
 			url = fmt.Sprintf("https://www.new.sahamidx.com/?/rups/page/%d", p)
 		}
 
-		doc, err := fetchGoQuery(client, url)
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		doc, err := fetchDoc(ctx, client, url, nil)
 		if err != nil {
 			log.Printf("[RUPS] Error fetching page %d: %v", p, err)
 			continue
 		}
 
 		foundOnPage := false
-		doc.Find("table tbody tr").Each(func(i int, row *goquery.Selection) {
-			cells := row.Find("td")
-			if cells.Length() >= 6 {
-				code := strings.TrimSpace(cells.Eq(1).Text())
-				date := strings.TrimSpace(cells.Eq(2).Text())
-				if code != "" && isTargetDateImproved(date, targetDate) {
-					uCode := strings.ToUpper(code)
-					if !seen[uCode] {
-						results = append(results, uCode)
-						seen[uCode] = true
-						foundOnPage = true
-					}
-				}
+		for _, code := range parseRUPSDocument(doc, targetDate) {
+			if !seen[code] {
+				results = append(results, code)
+				seen[code] = true
+				foundOnPage = true
 			}
-		})
+		}
 
 		// If we haven't found anything yet across several pages, we keep looking
 		// But if we FOUND something and now we don't, we might have passed the date block
@@ -164,7 +311,7 @@ func scrapeRUPSData(client *http.Client, targetDate time.Time) ([]string, error)
 	return results, nil
 }
 
-func scrapeDividendData(client *http.Client, targetDate time.Time) ([]domain.DividendData, error) {
+func scrapeDividendData(ctx context.Context, client *http.Client, targetDate time.Time) ([]domain.DividendData, error) {
 	var results []domain.DividendData
 	seen := make(map[string]bool)
 
@@ -174,41 +321,74 @@ func scrapeDividendData(client *http.Client, targetDate time.Time) ([]domain.Div
 			url = fmt.Sprintf("https://www.new.sahamidx.com/?/deviden/page/%d", p)
 		}
 
-		doc, err := fetchGoQuery(client, url)
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		doc, err := fetchDoc(ctx, client, url, nil)
 		if err != nil {
 			log.Printf("[Dividend] Error fetching page %d: %v", p, err)
 			continue
 		}
 
-		doc.Find("table tbody tr").Each(func(i int, row *goquery.Selection) {
-			cells := row.Find("td")
-			if cells.Length() >= 6 {
-				code := strings.TrimSpace(cells.Eq(0).Text())
-				amt := strings.TrimSpace(cells.Eq(1).Text())
-				cum := strings.TrimSpace(cells.Eq(2).Text())
-				ex := strings.TrimSpace(cells.Eq(3).Text())
-
-				if code != "" && code != "Deviden Saham" {
-					if isTargetDateImproved(cum, targetDate) || isTargetDateImproved(ex, targetDate) {
-						uCode := strings.ToUpper(code)
-						if !seen[uCode] {
-							results = append(results, domain.DividendData{
-								Code: uCode, Amount: amt, CumDate: cum, ExDate: ex,
-								Yield: "N/A", Price: "N/A",
-							})
-							seen[uCode] = true
-						}
-					}
-				}
+		for _, d := range parseDividendDocument(doc, targetDate) {
+			if !seen[d.Code] {
+				results = append(results, d)
+				seen[d.Code] = true
 			}
-		})
+		}
 	}
 	return results, nil
 }
 
+// parseRUPSDocument extracts the stock codes with a RUPS on targetDate from a
+// sahamidx RUPS listing page. Kept separate from the fetch so it can run on saved HTML.
+func parseRUPSDocument(doc *goquery.Document, targetDate time.Time) []string {
+	var codes []string
+	doc.Find("table tbody tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		if cells.Length() < 6 {
+			return
+		}
+		code := strings.TrimSpace(cells.Eq(1).Text())
+		date := strings.TrimSpace(cells.Eq(2).Text())
+		if code != "" && isTargetDateImproved(date, targetDate) {
+			codes = append(codes, strings.ToUpper(code))
+		}
+	})
+	return codes
+}
+
+// parseDividendDocument extracts dividends whose cum or ex date is targetDate from
+// a sahamidx dividend listing page.
+func parseDividendDocument(doc *goquery.Document, targetDate time.Time) []domain.DividendData {
+	var dividends []domain.DividendData
+	doc.Find("table tbody tr").Each(func(i int, row *goquery.Selection) {
+		cells := row.Find("td")
+		if cells.Length() < 6 {
+			return
+		}
+		code := strings.TrimSpace(cells.Eq(0).Text())
+		amt := strings.TrimSpace(cells.Eq(1).Text())
+		cum := strings.TrimSpace(cells.Eq(2).Text())
+		ex := strings.TrimSpace(cells.Eq(3).Text())
+
+		if code == "" || code == "Deviden Saham" {
+			return
+		}
+		if isTargetDateImproved(cum, targetDate) || isTargetDateImproved(ex, targetDate) {
+			dividends = append(dividends, domain.DividendData{
+				Code: strings.ToUpper(code), Amount: amt, CumDate: cum, ExDate: ex,
+				Yield: "N/A", Price: "N/A",
+			})
+		}
+	})
+	return dividends
+}
+
 // --- Headless Browser Logic ---
 
-func scrapeIDXWithChromedp(pageURL, _, _ string) ([]idxNuxtItem, error) {
+func scrapeIDXWithChromedp(parent context.Context, pageURL, _, _ string) ([]idxNuxtItem, error) {
 	js := `
 (function() {
 	var best = null; var max = 0;
@@ -242,11 +422,11 @@ func scrapeIDXWithChromedp(pageURL, _, _ string) ([]idxNuxtItem, error) {
 		chromedp.UserAgent("Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/122.0.0.0 Safari/537.36"),
 	)
 
-	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background(), opts...)
+	allocCtx, allocCancel := chromedp.NewExecAllocator(parent, opts...)
 	defer allocCancel()
 	ctx, cancel := chromedp.NewContext(allocCtx)
 	defer cancel()
-	ctx, tcancel := context.WithTimeout(ctx, 50*time.Second)
+	ctx, tcancel := context.WithTimeout(ctx, chromedpPageTimeout)
 	defer tcancel()
 
 	// Hide webdriver attribute
@@ -272,22 +452,112 @@ func scrapeIDXWithChromedp(pageURL, _, _ string) ([]idxNuxtItem, error) {
 	return items, nil
 }
 
-func fetchGoQuery(client *http.Client, url string) (*goquery.Document, error) {
-	req, _ := http.NewRequest("GET", url, nil)
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36")
+const (
+	fetchDocAttempts    = 3
+	fetchDocBaseBackoff = time.Second
+)
+
+var defaultFetchHeaders = map[string]string{
+	"User-Agent":      "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36",
+	"Accept-Encoding": "gzip, deflate, br",
+}
+
+// fetchDoc GETs url and parses it with goquery. Network errors and 5xx responses
+// are retried with exponential backoff (1s, 2s, ...); other statuses fail immediately.
+// headers are applied on top of defaultFetchHeaders. The client's timeout applies
+// per attempt.
+func fetchDoc(ctx context.Context, client *http.Client, url string, headers map[string]string) (*goquery.Document, error) {
+	var lastErr error
+	for attempt := 0; attempt < fetchDocAttempts; attempt++ {
+		if attempt > 0 {
+			delay := fetchDocBaseBackoff << (attempt - 1)
+			log.Printf("[IDX] Retrying %s in %s (attempt %d/%d): %v", url, delay, attempt+1, fetchDocAttempts, lastErr)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+
+		doc, retryable, err := fetchDocOnce(ctx, client, url, headers)
+		if err == nil {
+			return doc, nil
+		}
+		lastErr = err
+		if !retryable || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func fetchDocOnce(ctx context.Context, client *http.Client, url string, headers map[string]string) (*goquery.Document, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	for k, v := range defaultFetchHeaders {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, true, err
 	}
 	defer resp.Body.Close()
 
-	var r io.Reader = resp.Body
-	if strings.Contains(resp.Header.Get("Content-Encoding"), "gzip") {
-		gz, _ := gzip.NewReader(resp.Body)
-		defer gz.Close()
-		r = gz
+	if resp.StatusCode >= 500 {
+		return nil, true, fmt.Errorf("GET %s returned HTTP %d", url, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("GET %s returned HTTP %d", url, resp.StatusCode)
+	}
+
+	body, err := decodeResponseBody(resp)
+	if err != nil {
+		return nil, false, err
+	}
+	defer body.Close()
+
+	doc, err := goquery.NewDocumentFromReader(body)
+	if err != nil {
+		// A truncated body usually means the connection dropped mid-read
+		return nil, true, err
+	}
+	return doc, false, nil
+}
+
+// decodeResponseBody wraps the response body in the decompressor matching its
+// Content-Encoding (gzip, deflate or br). Unknown or missing encodings are passed through.
+func decodeResponseBody(resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch {
+	case strings.Contains(encoding, "gzip"):
+		gz, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("gzip reader: %v", err)
+		}
+		return gz, nil
+	case strings.Contains(encoding, "br"):
+		return io.NopCloser(brotli.NewReader(resp.Body)), nil
+	case strings.Contains(encoding, "deflate"):
+		// Servers disagree on whether "deflate" means zlib-wrapped or raw DEFLATE
+		buffered := bufio.NewReader(resp.Body)
+		if header, err := buffered.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(buffered)
+			if err != nil {
+				return nil, fmt.Errorf("zlib reader: %v", err)
+			}
+			return zr, nil
+		}
+		return flate.NewReader(buffered), nil
+	default:
+		return io.NopCloser(resp.Body), nil
 	}
-	return goquery.NewDocumentFromReader(r)
 }
 
 // --- Utilities ---
@@ -351,6 +621,101 @@ func isTargetDateImproved(dateStr string, targetDate time.Time) bool {
 	return false
 }
 
+// parseIDRAmount parses a raw amount string where '.' or ',' may be either a
+// thousands or decimal separator (e.g. "4.2", "1,000.50", "1.000,50", "1.000")
+func parseIDRAmount(raw string) (float64, bool) {
+	val := strings.TrimSpace(raw)
+	val = strings.TrimPrefix(strings.TrimPrefix(val, "Rp"), "IDR")
+	val = strings.ReplaceAll(strings.TrimSpace(val), " ", "")
+	if val == "" || strings.EqualFold(val, "N/A") || val == "-" {
+		return 0, false
+	}
+
+	lastDot := strings.LastIndex(val, ".")
+	lastComma := strings.LastIndex(val, ",")
+
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// Whichever separator comes last is the decimal separator
+		if lastComma > lastDot {
+			val = strings.ReplaceAll(val, ".", "")
+			val = strings.Replace(val, ",", ".", 1)
+		} else {
+			val = strings.ReplaceAll(val, ",", "")
+		}
+	case lastComma >= 0:
+		val = normalizeSingleSeparator(val, ",")
+	case lastDot >= 0:
+		val = normalizeSingleSeparator(val, ".")
+	}
+
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
+// normalizeSingleSeparator treats sep as a thousands separator when every group after it
+// has exactly three digits (or it appears more than once), otherwise as a decimal point
+func normalizeSingleSeparator(val, sep string) string {
+	groups := strings.Split(val, sep)
+	thousands := len(groups) > 2
+	if len(groups) == 2 && len(groups[1]) == 3 && groups[0] != "0" && groups[0] != "" {
+		thousands = true
+	}
+	if thousands {
+		return strings.Join(groups, "")
+	}
+	return groups[0] + "." + groups[1]
+}
+
+// ParseRupiah parses a user-entered amount such as "10.000", "10.5" or "1,000.50",
+// accepting both Indonesian and English separators
+func ParseRupiah(raw string) (float64, bool) {
+	return parseIDRAmount(raw)
+}
+
+// FormatRupiah renders a raw amount in Indonesian notation, e.g. "1,000.50" -> "Rp 1.000,50".
+// Values that cannot be parsed are returned as-is, and empty values become "N/A"
+func FormatRupiah(raw string) string {
+	amount, ok := parseIDRAmount(raw)
+	if !ok {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return "N/A"
+		}
+		return raw
+	}
+	return FormatRupiahAmount(amount)
+}
+
+// FormatRupiahAmount renders amount in Indonesian notation, e.g. 1000.5 -> "Rp 1.000,50"
+func FormatRupiahAmount(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	fixed := strconv.FormatFloat(amount, 'f', 2, 64)
+	intPart, fracPart := fixed[:len(fixed)-3], fixed[len(fixed)-2:]
+
+	var grouped strings.Builder
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			grouped.WriteByte('.')
+		}
+		grouped.WriteRune(ch)
+	}
+
+	result := "Rp " + sign + grouped.String()
+	if fracPart != "00" {
+		result += "," + fracPart
+	}
+	return result
+}
+
 func FormatIDXResponse(data *domain.IDXData) string {
 	var sb strings.Builder
 	sb.WriteString(fmt.Sprintf("[IDX Market Data for %s]\n\n", data.Date))
@@ -376,7 +741,7 @@ func FormatIDXResponse(data *domain.IDXData) string {
 		sb.WriteString("-\n")
 	} else {
 		for _, d := range data.Dividend {
-			sb.WriteString(fmt.Sprintf("%s (Div. Rp %s)\n", d.Code, d.Amount))
+			sb.WriteString(fmt.Sprintf("%s (Div. %s)\n", d.Code, FormatRupiah(d.Amount)))
 			if d.CumDate != "" && d.CumDate != "N/A" {
 				sb.WriteString("Cum: " + d.CumDate + "\n")
 			}
@@ -0,0 +1,129 @@
+package idx
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"whatsmeow-api/domain"
+)
+
+var testTargetDate = time.Date(2026, time.October, 15, 0, 0, 0, 0, time.FixedZone("WIB", 7*3600))
+
+func loadFixture(t *testing.T, name string) *goquery.Document {
+	t.Helper()
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		t.Fatalf("open fixture: %v", err)
+	}
+	defer f.Close()
+	doc, err := goquery.NewDocumentFromReader(f)
+	if err != nil {
+		t.Fatalf("parse fixture: %v", err)
+	}
+	return doc
+}
+
+func TestParseRUPSDocument(t *testing.T) {
+	got := parseRUPSDocument(loadFixture(t, "rups.html"), testTargetDate)
+	want := []string{"BBCA", "TLKM"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseRUPSDocument() = %v, want %v", got, want)
+	}
+}
+
+func TestParseDividendDocument(t *testing.T) {
+	got := parseDividendDocument(loadFixture(t, "dividend.html"), testTargetDate)
+	want := []domain.DividendData{
+		{Code: "BBRI", Amount: "Rp 135", CumDate: "15 Oktober 2026", ExDate: "16 Oktober 2026", Yield: "N/A", Price: "N/A"},
+		{Code: "ADRO", Amount: "Rp 250,5", CumDate: "14 Oktober 2026", ExDate: "15 Oktober 2026", Yield: "N/A", Price: "N/A"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseDividendDocument() =\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestSplitSuspensiItems(t *testing.T) {
+	b, err := os.ReadFile("testdata/suspensi.json")
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	var items []idxNuxtItem
+	if err := json.Unmarshal(b, &items); err != nil {
+		t.Fatalf("decode fixture: %v", err)
+	}
+
+	suspensi, unsuspensi := splitSuspensiItems(items, testTargetDate)
+
+	if want := []string{"ABCD", "QRST", "UVWX"}; !reflect.DeepEqual(suspensi, want) {
+		t.Errorf("suspensi = %v, want %v", suspensi, want)
+	}
+	if want := []string{"EFGH", "IJKL", "MNOP"}; !reflect.DeepEqual(unsuspensi, want) {
+		t.Errorf("unsuspensi = %v, want %v", unsuspensi, want)
+	}
+}
+
+func TestClassifySuspensiText(t *testing.T) {
+	tests := []struct {
+		text                   string
+		wantSuspend, wantUnsus bool
+	}{
+		{"Penghentian Sementara Perdagangan Saham (ABCD)", true, false},
+		{"Suspensi Saham (ABCD)", true, false},
+		{"Pencabutan Penghentian Sementara Perdagangan Saham (ABCD)", false, true},
+		{"Pembukaan Kembali Perdagangan Saham (ABCD)", false, true},
+		{"Pengumuman RUPS (ABCD)", false, false},
+	}
+	for _, tt := range tests {
+		suspend, unsuspend := classifySuspensiText(tt.text)
+		if suspend != tt.wantSuspend || unsuspend != tt.wantUnsus {
+			t.Errorf("classifySuspensiText(%q) = %t, %t; want %t, %t", tt.text, suspend, unsuspend, tt.wantSuspend, tt.wantUnsus)
+		}
+	}
+}
+
+func TestParseRupiah(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want float64
+		ok   bool
+	}{
+		{"10000", 10000, true},
+		{"10.000", 10000, true},
+		{"10,000", 10000, true},
+		{"10.5", 10.5, true},
+		{"10,5", 10.5, true},
+		{"1,000.50", 1000.5, true},
+		{"1.000,50", 1000.5, true},
+		{"", 0, false},
+		{"abc", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRupiah(tt.raw)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRupiah(%q) = %v, %t; want %v, %t", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestFormatRupiahAmount(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{10000, "Rp 10.000"},
+		{10.5, "Rp 10,50"},
+		{1000.5, "Rp 1.000,50"},
+		{1234.567, "Rp 1.234,57"},
+		{-2500, "Rp -2.500"},
+	}
+	for _, tt := range tests {
+		if got := FormatRupiahAmount(tt.amount); got != tt.want {
+			t.Errorf("FormatRupiahAmount(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package media
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+// downloaderResponse is the JSON shape accepted from MEDIA_DOWNLOADER_URL when it does
+// not stream the media directly
+type downloaderResponse struct {
+	URL         string  `json:"url"`
+	DownloadURL string  `json:"download_url"`
+	Title       string  `json:"title"`
+	Duration    float64 `json:"duration"`
+	Error       string  `json:"error"`
+}
+
+type Video struct {
+	Data     []byte
+	Title    string
+	MimeType string
+}
+
+func MaxVideoBytes() int {
+	return config.Get().MediaMaxBytes
+}
+
+func MaxDurationSeconds() int {
+	return config.Get().MediaMaxDuration
+}
+
+func allowedHosts() []string {
+	return config.Get().MediaAllowedHosts
+}
+
+// ValidateURL checks that rawURL is http(s) and its host (or a parent domain) is allowlisted
+func ValidateURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL: %s", rawURL)
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range allowedHosts() {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return u, nil
+		}
+	}
+	return nil, fmt.Errorf("host %s is not allowed", host)
+}
+
+// DownloadVideo asks the configured downloader service for the media behind sourceURL.
+// The service may either stream the video directly or answer with JSON containing a
+// direct "url"/"download_url" (and optionally "duration" in seconds and "title").
+func DownloadVideo(ctx context.Context, sourceURL string) (*Video, error) {
+	endpoint := config.Get().MediaDownloaderURL
+	if endpoint == "" {
+		return nil, fmt.Errorf("media downloader not configured")
+	}
+
+	if _, err := ValidateURL(sourceURL); err != nil {
+		return nil, err
+	}
+
+	reqURL := endpoint
+	if strings.Contains(reqURL, "?") {
+		reqURL += "&url=" + url.QueryEscape(sourceURL)
+	} else {
+		reqURL += "?url=" + url.QueryEscape(sourceURL)
+	}
+
+	client := &http.Client{Timeout: 120 * time.Second}
+	resp, err := doGet(ctx, client, reqURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	video := &Video{MimeType: "video/mp4"}
+
+	if strings.Contains(resp.Header.Get("Content-Type"), "application/json") {
+		var info downloaderResponse
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&info); err != nil {
+			return nil, fmt.Errorf("failed to parse downloader response: %v", err)
+		}
+		if info.Error != "" {
+			return nil, fmt.Errorf("downloader error: %s", info.Error)
+		}
+		if info.Duration > 0 && int(info.Duration) > MaxDurationSeconds() {
+			return nil, fmt.Errorf("video too long: %.0fs (max %ds)", info.Duration, MaxDurationSeconds())
+		}
+
+		mediaURL := info.DownloadURL
+		if mediaURL == "" {
+			mediaURL = info.URL
+		}
+		if mediaURL == "" {
+			return nil, fmt.Errorf("downloader returned no media URL")
+		}
+		video.Title = info.Title
+
+		mediaResp, err := doGet(ctx, client, mediaURL)
+		if err != nil {
+			return nil, err
+		}
+		defer mediaResp.Body.Close()
+		resp = mediaResp
+	}
+
+	if ct := resp.Header.Get("Content-Type"); strings.HasPrefix(ct, "video/") {
+		video.MimeType = strings.TrimSpace(strings.Split(ct, ";")[0])
+	}
+
+	maxBytes := MaxVideoBytes()
+	if resp.ContentLength > int64(maxBytes) {
+		return nil, fmt.Errorf("video too large: %d bytes (max %d MB)", resp.ContentLength, maxBytes/(1024*1024))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media: %v", err)
+	}
+	if len(data) > maxBytes {
+		return nil, fmt.Errorf("video too large (max %d MB)", maxBytes/(1024*1024))
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("downloaded media is empty")
+	}
+
+	video.Data = data
+	return video, nil
+}
+
+func doGet(ctx context.Context, client *http.Client, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("GET %s: %v", req.URL.Host, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s returned HTTP %d", req.URL.Host, resp.StatusCode)
+	}
+	return resp, nil
+}
@@ -0,0 +1,169 @@
+package prayer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+// Times holds the daily prayer schedule for a city, in local time (HH:MM).
+type Times struct {
+	City    string
+	Date    string
+	Imsak   string
+	Subuh   string
+	Terbit  string
+	Dzuhur  string
+	Ashar   string
+	Maghrib string
+	Isya    string
+}
+
+// aladhanResponse is the subset of the Aladhan timingsByCity API we need
+type aladhanResponse struct {
+	Code int             `json:"code"`
+	Data json.RawMessage `json:"data"`
+}
+
+type aladhanData struct {
+	Timings struct {
+		Imsak   string `json:"Imsak"`
+		Fajr    string `json:"Fajr"`
+		Sunrise string `json:"Sunrise"`
+		Dhuhr   string `json:"Dhuhr"`
+		Asr     string `json:"Asr"`
+		Maghrib string `json:"Maghrib"`
+		Isha    string `json:"Isha"`
+	} `json:"timings"`
+	Date struct {
+		Readable string `json:"readable"`
+	} `json:"date"`
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]*Times)
+)
+
+func getEndpoint() string {
+	return config.Get().PrayerAPIURL
+}
+
+// DefaultCity returns PRAYER_DEFAULT_CITY, falling back to Jakarta.
+func DefaultCity() string {
+	return config.Get().PrayerDefaultCity
+}
+
+// cleanTime strips the timezone suffix Aladhan appends, e.g. "04:35 (WIB)".
+func cleanTime(t string) string {
+	if idx := strings.Index(t, " "); idx >= 0 {
+		return t[:idx]
+	}
+	return t
+}
+
+// GetTimes returns today's prayer times for city. Results are cached per city
+// per day, so repeated requests don't hit the API.
+func GetTimes(ctx context.Context, city string) (*Times, error) {
+	city = strings.TrimSpace(city)
+	if city == "" {
+		city = DefaultCity()
+	}
+
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		loc = time.FixedZone("WIB", 7*60*60)
+	}
+	today := time.Now().In(loc).Format("02-01-2006")
+	key := strings.ToLower(city) + "|" + today
+
+	cacheMu.Lock()
+	if cached, ok := cache[key]; ok {
+		cacheMu.Unlock()
+		return cached, nil
+	}
+	cacheMu.Unlock()
+
+	params := url.Values{}
+	params.Set("city", city)
+	params.Set("country", "Indonesia")
+	// Method 20 is the Kementerian Agama (KEMENAG) calculation
+	params.Set("method", "20")
+
+	reqURL := fmt.Sprintf("%s/%s?%s", strings.TrimRight(getEndpoint(), "/"), today, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach prayer times API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body aladhanResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse prayer times: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Code != http.StatusOK {
+		return nil, fmt.Errorf("prayer times for %s not found (HTTP %d)", city, resp.StatusCode)
+	}
+
+	var data aladhanData
+	if err := json.Unmarshal(body.Data, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse prayer times: %v", err)
+	}
+	if data.Timings.Fajr == "" {
+		return nil, fmt.Errorf("prayer times for %s not found", city)
+	}
+
+	times := &Times{
+		City:    city,
+		Date:    data.Date.Readable,
+		Imsak:   cleanTime(data.Timings.Imsak),
+		Subuh:   cleanTime(data.Timings.Fajr),
+		Terbit:  cleanTime(data.Timings.Sunrise),
+		Dzuhur:  cleanTime(data.Timings.Dhuhr),
+		Ashar:   cleanTime(data.Timings.Asr),
+		Maghrib: cleanTime(data.Timings.Maghrib),
+		Isya:    cleanTime(data.Timings.Isha),
+	}
+
+	cacheMu.Lock()
+	for k := range cache {
+		if !strings.HasSuffix(k, "|"+today) {
+			delete(cache, k)
+		}
+	}
+	cache[key] = times
+	cacheMu.Unlock()
+
+	return times, nil
+}
+
+// FormatTimes renders the schedule as a WhatsApp message.
+func FormatTimes(t *Times) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[Jadwal Sholat] *%s*\n", t.City)
+	if t.Date != "" {
+		fmt.Fprintf(&sb, "%s\n", t.Date)
+	}
+	sb.WriteString("\n")
+	fmt.Fprintf(&sb, "Imsak   : %s\n", t.Imsak)
+	fmt.Fprintf(&sb, "Subuh   : %s\n", t.Subuh)
+	fmt.Fprintf(&sb, "Terbit  : %s\n", t.Terbit)
+	fmt.Fprintf(&sb, "Dzuhur  : %s\n", t.Dzuhur)
+	fmt.Fprintf(&sb, "Ashar   : %s\n", t.Ashar)
+	fmt.Fprintf(&sb, "Maghrib : %s\n", t.Maghrib)
+	fmt.Fprintf(&sb, "Isya    : %s", t.Isya)
+	return sb.String()
+}
@@ -0,0 +1,243 @@
+package schedule
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	KindDaily  = "daily"
+	KindWeekly = "weekly"
+	// KindOnce fires a single time at RunAt and is then removed
+	KindOnce = "once"
+)
+
+type Schedule struct {
+	ID        int    `json:"id"`
+	Chat      string `json:"chat"`
+	Kind      string `json:"kind"`
+	Weekday   int    `json:"weekday"`
+	Hour      int    `json:"hour"`
+	Minute    int    `json:"minute"`
+	Message   string `json:"message"`
+	CreatedBy string `json:"created_by"`
+	CreatedAt int64  `json:"created_at"`
+	// LastRun is the local date (YYYY-MM-DD) the schedule last fired, so a
+	// restart within the same minute doesn't send it twice
+	LastRun string `json:"last_run"`
+	// RunAt is the Unix time a KindOnce schedule fires
+	RunAt int64 `json:"run_at,omitempty"`
+	// Topic, when set, sends the message to the topic's subscribers instead of Chat.
+	// Chat is still the chat that created it, so it can be listed and cancelled there.
+	Topic string `json:"topic,omitempty"`
+}
+
+// Due reports whether the schedule should fire at now (already in local time)
+func (s Schedule) Due(now time.Time) bool {
+	if s.Kind == KindOnce {
+		// No window: a one-time message missed while offline is sent late rather than lost
+		return s.LastRun == "" && now.Unix() >= s.RunAt
+	}
+	if s.LastRun == now.Format("2006-01-02") {
+		return false
+	}
+	if s.Kind == KindWeekly && int(now.Weekday()) != s.Weekday {
+		return false
+	}
+	fireAt := time.Date(now.Year(), now.Month(), now.Day(), s.Hour, s.Minute, 0, 0, now.Location())
+	// Fire within a 5 minute window so a missed tick still delivers
+	return !now.Before(fireAt) && now.Sub(fireAt) < 5*time.Minute
+}
+
+type ScheduleStore struct {
+	mu        sync.Mutex
+	FilePath  string     `json:"-"`
+	NextID    int        `json:"next_id"`
+	Schedules []Schedule `json:"schedules"`
+}
+
+var Store *ScheduleStore
+
+func InitSchedules(filePath string) error {
+	if filePath == "" {
+		filePath = "schedules.json"
+	}
+
+	dir := filepath.Dir(filePath)
+	if dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+
+	store := &ScheduleStore{
+		FilePath:  filePath,
+		NextID:    1,
+		Schedules: []Schedule{},
+	}
+
+	if b, err := os.ReadFile(filePath); err == nil && len(b) > 0 {
+		if err := json.Unmarshal(b, store); err != nil {
+			Store = store
+			return fmt.Errorf("failed to parse %s: %v", filePath, err)
+		}
+		if store.NextID < 1 {
+			store.NextID = 1
+		}
+	}
+
+	Store = store
+	return nil
+}
+
+func (s *ScheduleStore) Add(sched Schedule) (Schedule, error) {
+	if s == nil {
+		return Schedule{}, fmt.Errorf("schedule store not initialized")
+	}
+	if sched.Kind != KindDaily && sched.Kind != KindWeekly && sched.Kind != KindOnce {
+		return Schedule{}, fmt.Errorf("unsupported schedule kind %q", sched.Kind)
+	}
+	if sched.Kind == KindOnce && sched.RunAt <= 0 {
+		return Schedule{}, fmt.Errorf("one-time schedule needs a run time")
+	}
+
+	s.mu.Lock()
+	sched.ID = s.NextID
+	sched.CreatedAt = time.Now().Unix()
+	s.NextID++
+	s.Schedules = append(s.Schedules, sched)
+	s.mu.Unlock()
+
+	if err := s.Save(); err != nil {
+		// Don't leave a schedule live that the caller was told failed to save
+		s.mu.Lock()
+		s.Schedules = removeSchedule(s.Schedules, sched.ID)
+		s.mu.Unlock()
+		return Schedule{}, err
+	}
+	return sched, nil
+}
+
+func removeSchedule(list []Schedule, id int) []Schedule {
+	kept := list[:0]
+	for _, sc := range list {
+		if sc.ID != id {
+			kept = append(kept, sc)
+		}
+	}
+	return kept
+}
+
+// List returns the schedules targeting chat
+func (s *ScheduleStore) List(chat string) []Schedule {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []Schedule
+	for _, sc := range s.Schedules {
+		if sc.Chat == chat {
+			result = append(result, sc)
+		}
+	}
+	return result
+}
+
+// Remove deletes a schedule in chat and reports whether it existed
+func (s *ScheduleStore) Remove(chat string, id int) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	removed := false
+	kept := s.Schedules[:0]
+	for _, sc := range s.Schedules {
+		if sc.ID == id && sc.Chat == chat {
+			removed = true
+			continue
+		}
+		kept = append(kept, sc)
+	}
+	s.Schedules = kept
+	s.mu.Unlock()
+
+	if removed {
+		_ = s.Save()
+	}
+	return removed
+}
+
+// TakeDue returns the schedules due at now and marks them as run for today.
+// Due one-time schedules are removed; hand back any that failed to send with Restore.
+func (s *ScheduleStore) TakeDue(now time.Time) []Schedule {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	var due []Schedule
+	kept := s.Schedules[:0]
+	for _, sc := range s.Schedules {
+		if sc.Due(now) {
+			sc.LastRun = now.Format("2006-01-02")
+			due = append(due, sc)
+			if sc.Kind == KindOnce {
+				continue
+			}
+		}
+		kept = append(kept, sc)
+	}
+	s.Schedules = kept
+	s.mu.Unlock()
+
+	if len(due) > 0 {
+		_ = s.Save()
+	}
+	return due
+}
+
+// Restore undoes TakeDue for a schedule whose message could not be sent, so it
+// fires again on the next tick. Recurring schedules only retry within their window.
+func (s *ScheduleStore) Restore(sched Schedule) error {
+	if s == nil {
+		return fmt.Errorf("schedule store not initialized")
+	}
+
+	sched.LastRun = ""
+	s.mu.Lock()
+	found := false
+	for i := range s.Schedules {
+		if s.Schedules[i].ID == sched.ID {
+			s.Schedules[i].LastRun = ""
+			found = true
+			break
+		}
+	}
+	if !found {
+		s.Schedules = append(s.Schedules, sched)
+		sort.Slice(s.Schedules, func(i, j int) bool { return s.Schedules[i].ID < s.Schedules[j].ID })
+	}
+	s.mu.Unlock()
+
+	return s.Save()
+}
+
+func (s *ScheduleStore) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.FilePath, b, 0o644)
+}
@@ -0,0 +1,56 @@
+package schedule
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAddRollsBackOnSaveFailure(t *testing.T) {
+	s := &ScheduleStore{FilePath: filepath.Join(t.TempDir(), "missing", "schedules.json"), NextID: 1}
+
+	if _, err := s.Add(Schedule{Chat: "chat", Kind: KindOnce, RunAt: time.Now().Unix(), Message: "hi"}); err == nil {
+		t.Fatal("Add succeeded with an unwritable file")
+	}
+	if got := s.List("chat"); len(got) != 0 {
+		t.Errorf("schedule still live after failed save: %+v", got)
+	}
+	if due := s.TakeDue(time.Now()); len(due) != 0 {
+		t.Errorf("rolled-back schedule fired: %+v", due)
+	}
+}
+
+func TestRestoreRetriesFailedSchedule(t *testing.T) {
+	s := &ScheduleStore{FilePath: filepath.Join(t.TempDir(), "schedules.json"), NextID: 1}
+	now := time.Date(2026, time.October, 16, 9, 0, 30, 0, time.UTC)
+
+	once, err := s.Add(Schedule{Chat: "chat", Kind: KindOnce, RunAt: now.Add(-time.Minute).Unix(), Message: "once"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily, err := s.Add(Schedule{Chat: "chat", Kind: KindDaily, Hour: 9, Minute: 0, Message: "daily"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	due := s.TakeDue(now)
+	if len(due) != 2 {
+		t.Fatalf("TakeDue = %+v, want both schedules", due)
+	}
+	if got := s.List("chat"); len(got) != 1 || got[0].ID != daily.ID {
+		t.Fatalf("after take: %+v, want only the daily schedule", got)
+	}
+
+	for _, sc := range due {
+		if err := s.Restore(sc); err != nil {
+			t.Fatalf("Restore #%d: %v", sc.ID, err)
+		}
+	}
+	got := s.List("chat")
+	if len(got) != 2 || got[0].ID != once.ID || got[1].ID != daily.ID {
+		t.Fatalf("after restore: %+v", got)
+	}
+	if again := s.TakeDue(now.Add(30 * time.Second)); len(again) != 2 {
+		t.Errorf("restored schedules not due again: %+v", again)
+	}
+}
@@ -0,0 +1,163 @@
+package shortener
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+const (
+	cacheTTL        = 24 * time.Hour
+	maxCacheEntries = 1000
+	maxResponseSize = 64 * 1024
+)
+
+var ErrInvalidURL = errors.New("invalid URL")
+
+type cachedLink struct {
+	short     string
+	expiresAt time.Time
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cachedLink)
+)
+
+// ValidateURL checks that raw is an absolute http(s) URL with a host and
+// returns it normalized.
+func ValidateURL(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", ErrInvalidURL
+	}
+	return u.String(), nil
+}
+
+// endpoint returns SHORTENER_URL. A "{url}" placeholder is replaced with the
+// escaped long URL; without one the URL is appended as the "url" query parameter.
+func endpoint() string {
+	return config.Get().ShortenerURL
+}
+
+// Shorten returns a short link for longURL. Identical URLs are served from a
+// cache instead of calling the service again.
+func Shorten(ctx context.Context, longURL string) (string, error) {
+	longURL, err := ValidateURL(longURL)
+	if err != nil {
+		return "", err
+	}
+
+	cacheMu.Lock()
+	if cached, ok := cache[longURL]; ok && time.Now().Before(cached.expiresAt) {
+		cacheMu.Unlock()
+		return cached.short, nil
+	}
+	cacheMu.Unlock()
+
+	short, err := requestShortLink(ctx, longURL)
+	if err != nil {
+		return "", err
+	}
+
+	cacheMu.Lock()
+	if len(cache) >= maxCacheEntries {
+		now := time.Now()
+		for k, v := range cache {
+			if now.After(v.expiresAt) {
+				delete(cache, k)
+			}
+		}
+		if len(cache) >= maxCacheEntries {
+			cache = make(map[string]cachedLink)
+		}
+	}
+	cache[longURL] = cachedLink{short: short, expiresAt: time.Now().Add(cacheTTL)}
+	cacheMu.Unlock()
+
+	return short, nil
+}
+
+func requestShortLink(ctx context.Context, longURL string) (string, error) {
+	target := endpoint()
+	if strings.Contains(target, "{url}") {
+		target = strings.ReplaceAll(target, "{url}", url.QueryEscape(longURL))
+	} else {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + "url=" + url.QueryEscape(longURL)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", "whatsmeow-api-bot/1.0")
+	if key := config.Get().ShortenerAPIKey; key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(string(body))
+
+	if resp.StatusCode != http.StatusOK {
+		if len(text) > 200 {
+			text = text[:200]
+		}
+		return "", fmt.Errorf("shortener returned HTTP %d: %s", resp.StatusCode, text)
+	}
+
+	short := text
+	if strings.HasPrefix(text, "{") {
+		short = shortLinkFromJSON(body)
+	}
+	if _, err := ValidateURL(short); err != nil {
+		return "", fmt.Errorf("shortener returned an unexpected response: %q", truncate(text, 200))
+	}
+	return short, nil
+}
+
+// shortLinkFromJSON picks the short link out of the common JSON response shapes.
+func shortLinkFromJSON(body []byte) string {
+	var payload map[string]interface{}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return ""
+	}
+	for _, key := range []string{"short_url", "shorturl", "shortUrl", "short_link", "link", "url"} {
+		if s, ok := payload[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,173 @@
+package subscription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// TopicIDX is the daily IDX market broadcast.
+const TopicIDX = "idx"
+
+// Topic holds the chats that opted in to or out of one broadcast.
+type Topic struct {
+	Subscribed   []string `json:"subscribed"`
+	Unsubscribed []string `json:"unsubscribed"`
+	// LastRun is the local date (YYYY-MM-DD) the broadcast last went out, so a
+	// restart doesn't send it twice
+	LastRun string `json:"last_run,omitempty"`
+}
+
+type SubscriptionStore struct {
+	mu       sync.Mutex
+	FilePath string            `json:"-"`
+	Topics   map[string]*Topic `json:"topics"`
+}
+
+var Store *SubscriptionStore
+
+func InitSubscriptions(filePath string) error {
+	if filePath == "" {
+		filePath = "subscriptions.json"
+	}
+
+	dir := filepath.Dir(filePath)
+	if dir != "." && dir != "" {
+		_ = os.MkdirAll(dir, 0o755)
+	}
+
+	store := &SubscriptionStore{
+		FilePath: filePath,
+		Topics:   make(map[string]*Topic),
+	}
+
+	if b, err := os.ReadFile(filePath); err == nil && len(b) > 0 {
+		if err := json.Unmarshal(b, store); err != nil {
+			Store = store
+			return fmt.Errorf("failed to parse %s: %v", filePath, err)
+		}
+		if store.Topics == nil {
+			store.Topics = make(map[string]*Topic)
+		}
+	}
+
+	Store = store
+	return nil
+}
+
+// topic returns the entry for name, creating it. Callers must hold s.mu.
+func (s *SubscriptionStore) topic(name string) *Topic {
+	t, ok := s.Topics[name]
+	if !ok {
+		t = &Topic{}
+		s.Topics[name] = t
+	}
+	return t
+}
+
+// Subscribe opts chat in to topic and reports whether anything changed.
+func (s *SubscriptionStore) Subscribe(topic, chat string) (bool, error) {
+	if s == nil {
+		return false, fmt.Errorf("subscription store not initialized")
+	}
+
+	s.mu.Lock()
+	t := s.topic(topic)
+	wasOut := slices.Contains(t.Unsubscribed, chat)
+	t.Unsubscribed = slices.DeleteFunc(t.Unsubscribed, func(c string) bool { return c == chat })
+	wasIn := slices.Contains(t.Subscribed, chat)
+	if !wasIn {
+		t.Subscribed = append(t.Subscribed, chat)
+	}
+	changed := wasOut || !wasIn
+	s.mu.Unlock()
+
+	if !changed {
+		return false, nil
+	}
+	return true, s.Save()
+}
+
+// Unsubscribe opts chat out of topic, including when it is only a configured
+// target, and reports whether anything changed.
+func (s *SubscriptionStore) Unsubscribe(topic, chat string) (bool, error) {
+	if s == nil {
+		return false, fmt.Errorf("subscription store not initialized")
+	}
+
+	s.mu.Lock()
+	t := s.topic(topic)
+	t.Subscribed = slices.DeleteFunc(t.Subscribed, func(c string) bool { return c == chat })
+	alreadyOut := slices.Contains(t.Unsubscribed, chat)
+	if !alreadyOut {
+		t.Unsubscribed = append(t.Unsubscribed, chat)
+	}
+	s.mu.Unlock()
+
+	if alreadyOut {
+		return false, nil
+	}
+	return true, s.Save()
+}
+
+// Recipients returns the configured chats plus the subscribed ones, minus the
+// chats that unsubscribed. configured must use the same JID strings as the store.
+func (s *SubscriptionStore) Recipients(topic string, configured []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	var subscribed, unsubscribed []string
+	if s != nil {
+		s.mu.Lock()
+		if t, ok := s.Topics[topic]; ok {
+			subscribed = slices.Clone(t.Subscribed)
+			unsubscribed = slices.Clone(t.Unsubscribed)
+		}
+		s.mu.Unlock()
+	}
+
+	for _, chat := range append(slices.Clone(configured), subscribed...) {
+		if chat == "" || seen[chat] || slices.Contains(unsubscribed, chat) {
+			continue
+		}
+		seen[chat] = true
+		result = append(result, chat)
+	}
+	return result
+}
+
+// TakeRun marks topic as sent for date and reports whether it had not been sent yet.
+func (s *SubscriptionStore) TakeRun(topic, date string) bool {
+	if s == nil {
+		return false
+	}
+
+	s.mu.Lock()
+	t := s.topic(topic)
+	if t.LastRun == date {
+		s.mu.Unlock()
+		return false
+	}
+	t.LastRun = date
+	s.mu.Unlock()
+
+	_ = s.Save()
+	return true
+}
+
+func (s *SubscriptionStore) Save() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.FilePath, b, 0o644)
+}
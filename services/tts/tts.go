@@ -0,0 +1,82 @@
+package tts
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+// maxAudioBytes bounds the response we accept from the TTS service
+const maxAudioBytes = 16 * 1024 * 1024
+
+type synthesizeRequest struct {
+	Text string `json:"text"`
+	Lang string `json:"lang"`
+}
+
+// Language returns TTS_LANG, defaulting to Indonesian.
+func Language() string {
+	return config.Get().TTSLang
+}
+
+// Synthesize sends text to the service at TTS_SERVICE_URL and returns the audio it
+// produces. The service receives {"text": ..., "lang": ...} as JSON and must answer
+// with the raw audio bytes (any format ffmpeg can read).
+func Synthesize(ctx context.Context, text string, lang string) ([]byte, error) {
+	endpoint := config.Get().TTSServiceURL
+	if endpoint == "" {
+		return nil, fmt.Errorf("TTS service not configured")
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("empty text")
+	}
+	if lang == "" {
+		lang = Language()
+	}
+
+	payload, err := json.Marshal(synthesizeRequest{Text: text, Lang: lang})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("TTS request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("TTS service returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if ct := resp.Header.Get("Content-Type"); strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, "text/") {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("TTS service returned %s instead of audio: %s", ct, strings.TrimSpace(string(body)))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TTS audio: %v", err)
+	}
+	if len(data) > maxAudioBytes {
+		return nil, fmt.Errorf("TTS audio too large")
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("TTS service returned empty audio")
+	}
+	return data, nil
+}
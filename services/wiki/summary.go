@@ -0,0 +1,139 @@
+package wiki
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"whatsmeow-api/config"
+)
+
+var (
+	ErrNotFound      = errors.New("wikipedia page not found")
+	ErrDisambiguated = errors.New("wikipedia topic is ambiguous")
+)
+
+// Summary is the part of a Wikipedia page summary we show in chat.
+type Summary struct {
+	Title   string
+	Extract string
+	URL     string
+}
+
+// restSummary is the subset of the Wikipedia REST page/summary response we need
+type restSummary struct {
+	Type        string `json:"type"`
+	Title       string `json:"title"`
+	Extract     string `json:"extract"`
+	ContentURLs struct {
+		Desktop struct {
+			Page string `json:"page"`
+		} `json:"desktop"`
+	} `json:"content_urls"`
+}
+
+type cachedSummary struct {
+	summary   *Summary
+	err       error
+	expiresAt time.Time
+}
+
+const cacheTTL = 30 * time.Minute
+
+var (
+	cacheMu sync.Mutex
+	cache   = make(map[string]cachedSummary)
+)
+
+// Language returns WIKI_LANG, defaulting to Indonesian Wikipedia.
+func Language() string {
+	return config.Get().WikiLang
+}
+
+// GetSummary fetches the lead extract of the Wikipedia page for topic.
+// Not-found and disambiguation results are reported as ErrNotFound and
+// ErrDisambiguated and cached just like successful lookups.
+func GetSummary(ctx context.Context, topic string) (*Summary, error) {
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return nil, ErrNotFound
+	}
+
+	lang := Language()
+	key := lang + "|" + strings.ToLower(topic)
+
+	cacheMu.Lock()
+	if cached, ok := cache[key]; ok && time.Now().Before(cached.expiresAt) {
+		cacheMu.Unlock()
+		return cached.summary, cached.err
+	}
+	cacheMu.Unlock()
+
+	summary, err := fetchSummary(ctx, lang, topic)
+	if err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrDisambiguated) {
+		return nil, err
+	}
+
+	cacheMu.Lock()
+	now := time.Now()
+	for k, v := range cache {
+		if now.After(v.expiresAt) {
+			delete(cache, k)
+		}
+	}
+	cache[key] = cachedSummary{summary: summary, err: err, expiresAt: now.Add(cacheTTL)}
+	cacheMu.Unlock()
+
+	return summary, err
+}
+
+func fetchSummary(ctx context.Context, lang, topic string) (*Summary, error) {
+	title := url.PathEscape(strings.ReplaceAll(topic, " ", "_"))
+	reqURL := fmt.Sprintf("https://%s.wikipedia.org/api/rest_v1/page/summary/%s?redirect=true", lang, title)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "whatsmeow-api-bot/1.0")
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Wikipedia: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("wikipedia returned HTTP %d", resp.StatusCode)
+	}
+
+	var body restSummary
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to parse Wikipedia response: %v", err)
+	}
+
+	summary := &Summary{
+		Title:   body.Title,
+		Extract: strings.TrimSpace(body.Extract),
+		URL:     body.ContentURLs.Desktop.Page,
+	}
+
+	switch {
+	case body.Type == "disambiguation":
+		return summary, ErrDisambiguated
+	case summary.Extract == "":
+		return nil, ErrNotFound
+	}
+	return summary, nil
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+
+	"whatsmeow-api/domain"
+)
+
+// WhatsApp renders at most three quick-reply buttons and truncates longer labels.
+const (
+	MaxButtons        = 3
+	MaxButtonLabelLen = 20
+	maxButtonIDLen    = 256
+)
+
+var ErrInvalidButtons = errors.New("invalid buttons")
+
+// ValidateButtons checks that there are 1-3 buttons, each with a label of at most
+// MaxButtonLabelLen characters and an ID that is unique within the message.
+func ValidateButtons(buttons []domain.ButtonOption) error {
+	if len(buttons) == 0 || len(buttons) > MaxButtons {
+		return fmt.Errorf("%w: between 1 and %d buttons are required, got %d", ErrInvalidButtons, MaxButtons, len(buttons))
+	}
+
+	seen := make(map[string]bool, len(buttons))
+	for i, b := range buttons {
+		label := strings.TrimSpace(b.Label)
+		id := strings.TrimSpace(b.ID)
+		switch {
+		case label == "":
+			return fmt.Errorf("%w: button %d has no label", ErrInvalidButtons, i+1)
+		case utf8.RuneCountInString(label) > MaxButtonLabelLen:
+			return fmt.Errorf("%w: button %d label is longer than %d characters", ErrInvalidButtons, i+1, MaxButtonLabelLen)
+		case len(id) > maxButtonIDLen:
+			return fmt.Errorf("%w: button %d id is longer than %d bytes", ErrInvalidButtons, i+1, maxButtonIDLen)
+		case seen[id]:
+			return fmt.Errorf("%w: duplicate button id %q", ErrInvalidButtons, id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
+
+// SendButtonsWithRetry sends text with quick-reply buttons. A tapped button comes back
+// as a ButtonsResponseMessage whose ID GetMessageText returns, so IDs such as "!idx"
+// are dispatched like typed commands.
+func SendButtonsWithRetry(ctx context.Context, targetJID types.JID, text, footer string, buttons []domain.ButtonOption, maxRetries int) error {
+	msg := &waE2E.ButtonsMessage{
+		ContentText: proto.String(text),
+		HeaderType:  waE2E.ButtonsMessage_EMPTY.Enum(),
+	}
+	if footer = strings.TrimSpace(footer); footer != "" {
+		msg.FooterText = proto.String(footer)
+	}
+	for _, b := range buttons {
+		msg.Buttons = append(msg.Buttons, &waE2E.ButtonsMessage_Button{
+			ButtonID:   proto.String(strings.TrimSpace(b.ID)),
+			ButtonText: &waE2E.ButtonsMessage_Button_ButtonText{DisplayText: proto.String(strings.TrimSpace(b.Label))},
+			Type:       waE2E.ButtonsMessage_Button_RESPONSE.Enum(),
+		})
+	}
+
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		_, err = SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{ButtonsMessage: msg})
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Buttons message attempt %d failed for %s: %v", i+1, targetJID, err)
+
+		if ctx.Err() != nil || !IsRetryableSendError(err) {
+			return err
+		}
+
+		if i < maxRetries-1 {
+			time.Sleep(time.Duration(i+1) * time.Second)
+		}
+	}
+
+	return err
+}
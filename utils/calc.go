@@ -0,0 +1,151 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrDivisionByZero = errors.New("division by zero")
+	ErrInvalidExpr    = errors.New("invalid expression")
+)
+
+// exprParser is a small recursive-descent parser for arithmetic expressions:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/" | "%") factor }
+//	factor = ("+" | "-") factor | number | "(" expr ")"
+type exprParser struct {
+	input string
+	pos   int
+}
+
+// EvaluateExpression evaluates an arithmetic expression supporting +, -, *, /, %,
+// parentheses, unary signs and decimal numbers. "x" and "×" are accepted for
+// multiplication and "÷" for division.
+func EvaluateExpression(expr string) (float64, error) {
+	replacer := strings.NewReplacer("×", "*", "x", "*", "X", "*", "÷", "/", " ", "", "\t", "")
+	p := &exprParser{input: replacer.Replace(expr)}
+	if p.input == "" {
+		return 0, ErrInvalidExpr
+	}
+
+	result, err := p.parseExpr()
+	if err != nil {
+		return 0, err
+	}
+	if p.pos < len(p.input) {
+		return 0, fmt.Errorf("%w: unexpected %q", ErrInvalidExpr, p.input[p.pos])
+	}
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return 0, ErrInvalidExpr
+	}
+	return result, nil
+}
+
+func (p *exprParser) peek() byte {
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *exprParser) parseExpr() (float64, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return 0, err
+		}
+		if op == '+' {
+			left += right
+		} else {
+			left -= right
+		}
+	}
+}
+
+func (p *exprParser) parseTerm() (float64, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return 0, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return 0, err
+		}
+		switch op {
+		case '*':
+			left *= right
+		case '/':
+			if right == 0 {
+				return 0, ErrDivisionByZero
+			}
+			left /= right
+		case '%':
+			if right == 0 {
+				return 0, ErrDivisionByZero
+			}
+			left = math.Mod(left, right)
+		}
+	}
+}
+
+func (p *exprParser) parseFactor() (float64, error) {
+	switch c := p.peek(); {
+	case c == '+' || c == '-':
+		p.pos++
+		val, err := p.parseFactor()
+		if c == '-' {
+			val = -val
+		}
+		return val, err
+	case c == '(':
+		p.pos++
+		val, err := p.parseExpr()
+		if err != nil {
+			return 0, err
+		}
+		if p.peek() != ')' {
+			return 0, fmt.Errorf("%w: missing closing parenthesis", ErrInvalidExpr)
+		}
+		p.pos++
+		return val, nil
+	case (c >= '0' && c <= '9') || c == '.':
+		start := p.pos
+		for p.pos < len(p.input) && ((p.input[p.pos] >= '0' && p.input[p.pos] <= '9') || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		val, err := strconv.ParseFloat(p.input[start:p.pos], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: bad number %q", ErrInvalidExpr, p.input[start:p.pos])
+		}
+		return val, nil
+	case c == 0:
+		return 0, fmt.Errorf("%w: unexpected end of expression", ErrInvalidExpr)
+	default:
+		return 0, fmt.Errorf("%w: unexpected %q", ErrInvalidExpr, c)
+	}
+}
+
+// FormatNumber renders a calculation result without trailing zeros.
+func FormatNumber(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
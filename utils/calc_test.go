@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEvaluateExpression(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2", 3},
+		{"2 + 3 * 4", 14},
+		{"(2 + 3) * 4", 20},
+		{"10 / 4", 2.5},
+		{"10 % 3", 1},
+		{"-5 + 2", -3},
+		{"-(2 + 3)", -5},
+		{"2 x 3", 6},
+		{"2 × 3 ÷ 4", 1.5},
+		{"1.5 * 2", 3},
+		{"8 - 3 - 2", 3},
+		{"16 / 4 / 2", 2},
+	}
+	for _, tt := range tests {
+		got, err := EvaluateExpression(tt.expr)
+		if err != nil {
+			t.Errorf("EvaluateExpression(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("EvaluateExpression(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestEvaluateExpressionErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want error
+	}{
+		{"", ErrInvalidExpr},
+		{"1 +", ErrInvalidExpr},
+		{"(1 + 2", ErrInvalidExpr},
+		{"1 + 2)", ErrInvalidExpr},
+		{"1..2", ErrInvalidExpr},
+		{"2 ^ 3", ErrInvalidExpr},
+		{"abc", ErrInvalidExpr},
+		{"1 / 0", ErrDivisionByZero},
+		{"5 % 0", ErrDivisionByZero},
+	}
+	for _, tt := range tests {
+		if _, err := EvaluateExpression(tt.expr); !errors.Is(err, tt.want) {
+			t.Errorf("EvaluateExpression(%q) error = %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := map[float64]string{3: "3", 2.5: "2.5", -0.125: "-0.125", 1e6: "1000000"}
+	for in, want := range tests {
+		if got := FormatNumber(in); got != want {
+			t.Errorf("FormatNumber(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
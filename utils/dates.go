@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+var ErrInvalidDate = errors.New("invalid date")
+
+// indonesianMonths maps Indonesian month names and abbreviations to the English
+// short names understood by time.Parse
+var indonesianMonths = map[string]string{
+	"januari": "Jan", "februari": "Feb", "pebruari": "Feb", "peb": "Feb", "maret": "Mar",
+	"april": "Apr", "mei": "May", "juni": "Jun", "juli": "Jul", "agustus": "Aug",
+	"agu": "Aug", "agt": "Aug", "september": "Sep", "oktober": "Oct", "okt": "Oct",
+	"november": "Nov", "desember": "Dec", "des": "Dec",
+}
+
+var eventDateLayouts = []string{
+	"2006-01-02", "02-01-2006", "2-1-2006", "02/01/2006", "2/1/2006",
+	"02-Jan-2006", "2-Jan-2006", "2 Jan 2006", "2 January 2006",
+}
+
+var eventTimeLayouts = []string{"15:04", "15.04", "15:04:05"}
+
+// WIB returns the Asia/Jakarta location, falling back to a fixed UTC+7 zone.
+func WIB() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Jakarta"); err == nil {
+		return loc
+	}
+	return time.FixedZone("WIB", 7*3600)
+}
+
+// normalizeMonthToken translates a single Indonesian month word to English.
+func normalizeMonthToken(token string) string {
+	if en, ok := indonesianMonths[strings.ToLower(token)]; ok {
+		return en
+	}
+	return token
+}
+
+// ParseEventDate reads a date (and optional HH:MM time) from the start of input
+// and returns it together with the remaining text as the label. Supported dates
+// include 2025-12-31, 31-12-2025, 31/12/2025, 31-Dec-2025 and "31 Desember 2025".
+// Dates without a time are taken as midnight in loc.
+func ParseEventDate(input string, loc *time.Location) (time.Time, string, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 0 {
+		return time.Time{}, "", ErrInvalidDate
+	}
+
+	// Try the longest date prefix first so "31 Desember 2025" beats "31"
+	for n := 3; n >= 1; n-- {
+		if len(fields) < n {
+			continue
+		}
+		parts := make([]string, n)
+		for i, f := range fields[:n] {
+			parts[i] = normalizeMonthToken(f)
+		}
+		candidate := strings.Join(parts, " ")
+		if n == 1 {
+			// Also accept month names inside a single token, e.g. 31-Desember-2025
+			if dash := strings.Split(candidate, "-"); len(dash) == 3 {
+				dash[1] = normalizeMonthToken(dash[1])
+				candidate = strings.Join(dash, "-")
+			}
+		}
+
+		for _, layout := range eventDateLayouts {
+			date, err := time.ParseInLocation(layout, candidate, loc)
+			if err != nil {
+				continue
+			}
+
+			rest := fields[n:]
+			if len(rest) > 0 {
+				for _, tl := range eventTimeLayouts {
+					if t, err := time.Parse(tl, rest[0]); err == nil {
+						date = date.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second)
+						rest = rest[1:]
+						break
+					}
+				}
+			}
+			return date, strings.Join(rest, " "), nil
+		}
+	}
+
+	return time.Time{}, "", ErrInvalidDate
+}
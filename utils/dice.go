@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"errors"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxDiceCount = 100
+	MaxDiceSides = 1000000
+)
+
+var (
+	ErrInvalidDice  = errors.New("invalid dice notation")
+	ErrTooManyDice  = errors.New("too many dice")
+	ErrTooManySides = errors.New("too many sides")
+
+	diceRe = regexp.MustCompile(`^(\d*)d(\d+)$`)
+)
+
+// ParseDice parses "NdM" dice notation (e.g. "2d6", "d20") or a plain upper
+// bound "N", which is treated as 1dN. An empty spec defaults to 1d6.
+func ParseDice(spec string) (count, sides int, err error) {
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" {
+		return 1, 6, nil
+	}
+
+	if m := diceRe.FindStringSubmatch(spec); m != nil {
+		count = 1
+		if m[1] != "" {
+			if count, err = strconv.Atoi(m[1]); err != nil {
+				return 0, 0, ErrInvalidDice
+			}
+		}
+		if sides, err = strconv.Atoi(m[2]); err != nil {
+			return 0, 0, ErrInvalidDice
+		}
+	} else {
+		count = 1
+		if sides, err = strconv.Atoi(spec); err != nil {
+			return 0, 0, ErrInvalidDice
+		}
+	}
+
+	switch {
+	case count < 1 || sides < 2:
+		return 0, 0, ErrInvalidDice
+	case count > MaxDiceCount:
+		return 0, 0, ErrTooManyDice
+	case sides > MaxDiceSides:
+		return 0, 0, ErrTooManySides
+	}
+	return count, sides, nil
+}
+
+// RollDice rolls count dice with the given number of sides and returns each
+// result plus the total.
+func RollDice(count, sides int) ([]int, int) {
+	rolls := make([]int, count)
+	total := 0
+	for i := range rolls {
+		rolls[i] = rand.Intn(sides) + 1
+		total += rolls[i]
+	}
+	return rolls, total
+}
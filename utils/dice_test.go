@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseDice(t *testing.T) {
+	tests := []struct {
+		spec      string
+		wantCount int
+		wantSides int
+		wantErr   error
+	}{
+		{"", 1, 6, nil},
+		{"2d6", 2, 6, nil},
+		{"D20", 1, 20, nil},
+		{" 3d8 ", 3, 8, nil},
+		{"100", 1, 100, nil},
+		{"100d1000000", MaxDiceCount, MaxDiceSides, nil},
+		{"0d6", 0, 0, ErrInvalidDice},
+		{"2d1", 0, 0, ErrInvalidDice},
+		{"1", 0, 0, ErrInvalidDice},
+		{"2x6", 0, 0, ErrInvalidDice},
+		{"-3", 0, 0, ErrInvalidDice},
+		{"101d6", 0, 0, ErrTooManyDice},
+		{"1d1000001", 0, 0, ErrTooManySides},
+		{"99999999999999999999d6", 0, 0, ErrInvalidDice},
+	}
+	for _, tt := range tests {
+		count, sides, err := ParseDice(tt.spec)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseDice(%q) error = %v, want %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if count != tt.wantCount || sides != tt.wantSides {
+			t.Errorf("ParseDice(%q) = %d, %d; want %d, %d", tt.spec, count, sides, tt.wantCount, tt.wantSides)
+		}
+	}
+}
+
+func TestRollDice(t *testing.T) {
+	rolls, total := RollDice(50, 6)
+	if len(rolls) != 50 {
+		t.Fatalf("got %d rolls, want 50", len(rolls))
+	}
+	sum := 0
+	for _, r := range rolls {
+		if r < 1 || r > 6 {
+			t.Errorf("roll %d out of range 1..6", r)
+		}
+		sum += r
+	}
+	if sum != total {
+		t.Errorf("total = %d, want the sum of the rolls %d", total, sum)
+	}
+}
@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	mdHeadingRe = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)\s*#*\s*$`)
+	mdBulletRe  = regexp.MustCompile(`^(\s*)[*+•]\s+`)
+	mdBoldRe    = regexp.MustCompile(`\*\*(.+?)\*\*`)
+	mdBoldAltRe = regexp.MustCompile(`__(.+?)__`)
+	mdLinkRe    = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^)\s]+)\)`)
+)
+
+// FormatForWhatsApp converts common Markdown produced by Gemini into WhatsApp formatting:
+// **bold**/__bold__ become *bold*, headings become bold lines, bullets are normalized to
+// "- ", and links become "text (url)". Fenced code blocks are kept as ``` blocks.
+func FormatForWhatsApp(text string) string {
+	segments := strings.Split(text, "```")
+
+	for i, segment := range segments {
+		if i%2 == 1 {
+			// Inside a fenced code block: drop the language hint, keep the content as-is
+			if nl := strings.Index(segment, "\n"); nl >= 0 && !strings.ContainsAny(segment[:nl], " \t") {
+				segment = segment[nl+1:]
+			}
+			segments[i] = strings.TrimRight(segment, "\n")
+			continue
+		}
+
+		lines := strings.Split(segment, "\n")
+		for j, line := range lines {
+			if m := mdHeadingRe.FindStringSubmatch(line); m != nil {
+				heading := strings.Trim(mdBoldRe.ReplaceAllString(m[1], "$1"), "*")
+				if heading != "" {
+					line = "*" + heading + "*"
+				} else {
+					line = ""
+				}
+			} else {
+				line = mdBulletRe.ReplaceAllString(line, "$1- ")
+				line = mdBoldRe.ReplaceAllString(line, "*$1*")
+				line = mdBoldAltRe.ReplaceAllString(line, "*$1*")
+			}
+			lines[j] = mdLinkRe.ReplaceAllString(line, "$1 ($2)")
+		}
+		segments[i] = strings.Join(lines, "\n")
+	}
+
+	return strings.TrimSpace(strings.Join(segments, "```"))
+}
+
+// MaxCaptionLength is the longest media caption WhatsApp reliably accepts.
+const MaxCaptionLength = 1024
+
+// TruncateCaption shortens caption to MaxCaptionLength runes, ending with an
+// ellipsis when it had to cut. Short captions are returned unchanged.
+func TruncateCaption(caption string) string {
+	runes := []rune(caption)
+	if len(runes) <= MaxCaptionLength {
+		return caption
+	}
+	return strings.TrimRight(string(runes[:MaxCaptionLength-1]), " \n") + "…"
+}
+
+// TruncateRunes shortens s to at most max runes, replacing the tail with "..."
+// when it had to cut, so multibyte characters are never split.
+func TruncateRunes(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	if max <= 3 {
+		return string(runes[:max])
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// ShortCommitID returns the usual 7 character abbreviation of a commit hash,
+// or the whole ID when it is shorter.
+func ShortCommitID(id string) string {
+	if len(id) > 7 {
+		return id[:7]
+	}
+	return id
+}
@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormatForWhatsApp(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"bold", "ini **penting** dan __tebal__", "ini *penting* dan *tebal*"},
+		{"heading", "## Ringkasan **Pasar**", "*Ringkasan Pasar*"},
+		{"empty heading", "## **", ""},
+		{"bullets", "* satu\n+ dua\n  • tiga", "- satu\n- dua\n  - tiga"},
+		{"link", "lihat [dokumen](https://example.com/a)", "lihat dokumen (https://example.com/a)"},
+		{"code block kept", "contoh:\n```go\nx := **y**\n```", "contoh:\n```x := **y**```"},
+		{"plain", "  halo  ", "halo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatForWhatsApp(tt.in); got != tt.want {
+				t.Errorf("FormatForWhatsApp(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateCaption(t *testing.T) {
+	short := "Gambar CCTV"
+	if got := TruncateCaption(short); got != short {
+		t.Errorf("TruncateCaption(%q) = %q, want it unchanged", short, got)
+	}
+
+	exact := strings.Repeat("a", MaxCaptionLength)
+	if got := TruncateCaption(exact); got != exact {
+		t.Error("caption of exactly MaxCaptionLength runes should be unchanged")
+	}
+
+	long := strings.Repeat("é", MaxCaptionLength+50)
+	got := TruncateCaption(long)
+	if n := utf8.RuneCountInString(got); n != MaxCaptionLength {
+		t.Errorf("truncated caption has %d runes, want %d", n, MaxCaptionLength)
+	}
+	if !utf8.ValidString(got) || !strings.HasSuffix(got, "…") {
+		t.Errorf("truncated caption should be valid UTF-8 ending in an ellipsis, got suffix %q", got[len(got)-6:])
+	}
+
+	spaced := strings.Repeat("a", MaxCaptionLength-3) + "   " + "tail"
+	if got := TruncateCaption(spaced); strings.Contains(got, " …") {
+		t.Errorf("trailing spaces should be trimmed before the ellipsis, got suffix %q", got[len(got)-8:])
+	}
+}
+
+func TestTruncateRunes(t *testing.T) {
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"this is too long", 10, "this is..."},
+		{"héllo wörld", 8, "héllo..."},
+		{"日本語のテキスト", 5, "日本..."},
+		{"abcdef", 3, "abc"},
+		{"abcdef", 0, ""},
+	}
+	for _, tt := range tests {
+		got := TruncateRunes(tt.in, tt.max)
+		if got != tt.want {
+			t.Errorf("TruncateRunes(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("TruncateRunes(%q, %d) returned invalid UTF-8", tt.in, tt.max)
+		}
+	}
+}
+
+func TestShortCommitID(t *testing.T) {
+	tests := map[string]string{
+		"3d630235b181194a2dfb88567bc13fe50b8be93b": "3d63023",
+		"3d63023": "3d63023",
+		"abc":     "abc",
+		"":        "",
+	}
+	for in, want := range tests {
+		if got := ShortCommitID(in); got != want {
+			t.Errorf("ShortCommitID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
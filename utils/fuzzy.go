@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Levenshtein returns the edit distance between a and b, counted in runes.
+func Levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+// similarity turns the edit distance into a 0..1 score relative to the longer string.
+func similarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(Levenshtein(a, b))/float64(longest)
+}
+
+// FuzzyScore rates how well query matches candidate on a 0..1 scale, ignoring case.
+// An exact match scores 1 and a substring hit scores at least 0.9, so those always
+// rank above approximate matches. Otherwise the score is the better of whole-string
+// similarity and the average best per-word similarity, which tolerates typos and
+// missing words in the query.
+func FuzzyScore(query, candidate string) float64 {
+	q := strings.ToLower(strings.TrimSpace(query))
+	c := strings.ToLower(strings.TrimSpace(candidate))
+	if q == "" || c == "" {
+		return 0
+	}
+	if q == c {
+		return 1
+	}
+	if strings.Contains(c, q) {
+		// Longer queries cover more of the name, so they rank higher among substring hits.
+		return 0.9 + 0.09*float64(utf8.RuneCountInString(q))/float64(utf8.RuneCountInString(c))
+	}
+
+	best := similarity(q, c)
+
+	queryWords := strings.Fields(q)
+	candidateWords := strings.Fields(c)
+	var total float64
+	for _, qw := range queryWords {
+		var wordBest float64
+		for _, cw := range candidateWords {
+			s := similarity(qw, cw)
+			if strings.HasPrefix(cw, qw) {
+				s = max(s, 0.85)
+			}
+			wordBest = max(wordBest, s)
+		}
+		total += wordBest
+	}
+	if len(queryWords) > 0 {
+		best = max(best, 0.89*total/float64(len(queryWords)))
+	}
+	return best
+}
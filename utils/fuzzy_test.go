@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"kitten", "sitting", 3},
+		{"braincore", "braincore", 0},
+		{"café", "cafe", 1},
+	}
+	for _, tt := range tests {
+		if got := Levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("Levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyScore(t *testing.T) {
+	if got := FuzzyScore("Braincore", "braincore"); got != 1 {
+		t.Errorf("exact match (ignoring case) = %v, want 1", got)
+	}
+	if got := FuzzyScore("", "braincore"); got != 0 {
+		t.Errorf("empty query = %v, want 0", got)
+	}
+	if got := FuzzyScore("core", "Braincore Dev"); got < 0.9 {
+		t.Errorf("substring hit = %v, want at least 0.9", got)
+	}
+	if got := FuzzyScore("brancore", "Braincore Dev"); got >= 0.9 || got < 0.5 {
+		t.Errorf("typo = %v, want an approximate score in [0.5, 0.9)", got)
+	}
+	if got := FuzzyScore("zzz", "Braincore Dev"); got > 0.3 {
+		t.Errorf("unrelated = %v, want a low score", got)
+	}
+}
+
+func TestFuzzyScoreRanking(t *testing.T) {
+	groups := []string{"Keluarga Besar", "Braincore Dev", "Braincore", "Alumni SMA", "Brain Gym"}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return FuzzyScore("braincore", groups[i]) > FuzzyScore("braincore", groups[j])
+	})
+
+	want := []string{"Braincore", "Braincore Dev", "Brain Gym"}
+	for i, name := range want {
+		if groups[i] != name {
+			t.Errorf("rank %d = %q, want %q (ranking %v)", i, groups[i], name, groups)
+		}
+	}
+}
@@ -4,22 +4,30 @@ import (
 	"bytes"
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
 	"image/jpeg"
 	"image/png"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os"
+	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/proto/waE2E"
 	"go.mau.fi/whatsmeow/types"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
 	"google.golang.org/protobuf/proto"
 
+	"whatsmeow-api/config"
 	"whatsmeow-api/domain"
 	"whatsmeow-api/whatsapp"
 )
@@ -35,28 +43,18 @@ func ContainsCommand(message, command string) bool {
 }
 
 func GetNotificationTargets() []string {
-	targets := os.Getenv("NOTIFICATION_TARGETS")
-	if targets == "" {
-		return []string{}
-	}
-	return strings.Split(targets, ",")
+	return config.Get().NotificationTargets
 }
 
-func GetNoResponseGroups() []string {
-	noResponse := os.Getenv("NO_RESPONSE")
-	if noResponse == "" {
+func GetRepoTargets(repoFullName string) []string {
+	if repoFullName == "" {
 		return []string{}
 	}
+	return config.Get().RepoTargets[strings.ToLower(repoFullName)]
+}
 
-	jids := strings.Split(noResponse, ";")
-	result := make([]string, 0, len(jids))
-	for _, jid := range jids {
-		trimmed := strings.TrimSpace(jid)
-		if trimmed != "" {
-			result = append(result, trimmed)
-		}
-	}
-	return result
+func GetNoResponseGroups() []string {
+	return config.Get().NoResponseGroups
 }
 
 func ShouldIgnoreGroup(chatJID string) bool {
@@ -80,9 +78,54 @@ func IsGroupJID(target string) bool {
 	return strings.HasSuffix(target, "@g.us")
 }
 
+func IsNewsletterJID(target string) bool {
+	return strings.HasSuffix(strings.TrimSpace(target), "@"+types.NewsletterServer)
+}
+
+// IsLIDJID reports whether target is a LID (hidden user) JID such as 202219995570386@lid
+func IsLIDJID(target string) bool {
+	return strings.HasSuffix(strings.TrimSpace(target), "@"+types.HiddenUserServer)
+}
+
+// DescribeTarget returns the target type ("individual", "group", "newsletter" or "lid") and the
+// display form used in API responses. Only individual targets are phone-normalized.
+func DescribeTarget(target string) (string, string) {
+	target = strings.TrimSpace(target)
+
+	switch {
+	case IsGroupJID(target):
+		return "group", target
+	case IsNewsletterJID(target):
+		return "newsletter", target
+	case IsLIDJID(target):
+		return "lid", target
+	default:
+		return "individual", NormalizePhoneNumber(target)
+	}
+}
+
 func CreateTargetJID(target string) types.JID {
 	target = strings.TrimSpace(target)
 
+	if IsNewsletterJID(target) {
+		jid, err := types.ParseJID(target)
+		if err != nil {
+			log.Printf("Invalid newsletter JID format: %s, error: %v", target, err)
+			return types.JID{}
+		}
+		return jid
+	}
+
+	// LIDs are opaque identifiers, not phone numbers, so they must not be normalized
+	if IsLIDJID(target) {
+		jid, err := types.ParseJID(target)
+		if err != nil || jid.User == "" {
+			log.Printf("Invalid LID JID format: %s, error: %v", target, err)
+			return types.JID{}
+		}
+		return jid.ToNonAD()
+	}
+
 	if IsGroupJID(target) {
 
 		jid, err := types.ParseJID(target)
@@ -95,6 +138,10 @@ func CreateTargetJID(target string) types.JID {
 	} else {
 
 		normalizedTarget := NormalizePhoneNumber(target)
+		if normalizedTarget == "" {
+			log.Printf("Invalid phone number target: %q", target)
+			return types.JID{}
+		}
 		return types.NewJID(normalizedTarget, types.DefaultUserServer)
 	}
 }
@@ -132,33 +179,209 @@ func GetFileChangesSummary(commit domain.Commit) string {
 	return " (" + strings.Join(changes, ", ") + ")"
 }
 
+var nonDigitRe = regexp.MustCompile(`\D`)
+
+// knownCountryCodes are the calling codes recognized on numbers written without a
+// leading "+" or "00". Codes starting with 8 (Japan, Korea, China, ...) are left out
+// because bare Indonesian mobile numbers such as 812... would be mistaken for them;
+// those countries need the "+" prefix.
+var knownCountryCodes = []string{
+	"1", "7", "20", "27", "30", "31", "32", "33", "34", "39", "41", "44", "49",
+	"60", "61", "63", "64", "65", "66", "90", "91", "92", "94", "95",
+	"966", "971", "973", "974", "965", "968",
+}
+
+// minInternationalDigits is the shortest bare number (country code included) treated
+// as international, so short local numbers are still given the 62 prefix.
+const minInternationalDigits = 10
+
+// NormalizePhoneNumber converts a phone number into the digits-only international
+// form WhatsApp expects. Spaces, dashes and parentheses are stripped first. Numbers
+// with a "+" or "00" prefix or a recognized country code keep it; everything else is
+// treated as an Indonesian number and gets the 62 prefix:
+//
+//	"0812-3456-789"    -> "628123456789"
+//	"+62 812 3456 789" -> "628123456789"
+//	"628123456789"     -> "628123456789"
+//	"8123456789"       -> "628123456789"
+//	"00628123456789"   -> "628123456789" (international 00 prefix)
+//	"6208123456789"    -> "628123456789" (trunk 0 after the country code)
+//	"+1 415 555 2671"  -> "14155552671"
+//	"+65 9123 4567"    -> "6591234567"
+//	"60123456789"      -> "60123456789"
+//	""                 -> ""
 func NormalizePhoneNumber(phone string) string {
-	re := regexp.MustCompile(`\D`)
-	phone = re.ReplaceAllString(phone, "")
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
 
-	if strings.HasPrefix(phone, "08") {
-		phone = "628" + phone[2:]
+	phone = nonDigitRe.ReplaceAllString(phone, "")
+	if phone == "" {
+		return ""
 	}
 
-	if strings.HasPrefix(phone, "8") && !strings.HasPrefix(phone, "62") {
-		phone = "62" + phone
+	if strings.HasPrefix(phone, "00") {
+		phone = phone[2:]
+		international = true
 	}
 
-	if strings.HasPrefix(phone, "+62") {
-		phone = phone[1:]
+	switch {
+	case strings.HasPrefix(phone, "62"):
+		// already has the Indonesian country code
+	case strings.HasPrefix(phone, "0"):
+		phone = "62" + phone[1:]
+	case international || hasKnownCountryCode(phone):
+		return phone
+	default:
+		phone = "62" + phone
 	}
 
-	if !strings.HasPrefix(phone, "62") {
-		phone = "62" + phone
+	// Drop the domestic trunk zero if it was kept after the country code (620812... -> 62812...)
+	for strings.HasPrefix(phone, "620") {
+		phone = "62" + phone[3:]
 	}
 
 	return phone
 }
 
+func hasKnownCountryCode(phone string) bool {
+	if len(phone) < minInternationalDigits {
+		return false
+	}
+	for _, code := range knownCountryCodes {
+		if strings.HasPrefix(phone, code) {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	sendsAttempted atomic.Int64
+	sendsSucceeded atomic.Int64
+)
+
+// GetSendStats returns how many WhatsApp sends were attempted and how many succeeded
+func GetSendStats() (int64, int64) {
+	return sendsAttempted.Load(), sendsSucceeded.Load()
+}
+
+// SendRetries is the retry count for command replies (SEND_RETRIES, default 2).
+func SendRetries() int {
+	return config.Get().SendRetries
+}
+
+// DeliveryRetries is the retry count for API sends, notifications and
+// scheduled messages (DELIVERY_RETRIES, default 3).
+func DeliveryRetries() int {
+	return config.Get().DeliveryRetries
+}
+
+// ImageRetries is the retry count for image sends (IMAGE_RETRIES, default 3).
+func ImageRetries() int {
+	return config.Get().ImageRetries
+}
+
+func GetSendTimeout() time.Duration {
+	return config.Get().SendTimeout
+}
+
+// SendMessageWithTimeout sends a single message with a bounded context so a stuck send
+// cannot hang forever. A timeout is reported as a distinct, retryable error.
+func SendMessageWithTimeout(ctx context.Context, targetJID types.JID, message *waE2E.Message) (whatsmeow.SendResponse, error) {
+	timeout := GetSendTimeout()
+	sendCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	sendsAttempted.Add(1)
+	resp, err := whatsapp.GetClient().SendMessage(sendCtx, targetJID, message)
+	if err == nil {
+		sendsSucceeded.Add(1)
+	}
+	if err != nil && errors.Is(sendCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return resp, fmt.Errorf("send to %s timed out after %v: %w", targetJID, timeout, err)
+	}
+	return resp, err
+}
+
+// StartTyping shows the "typing..." indicator in chat (individual or group) until the
+// returned stop function is called. WhatsApp expires the indicator after a while, so it is
+// refreshed periodically. Presence errors are ignored since they are purely cosmetic.
+func StartTyping(chat types.JID) func() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(10 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			if err := whatsapp.GetClient().SendChatPresence(ctx, chat, types.ChatPresenceComposing, types.ChatPresenceMediaText); err != nil && ctx.Err() == nil {
+				log.Printf("[presence] Failed to send composing to %s: %v", chat, err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		pauseCtx, pauseCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer pauseCancel()
+		_ = whatsapp.GetClient().SendChatPresence(pauseCtx, chat, types.ChatPresencePaused, types.ChatPresenceMediaText)
+	}
+}
+
+// permanentSendErrors are whatsmeow errors that will fail the same way on
+// every attempt, so retrying them only wastes time.
+var permanentSendErrors = []error{
+	whatsmeow.ErrClientIsNil,
+	whatsmeow.ErrNotLoggedIn,
+	whatsmeow.ErrBroadcastListUnsupported,
+	whatsmeow.ErrUnknownServer,
+	whatsmeow.ErrRecipientADJID,
+	whatsmeow.ErrInvalidInlineBotID,
+	whatsmeow.ErrNotInGroup,
+	whatsmeow.ErrGroupNotFound,
+	context.Canceled,
+}
+
+var permanentSendErrorMessages = []string{
+	"not logged in",
+	"invalid jid",
+	"doesn't contain a device jid",
+	"unknown server",
+}
+
+// IsRetryableSendError reports whether a failed send is worth another
+// attempt. Transient failures such as timeouts and dropped websockets are
+// retryable; invalid recipients and missing sessions are not.
+func IsRetryableSendError(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, permanent := range permanentSendErrors {
+		if errors.Is(err, permanent) {
+			return false
+		}
+	}
+	msg := strings.ToLower(err.Error())
+	for _, permanent := range permanentSendErrorMessages {
+		if strings.Contains(msg, permanent) {
+			return false
+		}
+	}
+	return true
+}
+
 func SendMessageWithRetry(ctx context.Context, targetJID types.JID, message string, maxRetries int) error {
 	var err error
 	for i := 0; i < maxRetries; i++ {
-		_, err = whatsapp.Client.SendMessage(ctx, targetJID, &waE2E.Message{
+		_, err = SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{
 			Conversation: proto.String(message),
 		})
 
@@ -168,6 +391,10 @@ func SendMessageWithRetry(ctx context.Context, targetJID types.JID, message stri
 
 		log.Printf("Attempt %d failed for %s: %v", i+1, targetJID, err)
 
+		if ctx.Err() != nil || !IsRetryableSendError(err) {
+			return err
+		}
+
 		if i < maxRetries-1 {
 			time.Sleep(time.Duration(i+1) * time.Second)
 		}
@@ -207,13 +434,15 @@ func GetMessageText(msg *waE2E.Message) string {
 		}
 	}
 
+	// The button ID is what the sender chose to dispatch on (e.g. "!idx"), so it wins
+	// over the label shown to the user.
 	if br := msg.GetButtonsResponseMessage(); br != nil {
-		if txt := br.GetSelectedDisplayText(); txt != "" {
-			return txt
-		}
 		if id := br.GetSelectedButtonID(); id != "" {
 			return id
 		}
+		if txt := br.GetSelectedDisplayText(); txt != "" {
+			return txt
+		}
 	}
 	if lr := msg.GetListResponseMessage(); lr != nil {
 		if single := lr.GetSingleSelectReply(); single != nil {
@@ -258,19 +487,79 @@ func GetMessageText(msg *waE2E.Message) string {
 	return ""
 }
 
+func GetContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	if msg == nil {
+		return nil
+	}
+
+	if ext := msg.GetExtendedTextMessage(); ext != nil && ext.GetContextInfo() != nil {
+		return ext.GetContextInfo()
+	}
+	if im := msg.GetImageMessage(); im != nil && im.GetContextInfo() != nil {
+		return im.GetContextInfo()
+	}
+	if vm := msg.GetVideoMessage(); vm != nil && vm.GetContextInfo() != nil {
+		return vm.GetContextInfo()
+	}
+	if dm := msg.GetDocumentMessage(); dm != nil && dm.GetContextInfo() != nil {
+		return dm.GetContextInfo()
+	}
+	if ep := msg.GetEphemeralMessage(); ep != nil {
+		return GetContextInfo(ep.GetMessage())
+	}
+	if dv := msg.GetDeviceSentMessage(); dv != nil {
+		return GetContextInfo(dv.GetMessage())
+	}
+
+	return nil
+}
+
+// GetQuotedMessage returns the message being replied to, or nil when msg is not a reply.
+func GetQuotedMessage(msg *waE2E.Message) *waE2E.Message {
+	return GetContextInfo(msg).GetQuotedMessage()
+}
+
+func GetImageMessage(msg *waE2E.Message) *waE2E.ImageMessage {
+	if msg == nil {
+		return nil
+	}
+
+	if im := msg.GetImageMessage(); im != nil {
+		return im
+	}
+	if ep := msg.GetEphemeralMessage(); ep != nil {
+		return GetImageMessage(ep.GetMessage())
+	}
+	if vo := msg.GetViewOnceMessage(); vo != nil {
+		return GetImageMessage(vo.GetMessage())
+	}
+	if dv := msg.GetDeviceSentMessage(); dv != nil {
+		return GetImageMessage(dv.GetMessage())
+	}
+
+	return nil
+}
+
 func SendImageWithRetry(ctx context.Context, targetJID types.JID, imageBase64 string, caption string, maxRetries int) error {
+	_, err := SendImageWithRetryStatus(ctx, targetJID, imageBase64, caption, maxRetries)
+	return err
+}
+
+// SendImageWithRetryStatus behaves like SendImageWithRetry but also reports whether
+// the image had to be delivered through SendImageFallback instead of a direct upload.
+func SendImageWithRetryStatus(ctx context.Context, targetJID types.JID, imageBase64 string, caption string, maxRetries int) (bool, error) {
 	var err error
 	for i := 0; i < maxRetries; i++ {
 
 		imageData, decodeErr := base64.StdEncoding.DecodeString(imageBase64)
 		if decodeErr != nil {
-			return fmt.Errorf("failed to decode base64 image: %v", decodeErr)
+			return false, fmt.Errorf("failed to decode base64 image: %v", decodeErr)
 		}
 
 		log.Printf("Image data size: %d bytes", len(imageData))
 
 		if len(imageData) > 15*1024*1024 {
-			return fmt.Errorf("image too large: %d bytes (max 15MB)", len(imageData))
+			return false, fmt.Errorf("image too large: %d bytes (max 15MB)", len(imageData))
 		}
 
 		tempFile, tempErr := SaveImageToTempFile(imageData)
@@ -284,17 +573,18 @@ func SendImageWithRetry(ctx context.Context, targetJID types.JID, imageBase64 st
 
 		log.Printf("Attempting to upload and send image...")
 
-		thumbnailData := imageData
-		if len(imageData) > 100*1024 {
-			log.Printf("Creating thumbnail for large image...")
-			thumb, thumbErr := CreateThumbnail(imageData)
-			if thumbErr == nil {
-				thumbnailData = thumb
-				log.Printf("Thumbnail created: %d bytes", len(thumbnailData))
-			}
+		// JPEGThumbnail must be JPEG regardless of the source format
+		var thumbnailData []byte
+		if thumb, thumbErr := CreateThumbnail(imageData); thumbErr == nil {
+			thumbnailData = thumb
+			log.Printf("Thumbnail created: %d bytes", len(thumbnailData))
+		} else {
+			log.Printf("Failed to create thumbnail: %v", thumbErr)
 		}
 
-		uploaded, uploadErr := whatsapp.Client.Upload(ctx, imageData, whatsmeow.MediaImage)
+		mimeType := DetectImageMimeType(imageData)
+
+		uploaded, uploadErr := whatsapp.GetClient().Upload(ctx, imageData, whatsmeow.MediaImage)
 		if uploadErr != nil {
 			log.Printf("Failed to upload image: %v", uploadErr)
 
@@ -309,8 +599,8 @@ func SendImageWithRetry(ctx context.Context, targetJID types.JID, imageBase64 st
 
 		imageMsg := &waE2E.Message{
 			ImageMessage: &waE2E.ImageMessage{
-				Caption:       proto.String(caption),
-				Mimetype:      proto.String("image/png"),
+				Caption:       proto.String(TruncateCaption(caption)),
+				Mimetype:      proto.String(mimeType),
 				JPEGThumbnail: thumbnailData,
 				URL:           &uploaded.URL,
 				DirectPath:    &uploaded.DirectPath,
@@ -321,20 +611,23 @@ func SendImageWithRetry(ctx context.Context, targetJID types.JID, imageBase64 st
 			},
 		}
 
-		_, err = whatsapp.Client.SendMessage(ctx, targetJID, imageMsg)
+		_, err = SendMessageWithTimeout(ctx, targetJID, imageMsg)
 		if err == nil {
 			log.Printf("Image sent successfully to %s", targetJID.String())
-			return nil
+			return false, nil
 		}
 
 		log.Printf("Failed to send image message (attempt %d/%d): %v", i+1, maxRetries, err)
+		if ctx.Err() != nil || !IsRetryableSendError(err) {
+			return false, err
+		}
 		if i < maxRetries-1 {
 			time.Sleep(time.Duration(i+1) * time.Second)
 		}
 	}
 
 	log.Printf("All upload attempts failed, trying alternative methods...")
-	return SendImageFallback(ctx, targetJID, imageBase64, caption)
+	return true, SendImageFallback(ctx, targetJID, imageBase64, caption)
 }
 
 func SendImageFallback(ctx context.Context, targetJID types.JID, imageBase64 string, caption string) error {
@@ -385,7 +678,7 @@ func SendImageFallback(ctx context.Context, targetJID types.JID, imageBase64 str
 
 				thumbnailMessage := fmt.Sprintf("[Gambar AI Generated]\n\n%s\n\n[Thumbnail:]\n%s\n\n*Catatan:* Gambar asli terlalu besar, ini adalah thumbnail kecil.", caption, thumbnailURL)
 
-				_, sendErr := whatsapp.Client.SendMessage(ctx, targetJID, &waE2E.Message{
+				_, sendErr := SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{
 					Conversation: proto.String(thumbnailMessage),
 				})
 
@@ -399,7 +692,7 @@ func SendImageFallback(ctx context.Context, targetJID types.JID, imageBase64 str
 		log.Printf("Thumbnail also too large, sending fallback message")
 		fallbackMessage := fmt.Sprintf("[Gambar AI Generated]\n\n%s\n\n[Gagal Mengirim Gambar]\n\nGambar berhasil dibuat oleh AI tetapi terlalu besar untuk dikirim melalui WhatsApp.\n\n*Detail:*\n- Ukuran file: %d bytes\n- Data URL: %d karakter\n- Batas WhatsApp: ~4000 karakter\n\n*Solusi:*\n- Gunakan deskripsi yang lebih sederhana\n- Coba prompt yang menghasilkan gambar lebih kecil\n- Contoh: `!img simple cat` atau `!img red circle`", caption, len(compressedImageData), len(dataURL))
 
-		_, sendErr := whatsapp.Client.SendMessage(ctx, targetJID, &waE2E.Message{
+		_, sendErr := SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{
 			Conversation: proto.String(fallbackMessage),
 		})
 
@@ -411,7 +704,7 @@ func SendImageFallback(ctx context.Context, targetJID types.JID, imageBase64 str
 
 		urlMessage := fmt.Sprintf("🎨 *Gambar AI Generated*\n\n%s\n\n📎 *Data URL:*\n%s\n\n*Catatan:* Upload langsung gagal (error 415), gambar tersedia sebagai data URL di atas.", caption, dataURL)
 
-		_, sendErr := whatsapp.Client.SendMessage(ctx, targetJID, &waE2E.Message{
+		_, sendErr := SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{
 			Conversation: proto.String(urlMessage),
 		})
 
@@ -475,15 +768,22 @@ func CompressImageAggressively(imageData []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// DetectImageMimeType sniffs the image format from its bytes. Unknown data falls back
+// to image/jpeg, which WhatsApp renders most reliably.
+func DetectImageMimeType(imageData []byte) string {
+	switch mimeType := http.DetectContentType(imageData); mimeType {
+	case "image/jpeg", "image/png", "image/webp", "image/gif":
+		return mimeType
+	default:
+		return "image/jpeg"
+	}
+}
+
 func CreateThumbnail(imageData []byte) ([]byte, error) {
 
-	img, err := jpeg.Decode(bytes.NewReader(imageData))
+	img, _, err := image.Decode(bytes.NewReader(imageData))
 	if err != nil {
-
-		img, err = png.Decode(bytes.NewReader(imageData))
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode image: %v", err)
-		}
+		return nil, fmt.Errorf("failed to decode image: %v", err)
 	}
 
 	bounds := img.Bounds()
@@ -508,14 +808,7 @@ func CreateThumbnail(imageData []byte) ([]byte, error) {
 	}
 
 	thumbnail := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
-
-	for y := 0; y < newHeight; y++ {
-		for x := 0; x < newWidth; x++ {
-			srcX := (x * width) / newWidth
-			srcY := (y * height) / newHeight
-			thumbnail.Set(x, y, img.At(srcX, srcY))
-		}
-	}
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Src, nil)
 
 	var buf bytes.Buffer
 	err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 10})
@@ -526,13 +819,163 @@ func CreateThumbnail(imageData []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// DownscaleImageBase64 shrinks a base64 image whose longest side exceeds
+// IMG_MAX_DIMENSION (default 1280px) and re-encodes it as JPEG at IMG_JPEG_QUALITY
+// (default 85). Images already within the limit are returned unchanged.
+func DownscaleImageBase64(imageBase64 string) (string, error) {
+	imageData, err := base64.StdEncoding.DecodeString(imageBase64)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 image: %v", err)
+	}
+
+	maxSide := config.Get().ImageMaxDimension
+	quality := config.Get().ImageJPEGQuality
+
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSide && height <= maxSide {
+		return imageBase64, nil
+	}
+
+	newWidth, newHeight := maxSide, height*maxSide/width
+	if height > width {
+		newWidth, newHeight = width*maxSide/height, maxSide
+	}
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	// JPEG has no alpha channel, so flatten onto white first
+	scaled := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	draw.Draw(scaled, scaled.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(scaled, scaled.Bounds(), img, bounds, draw.Over, nil)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaled, &jpeg.Options{Quality: quality}); err != nil {
+		return "", fmt.Errorf("failed to encode JPEG: %v", err)
+	}
+
+	log.Printf("Downscaled image from %dx%d (%d bytes) to %dx%d (%d bytes)", width, height, len(imageData), newWidth, newHeight, buf.Len())
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+const stickerSize = 512
+
+// ConvertToStickerWebP scales an image to fit a 512x512 transparent canvas (keeping the
+// aspect ratio) and encodes it as WebP using cwebp, falling back to ffmpeg.
+func ConvertToStickerWebP(imageData []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+	if width == 0 || height == 0 {
+		return nil, fmt.Errorf("image has no pixels")
+	}
+
+	var newWidth, newHeight int
+	if width > height {
+		newWidth = stickerSize
+		newHeight = (height * stickerSize) / width
+	} else {
+		newHeight = stickerSize
+		newWidth = (width * stickerSize) / height
+	}
+	if newWidth < 1 {
+		newWidth = 1
+	}
+	if newHeight < 1 {
+		newHeight = 1
+	}
+
+	// Transparent canvas, image centered with padding on the short side
+	canvas := image.NewRGBA(image.Rect(0, 0, stickerSize, stickerSize))
+	offsetX := (stickerSize - newWidth) / 2
+	offsetY := (stickerSize - newHeight) / 2
+	dst := image.Rect(offsetX, offsetY, offsetX+newWidth, offsetY+newHeight)
+	draw.CatmullRom.Scale(canvas, dst, img, bounds, draw.Over, nil)
+
+	pngFile, err := os.CreateTemp("", "whatsapp_sticker_*.png")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(pngFile.Name())
+
+	if err := png.Encode(pngFile, canvas); err != nil {
+		pngFile.Close()
+		return nil, fmt.Errorf("failed to encode PNG: %v", err)
+	}
+	pngFile.Close()
+
+	webpPath := strings.TrimSuffix(pngFile.Name(), ".png") + ".webp"
+	defer os.Remove(webpPath)
+
+	output, err := exec.Command("cwebp", "-quiet", "-q", "80", pngFile.Name(), "-o", webpPath).CombinedOutput()
+	if err != nil {
+		log.Printf("[sticker] cwebp failed: %v -- retrying with ffmpeg\nOutput: %s", err, string(output))
+
+		output, err = exec.Command("ffmpeg", "-y", "-i", pngFile.Name(), "-c:v", "libwebp", "-lossless", "0", "-q:v", "80", webpPath).CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("webp conversion failed: %v\nOutput: %s", err, string(output))
+		}
+	}
+
+	webpData, err := os.ReadFile(webpPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(webpData) == 0 {
+		return nil, fmt.Errorf("webp conversion produced an empty file")
+	}
+	return webpData, nil
+}
+
+func SendSticker(ctx context.Context, targetJID types.JID, webpData []byte) error {
+	uploaded, err := whatsapp.GetClient().Upload(ctx, webpData, whatsmeow.MediaImage)
+	if err != nil {
+		return fmt.Errorf("sticker upload failed: %v", err)
+	}
+
+	stickerMsg := &waE2E.Message{
+		StickerMessage: &waE2E.StickerMessage{
+			Mimetype:      proto.String("image/webp"),
+			Width:         proto.Uint32(stickerSize),
+			Height:        proto.Uint32(stickerSize),
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    &uploaded.FileLength,
+		},
+	}
+
+	if _, err := SendMessageWithTimeout(ctx, targetJID, stickerMsg); err != nil {
+		return fmt.Errorf("send sticker message failed: %v", err)
+	}
+
+	log.Printf("Sticker sent successfully to %s", targetJID.String())
+	return nil
+}
+
 func SendImageAsURL(ctx context.Context, targetJID types.JID, imageBase64 string, caption string) error {
 
 	dataURL := fmt.Sprintf("data:image/png;base64,%s", imageBase64)
 
 	urlMessage := fmt.Sprintf("[Gambar AI Generated]\n\n%s\n\n[Data URL:]\n%s\n\n*Catatan:* Upload langsung gagal, gambar tersedia sebagai data URL di atas.", caption, dataURL)
 
-	_, err := whatsapp.Client.SendMessage(ctx, targetJID, &waE2E.Message{
+	_, err := SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{
 		Conversation: proto.String(urlMessage),
 	})
 
@@ -550,3 +993,72 @@ func Min(a, b int) int {
 	}
 	return b
 }
+
+// ConvertToOpus transcodes audio to OGG/Opus mono, the format WhatsApp expects for
+// voice notes, and returns the result with its duration in seconds (0 if unknown).
+func ConvertToOpus(audioData []byte) ([]byte, uint32, error) {
+	inFile, err := os.CreateTemp("", "voice_in_*")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(inFile.Name())
+	if _, err := inFile.Write(audioData); err != nil {
+		inFile.Close()
+		return nil, 0, err
+	}
+	inFile.Close()
+
+	outPath := inFile.Name() + ".ogg"
+	defer os.Remove(outPath)
+
+	output, err := exec.Command("ffmpeg", "-y", "-i", inFile.Name(), "-vn", "-ac", "1", "-ar", "48000", "-c:a", "libopus", "-b:a", "32k", "-application", "voip", outPath).CombinedOutput()
+	if err != nil {
+		return nil, 0, fmt.Errorf("opus conversion failed: %v\nOutput: %s", err, string(output))
+	}
+
+	oggData, err := os.ReadFile(outPath)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(oggData) == 0 {
+		return nil, 0, fmt.Errorf("opus conversion produced an empty file")
+	}
+
+	var seconds uint32
+	if out, err := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=duration", "-of", "csv=p=0", outPath).Output(); err == nil {
+		if d, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64); err == nil && d > 0 {
+			seconds = uint32(d + 0.5)
+		}
+	}
+
+	return oggData, seconds, nil
+}
+
+// SendVoiceNote uploads OGG/Opus audio and sends it as a push-to-talk voice note.
+func SendVoiceNote(ctx context.Context, targetJID types.JID, oggData []byte, seconds uint32) error {
+	uploaded, err := whatsapp.GetClient().Upload(ctx, oggData, whatsmeow.MediaAudio)
+	if err != nil {
+		return fmt.Errorf("audio upload failed: %v", err)
+	}
+
+	audioMsg := &waE2E.Message{
+		AudioMessage: &waE2E.AudioMessage{
+			Mimetype:      proto.String("audio/ogg; codecs=opus"),
+			PTT:           proto.Bool(true),
+			Seconds:       proto.Uint32(seconds),
+			URL:           &uploaded.URL,
+			DirectPath:    &uploaded.DirectPath,
+			MediaKey:      uploaded.MediaKey,
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    &uploaded.FileLength,
+		},
+	}
+
+	if _, err := SendMessageWithTimeout(ctx, targetJID, audioMsg); err != nil {
+		return fmt.Errorf("send voice note failed: %v", err)
+	}
+
+	log.Printf("Voice note sent successfully to %s", targetJID.String())
+	return nil
+}
@@ -0,0 +1,179 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+
+	"whatsmeow-api/config"
+)
+
+func TestGetRepoTargets(t *testing.T) {
+	cfg, _ := config.LoadConfig()
+	cfg.RepoTargets = config.ParseRepoTargets("owner/repo1:jid1,jid2;owner/repo2:jid3")
+	config.Set(cfg)
+
+	tests := []struct {
+		repo string
+		want []string
+	}{
+		{"owner/repo1", []string{"jid1", "jid2"}},
+		{"Owner/Repo2", []string{"jid3"}},
+		{"owner/other", nil},
+		{"", []string{}},
+	}
+	for _, tt := range tests {
+		if got := GetRepoTargets(tt.repo); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetRepoTargets(%q) = %v, want %v", tt.repo, got, tt.want)
+		}
+	}
+}
+
+func TestNormalizePhoneNumberIndonesian(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"08123456789", "628123456789"},
+		{"0812-3456-789", "628123456789"},
+		{"(0812) 3456 789", "628123456789"},
+		{"628123456789", "628123456789"},
+		{"+62 812 3456 789", "628123456789"},
+		{"0062 812 3456 789", "628123456789"},
+		{"6208123456789", "628123456789"},
+		{"+62 0812 3456 789", "628123456789"},
+		{"8123456789", "628123456789"},
+		{"", ""},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizePhoneNumber(tt.in); got != tt.want {
+			t.Errorf("NormalizePhoneNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsRetryableSendError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"timeout", context.DeadlineExceeded, true},
+		{"websocket dropped", errors.New("failed to send node: websocket not connected"), true},
+		{"client nil", whatsmeow.ErrClientIsNil, false},
+		{"not logged in", whatsmeow.ErrNotLoggedIn, false},
+		{"wrapped not in group", fmt.Errorf("send: %w", whatsmeow.ErrNotInGroup), false},
+		{"canceled", context.Canceled, false},
+		{"invalid jid text", errors.New("Invalid JID: foo"), false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryableSendError(tt.err); got != tt.want {
+			t.Errorf("%s: IsRetryableSendError(%v) = %t, want %t", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func testImage(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for x := 0; x < w; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.RGBA{uint8(x), uint8(y), 128, 255})
+		}
+	}
+	return img
+}
+
+func encodeTestImage(t *testing.T, format string, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var err error
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		err = gif.Encode(&buf, img, nil)
+	}
+	if err != nil {
+		t.Fatalf("encode %s: %v", format, err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetectImageMimeType(t *testing.T) {
+	img := testImage(8, 8)
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"png", encodeTestImage(t, "png", img), "image/png"},
+		{"jpeg", encodeTestImage(t, "jpeg", img), "image/jpeg"},
+		{"gif", encodeTestImage(t, "gif", img), "image/gif"},
+		{"webp", []byte("RIFF\x00\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"unknown falls back to jpeg", []byte("not an image"), "image/jpeg"},
+		{"empty", nil, "image/jpeg"},
+	}
+	for _, tt := range tests {
+		if got := DetectImageMimeType(tt.data); got != tt.want {
+			t.Errorf("%s: DetectImageMimeType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateThumbnailIsJPEG(t *testing.T) {
+	thumb, err := CreateThumbnail(encodeTestImage(t, "png", testImage(200, 100)))
+	if err != nil {
+		t.Fatalf("CreateThumbnail: %v", err)
+	}
+	if got := DetectImageMimeType(thumb); got != "image/jpeg" {
+		t.Errorf("thumbnail mimetype = %q, want image/jpeg", got)
+	}
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if cfg.Width != 64 || cfg.Height != 32 {
+		t.Errorf("thumbnail is %dx%d, want 64x32", cfg.Width, cfg.Height)
+	}
+
+	if _, err := CreateThumbnail([]byte("not an image")); err == nil {
+		t.Error("expected an error for undecodable data")
+	}
+}
+
+func TestNormalizePhoneNumberInternational(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"+1 415 555 2671", "14155552671"},
+		{"001 415 555 2671", "14155552671"},
+		{"+65 9123 4567", "6591234567"},
+		{"60123456789", "60123456789"},
+		{"+44 20 7946 0958", "442079460958"},
+		{"971501234567", "971501234567"},
+		// Too short to be a bare international number, so it stays Indonesian
+		{"6512345", "626512345"},
+		// Numbers starting with 8 are never read as a country code
+		{"81234567890", "6281234567890"},
+	}
+	for _, tt := range tests {
+		if got := NormalizePhoneNumber(tt.in); got != tt.want {
+			t.Errorf("NormalizePhoneNumber(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
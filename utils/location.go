@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+var ErrInvalidCoordinates = errors.New("invalid coordinates")
+
+// ValidateCoordinates checks that lat/lng are finite and within the valid
+// ranges (-90..90 and -180..180).
+func ValidateCoordinates(lat, lng float64) error {
+	if math.IsNaN(lat) || math.IsNaN(lng) || math.IsInf(lat, 0) || math.IsInf(lng, 0) {
+		return ErrInvalidCoordinates
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return ErrInvalidCoordinates
+	}
+	return nil
+}
+
+// ParseCoordinates parses latitude and longitude strings. A trailing comma on
+// the latitude is accepted so "-6.2, 106.8" pasted from a map works.
+func ParseCoordinates(latStr, lngStr string) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(strings.TrimSuffix(strings.TrimSpace(latStr), ","), 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCoordinates
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(lngStr), 64)
+	if err != nil {
+		return 0, 0, ErrInvalidCoordinates
+	}
+	if err := ValidateCoordinates(lat, lng); err != nil {
+		return 0, 0, err
+	}
+	return lat, lng, nil
+}
+
+func SendLocationWithRetry(ctx context.Context, targetJID types.JID, lat, lng float64, name string, maxRetries int) error {
+	location := &waE2E.LocationMessage{
+		DegreesLatitude:  proto.Float64(lat),
+		DegreesLongitude: proto.Float64(lng),
+	}
+	if name = strings.TrimSpace(name); name != "" {
+		location.Name = proto.String(name)
+	}
+
+	var err error
+	for i := 0; i < maxRetries; i++ {
+		_, err = SendMessageWithTimeout(ctx, targetJID, &waE2E.Message{LocationMessage: location})
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Location attempt %d failed for %s: %v", i+1, targetJID, err)
+
+		if ctx.Err() != nil || !IsRetryableSendError(err) {
+			return err
+		}
+
+		if i < maxRetries-1 {
+			time.Sleep(time.Duration(i+1) * time.Second)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"mime"
+	"net/http"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+
+	"whatsmeow-api/whatsapp"
+)
+
+const (
+	MediaKindImage    = "image"
+	MediaKindVideo    = "video"
+	MediaKindAudio    = "audio"
+	MediaKindDocument = "document"
+	MediaKindSticker  = "sticker"
+)
+
+var ErrNoMedia = errors.New("message has no downloadable media")
+
+// unwrapMessage strips the ephemeral/view-once/device-sent wrappers around the
+// actual message content.
+func unwrapMessage(msg *waE2E.Message) *waE2E.Message {
+	for msg != nil {
+		switch {
+		case msg.GetEphemeralMessage() != nil:
+			msg = msg.GetEphemeralMessage().GetMessage()
+		case msg.GetViewOnceMessage() != nil:
+			msg = msg.GetViewOnceMessage().GetMessage()
+		case msg.GetViewOnceMessageV2() != nil:
+			msg = msg.GetViewOnceMessageV2().GetMessage()
+		case msg.GetDeviceSentMessage() != nil:
+			msg = msg.GetDeviceSentMessage().GetMessage()
+		default:
+			return msg
+		}
+	}
+	return nil
+}
+
+// GetMediaMessage returns the downloadable media in msg along with its kind and
+// the mimetype the sender declared. It returns nil when msg carries no media.
+func GetMediaMessage(msg *waE2E.Message) (whatsmeow.DownloadableMessage, string, string) {
+	msg = unwrapMessage(msg)
+	if msg == nil {
+		return nil, "", ""
+	}
+
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage(), MediaKindImage, msg.GetImageMessage().GetMimetype()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage(), MediaKindVideo, msg.GetVideoMessage().GetMimetype()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage(), MediaKindAudio, msg.GetAudioMessage().GetMimetype()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage(), MediaKindDocument, msg.GetDocumentMessage().GetMimetype()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage(), MediaKindSticker, msg.GetStickerMessage().GetMimetype()
+	}
+	return nil, "", ""
+}
+
+// DownloadMedia downloads and decrypts the media in msg. The returned mimetype is
+// the declared one, or sniffed from the content when the sender didn't set it.
+func DownloadMedia(ctx context.Context, msg *waE2E.Message) ([]byte, string, string, error) {
+	media, kind, mimetype := GetMediaMessage(msg)
+	if media == nil {
+		return nil, "", "", ErrNoMedia
+	}
+
+	data, err := whatsapp.GetClient().Download(ctx, media)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if mimetype == "" {
+		mimetype = http.DetectContentType(data)
+	}
+	return data, mimetype, kind, nil
+}
+
+// MediaExtension returns a file extension (with the leading dot) for mimetype.
+func MediaExtension(mimetype string) string {
+	base, _, _ := strings.Cut(mimetype, ";")
+	base = strings.TrimSpace(base)
+
+	switch base {
+	case "image/jpeg":
+		return ".jpg"
+	case "audio/ogg":
+		return ".ogg"
+	case "audio/mpeg":
+		return ".mp3"
+	case "video/mp4":
+		return ".mp4"
+	case "image/webp":
+		return ".webp"
+	}
+	if exts, err := mime.ExtensionsByType(base); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
@@ -1,7 +1,23 @@
 package whatsapp
 
 import (
+	"sync/atomic"
+
 	"go.mau.fi/whatsmeow"
 )
 
-var Client *whatsmeow.Client
+// client holds the active WhatsApp client. It is read from every handler
+// goroutine and can be replaced on re-login, so access goes through
+// GetClient/SetClient instead of a plain package variable.
+var client atomic.Pointer[whatsmeow.Client]
+
+// GetClient returns the active WhatsApp client, or nil before startup has
+// created one.
+func GetClient() *whatsmeow.Client {
+	return client.Load()
+}
+
+// SetClient installs c as the active WhatsApp client.
+func SetClient(c *whatsmeow.Client) {
+	client.Store(c)
+}
@@ -0,0 +1,23 @@
+package whatsapp
+
+import "sync"
+
+var (
+	qrMu     sync.RWMutex
+	qrLatest string
+)
+
+// SetQRCode stores the most recent login QR code. Pass "" once the login
+// flow has finished.
+func SetQRCode(code string) {
+	qrMu.Lock()
+	qrLatest = code
+	qrMu.Unlock()
+}
+
+// GetQRCode returns the most recent login QR code, or "" if none is pending.
+func GetQRCode() string {
+	qrMu.RLock()
+	defer qrMu.RUnlock()
+	return qrLatest
+}
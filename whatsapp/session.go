@@ -0,0 +1,92 @@
+package whatsapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// SessionState describes whether the linked device is still logged in.
+type SessionState struct {
+	LoggedOut bool
+	Reason    string
+	Since     time.Time
+}
+
+var (
+	sessionMu    sync.RWMutex
+	sessionState SessionState
+	reloginFunc  func() error
+)
+
+// MarkLoggedOut records that WhatsApp ended the session remotely.
+func MarkLoggedOut(reason string) {
+	sessionMu.Lock()
+	sessionState = SessionState{LoggedOut: true, Reason: reason, Since: time.Now()}
+	sessionMu.Unlock()
+}
+
+// ClearLoggedOut resets the session state once the client is logged in again.
+func ClearLoggedOut() {
+	sessionMu.Lock()
+	sessionState = SessionState{}
+	sessionMu.Unlock()
+}
+
+// GetSessionState returns the current session state.
+func GetSessionState() SessionState {
+	sessionMu.RLock()
+	defer sessionMu.RUnlock()
+	return sessionState
+}
+
+// SetReloginFunc registers how to build a fresh client and start a new QR
+// login after the old session was logged out. main owns the device store, so
+// it provides this.
+func SetReloginFunc(f func() error) {
+	sessionMu.Lock()
+	reloginFunc = f
+	sessionMu.Unlock()
+}
+
+// Relogin runs the registered relogin function.
+func Relogin() error {
+	sessionMu.RLock()
+	f := reloginFunc
+	sessionMu.RUnlock()
+	if f == nil {
+		return errors.New("relogin is not configured")
+	}
+	return f()
+}
+
+// StartQRLogin connects a client that has no stored session and publishes the
+// login QR codes for /qr in the background until pairing finishes.
+func StartQRLogin(ctx context.Context, c *whatsmeow.Client) error {
+	qrChan, err := c.GetQRChannel(ctx)
+	if err != nil {
+		return err
+	}
+	if err := c.Connect(); err != nil {
+		return err
+	}
+	// Drain the QR channel in the background so the HTTP server (and /qr)
+	// is reachable while waiting for the code to be scanned.
+	go func() {
+		for evt := range qrChan {
+			if evt.Event == "code" {
+				SetQRCode(evt.Code)
+				fmt.Println("QR Code:")
+				fmt.Println(evt.Code)
+			} else {
+				SetQRCode("")
+				fmt.Println("Login event:", evt.Event)
+			}
+		}
+	}()
+	return nil
+}